@@ -2,24 +2,13 @@ package cmd
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
+	"strings"
 
 	"sensorcli/config"
 
 	"github.com/spf13/cobra"
 )
 
-var (
-	configPath string
-	// 配置参数
-	setDefaultBus     int
-	setDefaultTimeout int
-	setLogLevel       string
-	setOutputFormat   string
-	setMockMode       bool
-)
-
 func init() {
 	// 创建主配置命令
 	configCmd := &cobra.Command{
@@ -27,32 +16,89 @@ func init() {
 		Short: "管理配置文件",
 		Long: `管理 SensorCLI 的配置文件。
 
+未指定 --config 时依次合并以下配置文件，后面的覆盖前面的:
+  /etc/sensorcli/config.{yaml,toml,json}      系统级
+  ~/.sensorcli/config.{yaml,toml,json}        用户级
+  .sensorcli.{yaml,toml,json}                 项目级，从根目录到当前目录逐级查找
+
+set、unset 和 reset 修改 --config 指定的文件，默认修改用户级配置文件。
+
 示例:
   sensorcli config show
-  sensorcli config set --default-bus 2
-  sensorcli config reset`,
+  sensorcli config get default_bus
+  sensorcli config set default_bus 2
+  sensorcli config set mock_mode false
+  sensorcli config unset default_bus
+  sensorcli config reset
+  sensorcli --config .sensorcli.yaml config set default_bus 2`,
+		// 配置命令直接读写配置文件，配置内容无效时也要能够修改
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return nil
+		},
 	}
 
 	// 创建子命令
 	showConfigCmd := &cobra.Command{
 		Use:   "show",
-		Short: "显示当前配置",
+		Short: "显示生效配置及每一项的来源",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := loadAppConfig(cmd); err != nil {
+				return err
+			}
 			return showConfig()
 		},
 	}
 
+	getConfigCmd := &cobra.Command{
+		Use:               "get <配置项>",
+		Short:             "显示配置项的生效值",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeConfigKeys,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := loadAppConfig(cmd); err != nil {
+				return err
+			}
+			value, err := appConfig.Get(args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Println(value)
+			return nil
+		},
+	}
+
 	setConfigCmd := &cobra.Command{
-		Use:   "set",
-		Short: "设置配置项",
+		Use:               "set <配置项> <值>",
+		Short:             "在配置文件中设置配置项",
+		Long:              "在配置文件中设置配置项，取值按配置项的类型校验。\n\n" + configKeysHelp(),
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completeConfigKeys,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return setConfig(args[0], args[1])
+		},
+	}
+
+	unsetConfigCmd := &cobra.Command{
+		Use:               "unset <配置项>",
+		Short:             "从配置文件中删除配置项，恢复默认值",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeConfigKeys,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return unsetConfig(args[0])
+		},
+	}
+
+	migrateConfigCmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "把配置文件升级到当前版本 (YAML/TOML 中的注释不会保留)",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return setConfig()
+			return migrateConfig()
 		},
 	}
 
 	resetConfigCmd := &cobra.Command{
 		Use:   "reset",
-		Short: "重置为默认配置",
+		Short: "删除配置文件中的所有配置项，恢复默认配置 (保留命名设备)",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return resetConfig()
 		},
@@ -60,88 +106,173 @@ func init() {
 
 	// 添加子命令
 	configCmd.AddCommand(showConfigCmd)
+	configCmd.AddCommand(getConfigCmd)
 	configCmd.AddCommand(setConfigCmd)
+	configCmd.AddCommand(unsetConfigCmd)
 	configCmd.AddCommand(resetConfigCmd)
+	configCmd.AddCommand(migrateConfigCmd)
 
-	// 全局配置路径参数
-	configCmd.PersistentFlags().StringVarP(&configPath, "config", "c", "", "配置文件路径")
-
-	// set 命令参数
-	setConfigCmd.Flags().IntVarP(&setDefaultBus, "default-bus", "b", 0, "默认I2C总线号")
-	setConfigCmd.Flags().IntVarP(&setDefaultTimeout, "default-timeout", "t", 0, "默认超时时间(毫秒)")
-	setConfigCmd.Flags().StringVarP(&setLogLevel, "log-level", "l", "", "日志级别 (debug, info, warn, error)")
-	setConfigCmd.Flags().StringVarP(&setOutputFormat, "output-format", "f", "", "默认输出格式 (json, csv, hex)")
-	setConfigCmd.Flags().BoolVarP(&setMockMode, "mock-mode", "m", false, "是否启用模拟模式")
+	// 兼容旧版本的 config -c，与全局 --config 是同一个参数
+	configCmd.PersistentFlags().StringVarP(&configPath, "config", "c", "", "配置文件路径 (同全局 --config)")
 
 	// 添加到根命令
 	rootCmd.AddCommand(configCmd)
 }
 
-func showConfig() error {
-	cfg, err := config.LoadConfig(configPath)
+// configKeysHelp 根据配置模式生成配置项说明
+func configKeysHelp() string {
+	var b strings.Builder
+	b.WriteString("配置项:\n")
+	for _, k := range config.Keys() {
+		values := k.Type
+		if len(k.Allowed) > 0 {
+			values = strings.Join(k.Allowed, "|")
+		}
+		fmt.Fprintf(&b, "  %-16s %-24s %s (默认: %s", k.Name, values, k.Doc, k.Default())
+		if k.Env != "" {
+			fmt.Fprintf(&b, "，环境变量 %s", k.Env)
+		}
+		if k.Flag != "" {
+			fmt.Fprintf(&b, "，参数 --%s", k.Flag)
+		}
+		b.WriteString(")\n")
+	}
+	return b.String()
+}
+
+// completeConfigKeys 补全配置项名称，set 的第二个参数补全允许的取值
+func completeConfigKeys(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		var names []string
+		for _, k := range config.Keys() {
+			names = append(names, k.Name+"\t"+k.Doc)
+		}
+		return names, cobra.ShellCompDirectiveNoFileComp
+	}
+	if len(args) == 1 && cmd.Name() == "set" {
+		if k, err := config.LookupKey(args[0]); err == nil {
+			if k.Type == config.TypeBool {
+				return []string{"true", "false"}, cobra.ShellCompDirectiveNoFileComp
+			}
+			return k.Allowed, cobra.ShellCompDirectiveNoFileComp
+		}
+	}
+	return nil, cobra.ShellCompDirectiveNoFileComp
+}
+
+// editConfigFile 读取配置文件的键值，修改后写回
+func editConfigFile(edit func(values map[string]any) error) error {
+	path := config.WritePath(configFilePath())
+	values, err := config.ReadFile(path)
 	if err != nil {
-		return fmt.Errorf("加载配置失败: %v", err)
+		return err
+	}
+	if err := edit(values); err != nil {
+		return err
 	}
+	if err := config.WriteFile(path, values); err != nil {
+		return fmt.Errorf("保存配置失败: %v", err)
+	}
+	return nil
+}
 
+func showConfig() error {
 	fmt.Println("当前配置:")
-	fmt.Printf("  默认总线: %d\n", cfg.DefaultBus)
-	fmt.Printf("  默认超时: %d ms\n", cfg.DefaultTimeout)
-	fmt.Printf("  日志级别: %s\n", cfg.LogLevel)
-	fmt.Printf("  输出格式: %s\n", cfg.OutputFormat)
-	fmt.Printf("  模拟模式: %t\n", cfg.MockMode)
+	for _, k := range config.Keys() {
+		value, _ := appConfig.Get(k.Name)
+		fmt.Printf("  %-16s = %-8s (%s)\n", k.Name, value, appConfig.Source(k.Name))
+	}
+	if n := len(appConfig.Devices); n > 0 {
+		fmt.Printf("  命名设备: %d 个 (sensorcli device list)\n", n)
+	}
 
-	if configPath == "" {
-		homeDir, _ := os.UserHomeDir()
-		defaultPath := filepath.Join(homeDir, ".sensorcli", "config.json")
-		fmt.Printf("\n配置文件路径: %s\n", defaultPath)
-	} else {
-		fmt.Printf("\n配置文件路径: %s\n", configPath)
+	fmt.Println("\n已加载的配置文件 (优先级从低到高):")
+	if len(appConfig.Files) == 0 {
+		fmt.Println("  (无)")
+	}
+	for _, file := range appConfig.Files {
+		fmt.Printf("  %s\n", file)
 	}
+	fmt.Printf("修改配置写入: %s\n", config.WritePath(configFilePath()))
 
 	return nil
 }
 
-func setConfig() error {
-	cfg, err := config.LoadConfig(configPath)
+func setConfig(name, value string) error {
+	k, err := config.LookupKey(name)
 	if err != nil {
-		return fmt.Errorf("加载配置失败: %v", err)
-	}
-
-	// 更新配置
-	if setDefaultBus > 0 {
-		cfg.DefaultBus = setDefaultBus
-	}
-	if setDefaultTimeout > 0 {
-		cfg.DefaultTimeout = setDefaultTimeout
+		return err
 	}
-	if setLogLevel != "" {
-		cfg.LogLevel = setLogLevel
+	v, err := k.Parse(value)
+	if err != nil {
+		return err
 	}
-	if setOutputFormat != "" {
-		cfg.OutputFormat = setOutputFormat
+
+	err = editConfigFile(func(values map[string]any) error {
+		values[k.Name] = v
+		return nil
+	})
+	if err != nil {
+		return err
 	}
-	// MockMode 是布尔值，需要特殊处理
-	// 这里我们直接使用 setMockMode 的值，因为它是通过命令行参数设置的
-	if setMockMode {
-		cfg.MockMode = setMockMode
+
+	fmt.Printf("%s = %v\n", k.Name, v)
+	return nil
+}
+
+func unsetConfig(name string) error {
+	k, err := config.LookupKey(name)
+	if err != nil {
+		return err
 	}
 
-	// 保存配置
-	if err := config.SaveConfig(cfg, configPath); err != nil {
-		return fmt.Errorf("保存配置失败: %v", err)
+	err = editConfigFile(func(values map[string]any) error {
+		delete(values, k.Name)
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	fmt.Println("配置已更新")
-	return showConfig()
+	fmt.Printf("已删除 %s，恢复默认值 %s\n", k.Name, k.Default())
+	return nil
 }
 
 func resetConfig() error {
-	cfg := config.DefaultConfig()
-
-	if err := config.SaveConfig(cfg, configPath); err != nil {
+	err := editConfigFile(func(values map[string]any) error {
+		for _, k := range config.Keys() {
+			delete(values, k.Name)
+		}
+		return nil
+	})
+	if err != nil {
 		return fmt.Errorf("重置配置失败: %v", err)
 	}
 
 	fmt.Println("配置已重置为默认值")
-	return showConfig()
+	return nil
+}
+
+// migrateConfig 重写已加载的配置文件，ReadFile 读取时已迁移到当前版本
+func migrateConfig() error {
+	cfg, err := config.LoadConfig(configFilePath())
+	if err != nil {
+		return fmt.Errorf("加载配置失败: %v", err)
+	}
+	if len(cfg.Files) == 0 {
+		fmt.Println("没有需要迁移的配置文件")
+		return nil
+	}
+
+	for _, file := range cfg.Files {
+		values, err := config.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if err := config.WriteFile(file, values); err != nil {
+			return fmt.Errorf("保存配置失败: %v", err)
+		}
+		fmt.Printf("已迁移 %s 到版本 %d\n", file, config.CurrentVersion)
+	}
+	return nil
 }
@@ -0,0 +1,17 @@
+package cmd
+
+import "testing"
+
+func TestConfigPathShorthand(t *testing.T) {
+	// 旧版本的 config show -c path 仍然可用，且与全局 --config 是同一个参数
+	for _, name := range []string{"show", "get", "set", "reset"} {
+		cmd, _, err := rootCmd.Find([]string{"config", name})
+		if err != nil {
+			t.Fatal(err)
+		}
+		flag := cmd.InheritedFlags().ShorthandLookup("c")
+		if flag == nil || flag.Name != "config" {
+			t.Errorf("config %s 应支持 -c 作为 --config 的简写", name)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"sensorcli/datalog"
+	"sensorcli/i2c"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	logSources     []string
+	logInterval    time.Duration
+	logFormat      string
+	logOutput      string
+	logDuration    time.Duration
+	logSamples     int64
+	logRotateSize  string
+	logRotateEvery time.Duration
+)
+
+var logCmd = &cobra.Command{
+	Use:   "log",
+	Short: "长时间记录寄存器数据",
+	Long: `以固定频率采集一个或多个设备的寄存器，写入按大小或时间轮转的 CSV 或 NDJSON 文件。
+
+采样点固定在 起始时间 + n*间隔 上，不随读取耗时漂移；处理过慢时跳过错过的采样点并记录数量。
+读取失败会记录在对应的采样中，采集继续进行。按 Ctrl+C 停止并写入所有缓冲数据。
+
+采集源格式为 ADDR:REG[:COUNT]，可以重复指定。ADDR 也可以是 @名称 形式的命名设备，
+命名设备必须位于当前总线上。
+
+示例:
+  sensorcli log --source 0x48:0x00:2 --interval 100ms --output run1
+  sensorcli log -s 0x48:0x00:2 -s 0x68:0x3B:6 --format ndjson --duration 1h
+  sensorcli log -s @board_temp:0x00:2 -s @board_imu:0x3B:6
+  sensorcli log -s 0x76:0xF7:8 --rotate-size 10MB --rotate-every 1h --output logs/bme280`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return logRegisters(cmd.Context())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(logCmd)
+
+	logCmd.Flags().StringArrayVarP(&logSources, "source", "s", nil, "采集源 ADDR:REG[:COUNT] 或 @名称:REG[:COUNT]，可重复指定")
+	logCmd.Flags().DurationVarP(&logInterval, "interval", "i", time.Second, "采样间隔")
+	logCmd.Flags().StringVarP(&logFormat, "format", "f", datalog.FormatCSV, "文件格式 (csv, ndjson)")
+	logCmd.Flags().StringVarP(&logOutput, "output", "o", "sensorcli-log", "输出文件前缀")
+	logCmd.Flags().DurationVarP(&logDuration, "duration", "d", 0, "采集时长，0 表示一直采集")
+	logCmd.Flags().Int64VarP(&logSamples, "samples", "n", 0, "采样次数，0 表示不限制")
+	logCmd.Flags().StringVar(&logRotateSize, "rotate-size", "", "单个文件的最大大小，如 10MB")
+	logCmd.Flags().DurationVar(&logRotateEvery, "rotate-every", 0, "单个文件的最长时间跨度，如 1h")
+
+	logCmd.MarkFlagRequired("source")
+}
+
+func logRegisters(ctx context.Context) error {
+	if logInterval <= 0 {
+		return fmt.Errorf("采样间隔必须大于 0")
+	}
+
+	sources := make([]datalog.Source, len(logSources))
+	for i, s := range logSources {
+		s, err := expandSourceDevice(s)
+		if err != nil {
+			return err
+		}
+		src, err := datalog.ParseSource(s)
+		if err != nil {
+			return err
+		}
+		sources[i] = src
+	}
+
+	maxBytes, err := parseSize(logRotateSize)
+	if err != nil {
+		return err
+	}
+
+	// 每个设备只打开一次
+	devices := make(map[uint8]i2c.ContextDevice)
+	defer func() {
+		for _, device := range devices {
+			device.Close()
+		}
+	}()
+	for _, src := range sources {
+		if _, ok := devices[src.Addr]; ok {
+			continue
+		}
+		device, err := openDevice(src.Addr)
+		if err != nil {
+			return fmt.Errorf("打开I2C设备 0x%02X 失败: %w", src.Addr, err)
+		}
+		devices[src.Addr] = device
+	}
+
+	start := time.Now()
+	header := datalog.Header{
+		Version:  Version,
+		Bus:      appConfig.DefaultBus,
+		Interval: logInterval,
+		Started:  start,
+		Sources:  sources,
+	}
+	sink, err := datalog.NewFileSink(logOutput, logFormat,
+		datalog.RotateOptions{MaxBytes: maxBytes, MaxAge: logRotateEvery}, header)
+	if errors.Is(err, datalog.ErrExists) {
+		return fmt.Errorf("%v，请用 --output 指定新的文件前缀", err)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "开始记录 %d 个采集源，间隔 %v，写入 %s (Ctrl+C 停止)\n",
+		len(sources), logInterval, sink.Files()[0])
+
+	var samples, missed, failures int64
+	schedule := datalog.NewSchedule(start, logInterval)
+
+loop:
+	for logSamples == 0 || samples < logSamples {
+		tick, skipped := schedule.Next(time.Now())
+		if logDuration > 0 && tick.Sub(start) >= logDuration {
+			break
+		}
+
+		timer := time.NewTimer(time.Until(tick))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			break loop
+		}
+
+		sample := &datalog.Sample{
+			Seq:      samples,
+			Time:     time.Now(),
+			Missed:   skipped,
+			Readings: make([]datalog.Reading, len(sources)),
+		}
+		sample.Elapsed = sample.Time.Sub(start)
+		for i, src := range sources {
+			data, err := devices[src.Addr].ReadBytesCtx(ctx, src.Reg, src.Count)
+			sample.Readings[i] = datalog.Reading{Source: src, Data: data, Err: err}
+		}
+		// 采样途中被中断时丢弃不完整的采样
+		if ctx.Err() != nil {
+			break loop
+		}
+
+		if err := sink.Write(sample); err != nil {
+			sink.Close()
+			return err
+		}
+		samples++
+		missed += skipped
+		failures += int64(sample.Errors())
+	}
+
+	if err := sink.Close(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "记录结束: %d 次采样，%d 次读取失败，跳过 %d 个采样点\n", samples, failures, missed)
+	for _, file := range sink.Files() {
+		fmt.Fprintf(os.Stderr, "  %s\n", file)
+	}
+	return nil
+}
+
+// expandSourceDevice 把 @名称:REG[:COUNT] 形式的采集源替换为设备地址
+func expandSourceDevice(s string) (string, error) {
+	name, ok := strings.CutPrefix(s, "@")
+	if !ok {
+		return s, nil
+	}
+	name, rest, _ := strings.Cut(name, ":")
+	dev, err := appConfig.Device(name)
+	if err != nil {
+		return "", err
+	}
+	if dev.Bus != appConfig.DefaultBus {
+		return "", fmt.Errorf("设备 %s 位于总线 %d，与当前总线 %d 不同", name, dev.Bus, appConfig.DefaultBus)
+	}
+	return dev.Address.String() + ":" + rest, nil
+}
+
+// parseSize 解析 "512", "64KB", "10MB", "1GB" 形式的大小，空字符串表示不限制
+func parseSize(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+
+	units := []struct {
+		suffix string
+		size   int64
+	}{
+		{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1},
+	}
+
+	upper := strings.ToUpper(strings.TrimSpace(s))
+	multiplier := int64(1)
+	for _, unit := range units {
+		if strings.HasSuffix(upper, unit.suffix) {
+			upper = strings.TrimSpace(strings.TrimSuffix(upper, unit.suffix))
+			multiplier = unit.size
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(upper, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("无效的大小: %s", s)
+	}
+	return n * multiplier, nil
+}
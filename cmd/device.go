@@ -0,0 +1,241 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"sensorcli/config"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var (
+	deviceName   string
+	deviceAddr   uint8
+	deviceRegMap string
+	deviceDriver string
+	deviceForce  bool
+)
+
+func init() {
+	deviceCmd := &cobra.Command{
+		Use:   "device",
+		Short: "管理命名设备",
+		Long: `管理配置文件中的命名设备。
+
+命名设备记录总线号、设备地址、寄存器映射和驱动名称，之后的命令可以用
+--device 名称 或 @名称 代替 --bus 和 --addr，并自动使用设备的寄存器映射。
+命令行中显式指定的参数优先于设备中的设置。
+
+示例:
+  sensorcli device add board_temp --bus 1 --addr 0x48 --regmap tmp102 --driver tmp102
+  sensorcli device list
+  sensorcli read @board_temp --reg 0x00
+  sensorcli dump --device board_temp --reg 0x00 --count 4
+  sensorcli device rm board_temp`,
+	}
+
+	addDeviceCmd := &cobra.Command{
+		Use:   "add <名称>",
+		Short: "添加或更新命名设备 (总线号来自 --bus)",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return addDevice(args[0])
+		},
+	}
+
+	rmDeviceCmd := &cobra.Command{
+		Use:               "rm <名称>",
+		Short:             "删除命名设备",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeDeviceNames,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return removeDevice(args[0])
+		},
+	}
+
+	listDeviceCmd := &cobra.Command{
+		Use:   "list",
+		Short: "列出命名设备",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return listDevices()
+		},
+	}
+
+	deviceCmd.AddCommand(addDeviceCmd)
+	deviceCmd.AddCommand(rmDeviceCmd)
+	deviceCmd.AddCommand(listDeviceCmd)
+
+	addDeviceCmd.Flags().Uint8VarP(&deviceAddr, "addr", "a", 0, "I2C设备地址 (十六进制)")
+	addDeviceCmd.Flags().StringVar(&deviceRegMap, "regmap", "", "寄存器映射 (内置名称或 YAML 文件)")
+	addDeviceCmd.Flags().StringVar(&deviceDriver, "driver", "", "驱动或传感器型号")
+	addDeviceCmd.Flags().BoolVar(&deviceForce, "force", false, "覆盖同名设备")
+	addDeviceCmd.MarkFlagRequired("addr")
+
+	rootCmd.AddCommand(deviceCmd)
+
+	rootCmd.PersistentFlags().StringVar(&deviceName, "device", "", "命名设备，提供默认的总线号、地址和寄存器映射 (也可写作 @名称)")
+	rootCmd.RegisterFlagCompletionFunc("device", completeDeviceNames)
+}
+
+// applyDevice 把命名设备的设置作为未显式指定的 --bus、--addr、--regmap 参数的值
+func applyDevice(cfg *config.Config, flags *pflag.FlagSet) error {
+	if deviceName == "" {
+		return nil
+	}
+	dev, err := cfg.Device(deviceName)
+	if err != nil {
+		return err
+	}
+
+	values := map[string]string{
+		"bus":  strconv.Itoa(dev.Bus),
+		"addr": dev.Address.String(),
+	}
+	if dev.RegMap != "" {
+		values["regmap"] = dev.RegMap
+	}
+	for name, value := range values {
+		if flags.Lookup(name) == nil || flags.Changed(name) {
+			continue
+		}
+		if err := flags.Set(name, value); err != nil {
+			return fmt.Errorf("设备 %s 的 %s 无效: %v", deviceName, name, err)
+		}
+	}
+	return nil
+}
+
+// expandDeviceArgs 把 @名称 形式的参数改写为 --device=名称
+func expandDeviceArgs(args []string) []string {
+	out := make([]string, 0, len(args))
+	for i, arg := range args {
+		if arg == "--" {
+			return append(out, args[i:]...)
+		}
+		if name, ok := strings.CutPrefix(arg, "@"); ok && config.ValidDeviceName(name) == nil {
+			arg = "--device=" + name
+		}
+		out = append(out, arg)
+	}
+	return out
+}
+
+// completeDeviceNames 为 shell 补全提供设备别名
+func completeDeviceNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	cfg, err := config.LoadConfig(configFilePath())
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var names []string
+	for _, name := range cfg.DeviceNames() {
+		if strings.HasPrefix(name, toComplete) {
+			dev := cfg.Devices[name]
+			names = append(names, fmt.Sprintf("%s\t总线 %d 地址 %s", name, dev.Bus, dev.Address))
+		}
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+func addDevice(name string) error {
+	if err := config.ValidDeviceName(name); err != nil {
+		return err
+	}
+	if _, err := loadRegMap(deviceRegMap); err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig(configFilePath())
+	if err != nil {
+		return fmt.Errorf("加载配置失败: %v", err)
+	}
+	if _, exists := cfg.Devices[name]; exists && !deviceForce {
+		return fmt.Errorf("设备 %s 已存在 (定义在 %s)，使用 --force 覆盖", name, cfg.DeviceSource(name))
+	}
+
+	err = editDevices(func(path string, devices map[string]any) error {
+		devices[name] = config.Device{
+			Bus:     appConfig.DefaultBus,
+			Address: config.HexByte(deviceAddr),
+			RegMap:  deviceRegMap,
+			Driver:  deviceDriver,
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("已添加设备 %s: 总线 %d 地址 0x%02X\n", name, appConfig.DefaultBus, deviceAddr)
+	return nil
+}
+
+func removeDevice(name string) error {
+	cfg, err := config.LoadConfig(configFilePath())
+	if err != nil {
+		return fmt.Errorf("加载配置失败: %v", err)
+	}
+	if _, err := cfg.Device(name); err != nil {
+		return err
+	}
+
+	err = editDevices(func(path string, devices map[string]any) error {
+		if _, ok := devices[name]; !ok {
+			return fmt.Errorf("设备 %s 定义在 %s 中，不在 %s 中，请直接编辑该文件", name, cfg.DeviceSource(name), path)
+		}
+		delete(devices, name)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("已删除设备 %s\n", name)
+	return nil
+}
+
+// editDevices 只修改写入目标配置文件 (--config 或用户配置) 中的 devices 部分，
+// 不写入来自其他配置文件的设备
+func editDevices(edit func(path string, devices map[string]any) error) error {
+	path := config.WritePath(configFilePath())
+	return editConfigFile(func(values map[string]any) error {
+		devices, _ := values["devices"].(map[string]any)
+		if devices == nil {
+			devices = make(map[string]any)
+		}
+		if err := edit(path, devices); err != nil {
+			return err
+		}
+		if len(devices) == 0 {
+			delete(values, "devices")
+		} else {
+			values["devices"] = devices
+		}
+		return nil
+	})
+}
+
+func listDevices() error {
+	names := appConfig.DeviceNames()
+	if len(names) == 0 {
+		fmt.Println("尚未定义任何设备，使用 sensorcli device add 添加")
+		return nil
+	}
+
+	fmt.Printf("%-16s %-4s %-6s %-12s %s\n", "名称", "总线", "地址", "寄存器映射", "驱动")
+	for _, name := range names {
+		dev := appConfig.Devices[name]
+		fmt.Printf("%-16s %-4d %-6s %-12s %s\n", name, dev.Bus, dev.Address, orDash(dev.RegMap), orDash(dev.Driver))
+	}
+	return nil
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExpandDeviceArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"无别名", []string{"read", "-a", "0x48", "-r", "0x00"}, []string{"read", "-a", "0x48", "-r", "0x00"}},
+		{"别名", []string{"read", "@temp", "-r", "0x00"}, []string{"read", "--device=temp", "-r", "0x00"}},
+		{"多个别名", []string{"@temp", "dump", "@imu"}, []string{"--device=temp", "dump", "--device=imu"}},
+		{"-- 之后不改写", []string{"write", "@temp", "--", "@imu"}, []string{"write", "--device=temp", "--", "@imu"}},
+		{"-- 之后的参数原样保留", []string{"--", "@temp", "-r"}, []string{"--", "@temp", "-r"}},
+		{"数据源不是设备名", []string{"log", "-s", "@temp:0x00", "-s", "@imu:ACCEL_X"}, []string{"log", "-s", "@temp:0x00", "-s", "@imu:ACCEL_X"}},
+		{"无效的名称", []string{"@", "@-x", "@a b"}, []string{"@", "@-x", "@a b"}},
+		{"空参数", []string{}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := expandDeviceArgs(tt.args); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("期望 %q，实际 %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestApplyDevice(t *testing.T) {
+	const file = "default_bus: 1\ndevices:\n" +
+		"  temp:\n    bus: 3\n    addr: 0x48\n    regmap: tmp102\n" +
+		"  imu:\n    bus: 2\n    addr: 0x68\n"
+
+	tests := []struct {
+		name       string
+		device     string
+		args       []string
+		wantBus    int
+		wantAddr   string
+		wantRegMap string
+	}{
+		{"未指定设备", "", nil, 1, "0", ""},
+		{"设备提供默认值", "temp", nil, 3, "72", "tmp102"},
+		{"参数优先于设备", "temp", []string{"-b", "5", "-a", "0x49", "--regmap", "ina219"}, 5, "73", "ina219"},
+		{"只覆盖部分参数", "temp", []string{"-a", "0x4A"}, 3, "74", "tmp102"},
+		{"设备没有寄存器映射", "imu", nil, 2, "104", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupConfigTest(t, file)
+			deviceName = tt.device
+
+			cmd := newConfigTestCmd(t, tt.args...)
+			if err := loadAppConfig(cmd); err != nil {
+				t.Fatal(err)
+			}
+			flags := cmd.Flags()
+			if appConfig.DefaultBus != tt.wantBus {
+				t.Errorf("总线号: 期望 %d，实际 %d", tt.wantBus, appConfig.DefaultBus)
+			}
+			if got := flags.Lookup("addr").Value.String(); got != tt.wantAddr {
+				t.Errorf("地址: 期望 %s，实际 %s", tt.wantAddr, got)
+			}
+			if got := flags.Lookup("regmap").Value.String(); got != tt.wantRegMap {
+				t.Errorf("寄存器映射: 期望 %q，实际 %q", tt.wantRegMap, got)
+			}
+		})
+	}
+}
+
+func TestApplyDeviceUnknown(t *testing.T) {
+	setupConfigTest(t, "devices:\n  temp:\n    bus: 3\n    addr: 0x48\n")
+	deviceName = "missing"
+
+	if err := loadAppConfig(newConfigTestCmd(t)); err == nil {
+		t.Error("未定义的设备应返回错误")
+	}
+}
@@ -0,0 +1,272 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"sensorcli/snapshot"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffFormat  string
+	diffLive    bool
+	diffAddr    uint8
+	diffRegMap  string
+	diffContext int
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <旧快照> [新快照]",
+	Short: "比较寄存器快照",
+	Long: `按地址对齐两个寄存器快照 (JSON/CSV/HEX)，报告新增、删除和修改的字节。
+
+使用 --live 时将快照与设备的当前状态比较，读取快照中包含的所有寄存器。
+提供寄存器映射时解码发生变化的位域。
+
+没有差异时退出码为 0，存在差异时为 1，出错时为 2 (与 diff 命令相同)，
+可以直接用于 CI 中的硬件在环测试。
+
+示例:
+  sensorcli diff good.json bad.json
+  sensorcli diff good.json bad.json --regmap tmp102
+  sensorcli diff good.json --live
+  sensorcli diff good.json --live --format unified`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if diffLive {
+			return cobra.ExactArgs(1)(cmd, args)
+		}
+		return cobra.ExactArgs(2)(cmd, args)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := diffSnapshots(cmd, args)
+		if err == nil {
+			return nil
+		}
+
+		// 差异和错误都通过退出码区分，不再打印用法
+		cmd.SilenceErrors = true
+		cmd.SilenceUsage = true
+		if _, ok := err.(*exitError); ok {
+			return err
+		}
+		return &exitError{code: 2, err: err}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+
+	diffCmd.Flags().StringVarP(&diffFormat, "format", "f", "human", "输出格式 (human, json, unified)")
+	diffCmd.Flags().BoolVar(&diffLive, "live", false, "与设备的当前状态比较")
+	diffCmd.Flags().Uint8VarP(&diffAddr, "addr", "a", 0, "--live 时的I2C设备地址 (十六进制)，默认使用快照中的地址")
+	diffCmd.Flags().StringVar(&diffRegMap, "regmap", "", "寄存器映射 (内置名称或 YAML 文件)，用于解码变化的位域")
+	diffCmd.Flags().IntVarP(&diffContext, "context", "U", 3, "unified 格式的上下文行数")
+}
+
+func diffSnapshots(cmd *cobra.Command, args []string) error {
+	switch diffFormat {
+	case "human", "json", "unified":
+	default:
+		return fmt.Errorf("不支持的输出格式: %s", diffFormat)
+	}
+
+	regMap, err := loadRegMap(diffRegMap)
+	if err != nil {
+		return err
+	}
+
+	a, err := snapshot.Load(args[0])
+	if err != nil {
+		return err
+	}
+
+	var b *snapshot.Snapshot
+	nameA, nameB := args[0], ""
+	if diffLive {
+		addr := diffAddr
+		if !cmd.Flags().Changed("addr") {
+			addr = a.DeviceAddr
+		}
+		bus := appConfig.DefaultBus
+		if !cmd.Flags().Changed("bus") && a.Bus != 0 {
+			bus = a.Bus
+		}
+		if b, err = readLiveSnapshot(cmd.Context(), bus, addr, a); err != nil {
+			return err
+		}
+		nameB = fmt.Sprintf("设备 0x%02X (总线 %d)", addr, bus)
+	} else {
+		if b, err = snapshot.Load(args[1]); err != nil {
+			return err
+		}
+		nameB = args[1]
+	}
+
+	changes := snapshot.Diff(a, b)
+	if regMap != nil {
+		snapshot.DecodeChanges(regMap, a, b, changes)
+	}
+
+	switch diffFormat {
+	case "json":
+		err = printDiffJSON(nameA, nameB, changes)
+	case "unified":
+		printDiffUnified(nameA, nameB, a, b, changes)
+	default:
+		printDiffHuman(nameA, nameB, changes)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(changes) > 0 {
+		return &exitError{code: 1}
+	}
+	return nil
+}
+
+// readLiveSnapshot 读取设备上与参考快照相同的寄存器
+func readLiveSnapshot(ctx context.Context, bus int, addr uint8, ref *snapshot.Snapshot) (*snapshot.Snapshot, error) {
+	device, err := openBusDevice(bus, addr)
+	if err != nil {
+		return nil, fmt.Errorf("打开I2C设备失败: %w", err)
+	}
+	defer device.Close()
+
+	live := snapshot.New(bus, addr, ref.StartReg, nil)
+	for _, run := range ref.Runs() {
+		data, err := device.ReadBytesCtx(ctx, run.Start, len(run.Data))
+		if err != nil {
+			return nil, fmt.Errorf("读取寄存器 0x%02X 失败: %w", run.Start, err)
+		}
+		live.Registers = append(live.Registers, snapshot.New(bus, addr, run.Start, data).Registers...)
+	}
+	return live, nil
+}
+
+func printDiffHuman(nameA, nameB string, changes []snapshot.Change) {
+	fmt.Printf("比较 %s 与 %s:\n", nameA, nameB)
+	if len(changes) == 0 {
+		fmt.Println("  没有差异")
+		return
+	}
+
+	counts := make(map[string]int)
+	for _, c := range changes {
+		counts[c.Kind]++
+		switch c.Kind {
+		case snapshot.Changed:
+			fmt.Printf("  0x%02X: 0x%02X -> 0x%02X  已修改\n", c.Addr, *c.Old, *c.New)
+		case snapshot.Removed:
+			fmt.Printf("  0x%02X: 0x%02X          仅存在于 %s\n", c.Addr, *c.Old, nameA)
+		case snapshot.Added:
+			fmt.Printf("  0x%02X: 0x%02X          仅存在于 %s\n", c.Addr, *c.New, nameB)
+		}
+		for _, fc := range c.Fields {
+			fmt.Printf("      %s.%s[%s]: %s -> %s\n", fc.Register, fc.Name, fc.Bits,
+				fieldValueString(fc.Old, fc.OldMeaning), fieldValueString(fc.New, fc.NewMeaning))
+		}
+	}
+
+	fmt.Printf("共 %d 处差异: 修改 %d，删除 %d，新增 %d\n",
+		len(changes), counts[snapshot.Changed], counts[snapshot.Removed], counts[snapshot.Added])
+}
+
+func fieldValueString(value uint64, meaning string) string {
+	if meaning == "" {
+		return fmt.Sprint(value)
+	}
+	return fmt.Sprintf("%d (%s)", value, meaning)
+}
+
+func printDiffJSON(nameA, nameB string, changes []snapshot.Change) error {
+	type jsonChange struct {
+		Reg string `json:"reg"`
+		snapshot.Change
+	}
+	out := struct {
+		Old     string       `json:"old"`
+		New     string       `json:"new"`
+		Equal   bool         `json:"equal"`
+		Changes []jsonChange `json:"changes"`
+	}{Old: nameA, New: nameB, Equal: len(changes) == 0, Changes: []jsonChange{}}
+
+	for _, c := range changes {
+		out.Changes = append(out.Changes, jsonChange{Reg: fmt.Sprintf("0x%02X", c.Addr), Change: c})
+	}
+
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return fmt.Errorf("JSON编码失败: %v", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// printDiffUnified 以 diff -u 的形式输出，每行一个寄存器
+func printDiffUnified(nameA, nameB string, a, b *snapshot.Snapshot, changes []snapshot.Change) {
+	if len(changes) == 0 {
+		return
+	}
+
+	// 两个快照中所有寄存器地址的并集
+	seen := make(map[uint8]bool)
+	var addrs []uint8
+	for _, s := range []*snapshot.Snapshot{a, b} {
+		for _, r := range s.Registers {
+			if !seen[r.Addr] {
+				seen[r.Addr] = true
+				addrs = append(addrs, r.Addr)
+			}
+		}
+	}
+	sort.Slice(addrs, func(i, j int) bool { return addrs[i] < addrs[j] })
+
+	changed := make(map[uint8]bool)
+	for _, c := range changes {
+		changed[c.Addr] = true
+	}
+
+	fmt.Printf("--- %s\n+++ %s\n", nameA, nameB)
+	for start := 0; start < len(addrs); {
+		// 找到下一个差异并向前后扩展上下文，相距不超过两倍上下文的差异合并为一段
+		first := start
+		for first < len(addrs) && !changed[addrs[first]] {
+			first++
+		}
+		if first == len(addrs) {
+			break
+		}
+		last := first
+		for next := first + 1; next < len(addrs) && next <= last+2*diffContext; next++ {
+			if changed[addrs[next]] {
+				last = next
+			}
+		}
+		from, to := max(first-diffContext, start), min(last+diffContext, len(addrs)-1)
+
+		var lines []string
+		for _, addr := range addrs[from : to+1] {
+			old, inA := a.Value(addr)
+			cur, inB := b.Value(addr)
+			switch {
+			case inA && inB && old == cur:
+				lines = append(lines, fmt.Sprintf(" 0x%02X 0x%02X", addr, old))
+			default:
+				if inA {
+					lines = append(lines, fmt.Sprintf("-0x%02X 0x%02X", addr, old))
+				}
+				if inB {
+					lines = append(lines, fmt.Sprintf("+0x%02X 0x%02X", addr, cur))
+				}
+			}
+		}
+		fmt.Printf("@@ 0x%02X-0x%02X @@\n%s\n", addrs[from], addrs[to], strings.Join(lines, "\n"))
+		start = to + 1
+	}
+}
@@ -0,0 +1,229 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"sensorcli/i2c"
+	"sensorcli/regmap"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	fieldAddr   uint8
+	fieldReg    uint8
+	fieldBits   string
+	fieldName   string
+	fieldRegMap string
+	fieldValue  uint64
+)
+
+// fieldTarget 待操作的寄存器位域
+type fieldTarget struct {
+	register *regmap.Register
+	name     string
+	msb      int
+	lsb      int
+}
+
+func init() {
+	fieldCmd := &cobra.Command{
+		Use:   "field",
+		Short: "读写寄存器位域",
+		Long: `以读-改-写方式读写寄存器中的位域，写入后回读校验。
+
+位域可以通过 --reg 和 --bits 指定，也可以在提供寄存器映射时通过名称指定
+（--field REGISTER.FIELD，或 --reg 加 --field FIELD）。
+
+示例:
+  sensorcli field get --addr 0x48 --reg 0x01 --bits 6:5
+  sensorcli field set --addr 0x48 --reg 0x01 --bits 6:5 --value 2
+  sensorcli field clear --addr 0x48 --reg 0x01 --bits 0
+  sensorcli field toggle --addr 0x68 --regmap mpu6050 --field PWR_MGMT_1.SLEEP`,
+	}
+
+	getFieldCmd := &cobra.Command{
+		Use:   "get",
+		Short: "读取位域值",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return getField(cmd)
+		},
+	}
+
+	setFieldCmd := &cobra.Command{
+		Use:   "set",
+		Short: "设置位域值",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return modifyField(cmd, "设置", true, func(old, mask uint64, lsb int) uint64 {
+				return old&^mask | fieldValue<<lsb&mask
+			})
+		},
+	}
+	setFieldCmd.Flags().Uint64VarP(&fieldValue, "value", "v", 0, "位域值")
+	setFieldCmd.MarkFlagRequired("value")
+
+	clearFieldCmd := &cobra.Command{
+		Use:   "clear",
+		Short: "将位域清零",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return modifyField(cmd, "清零", false, func(old, mask uint64, lsb int) uint64 {
+				return old &^ mask
+			})
+		},
+	}
+
+	toggleFieldCmd := &cobra.Command{
+		Use:   "toggle",
+		Short: "翻转位域的所有位",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return modifyField(cmd, "翻转", false, func(old, mask uint64, lsb int) uint64 {
+				return old ^ mask
+			})
+		},
+	}
+
+	fieldCmd.AddCommand(getFieldCmd)
+	fieldCmd.AddCommand(setFieldCmd)
+	fieldCmd.AddCommand(clearFieldCmd)
+	fieldCmd.AddCommand(toggleFieldCmd)
+
+	// 公共参数
+	fieldCmd.PersistentFlags().Uint8VarP(&fieldAddr, "addr", "a", 0, "I2C设备地址 (十六进制)")
+	fieldCmd.PersistentFlags().Uint8VarP(&fieldReg, "reg", "r", 0, "寄存器地址 (十六进制)")
+	fieldCmd.PersistentFlags().StringVar(&fieldBits, "bits", "", "位范围，如 5:4 或 3")
+	fieldCmd.PersistentFlags().StringVarP(&fieldName, "field", "f", "", "位域名称 (FIELD 或 REGISTER.FIELD，需要寄存器映射)")
+	fieldCmd.PersistentFlags().StringVar(&fieldRegMap, "regmap", "", "寄存器映射 (内置名称或 YAML 文件)")
+	fieldCmd.MarkPersistentFlagRequired("addr")
+
+	rootCmd.AddCommand(fieldCmd)
+}
+
+// resolveField 根据参数确定寄存器和位范围
+func resolveField(cmd *cobra.Command) (*fieldTarget, error) {
+	if fieldName == "" {
+		if fieldBits == "" {
+			return nil, fmt.Errorf("必须指定 --bits 或 --field")
+		}
+		if !cmd.Flags().Changed("reg") {
+			return nil, fmt.Errorf("使用 --bits 时必须指定 --reg")
+		}
+
+		msb, lsb, err := regmap.ParseBits(fieldBits)
+		if err != nil {
+			return nil, err
+		}
+		if msb > 7 {
+			return nil, fmt.Errorf("位范围 %s 超出 8 位寄存器，请使用寄存器映射描述多字节寄存器", fieldBits)
+		}
+
+		return &fieldTarget{
+			register: &regmap.Register{Name: fmt.Sprintf("0x%02X", fieldReg), Address: fieldReg, Width: 8, Endian: "big"},
+			name:     fmt.Sprintf("[%s]", fieldBits),
+			msb:      msb,
+			lsb:      lsb,
+		}, nil
+	}
+
+	regMap, err := loadRegMap(fieldRegMap)
+	if err != nil {
+		return nil, err
+	}
+	if regMap == nil {
+		return nil, fmt.Errorf("按名称指定位域时必须提供 --regmap")
+	}
+
+	var reg *regmap.Register
+	name := fieldName
+	if regName, fName, ok := strings.Cut(fieldName, "."); ok {
+		reg, ok = regMap.RegisterByName(regName)
+		if !ok {
+			return nil, fmt.Errorf("寄存器映射 %s 中没有寄存器 %s", regMap.Name, regName)
+		}
+		name = fName
+	} else {
+		if !cmd.Flags().Changed("reg") {
+			return nil, fmt.Errorf("位域名称不含寄存器时必须指定 --reg")
+		}
+		reg, ok = regMap.Register(fieldReg)
+		if !ok {
+			return nil, fmt.Errorf("寄存器映射 %s 中没有寄存器 0x%02X", regMap.Name, fieldReg)
+		}
+	}
+
+	field, ok := reg.Field(name)
+	if !ok {
+		return nil, fmt.Errorf("寄存器 %s 中没有位域 %s", reg.Name, name)
+	}
+
+	msb, lsb := field.Range()
+	return &fieldTarget{
+		register: reg,
+		name:     fmt.Sprintf("%s.%s[%s]", reg.Name, field.Name, field.Bits),
+		msb:      msb,
+		lsb:      lsb,
+	}, nil
+}
+
+func getField(cmd *cobra.Command) error {
+	target, err := resolveField(cmd)
+	if err != nil {
+		return err
+	}
+
+	device, err := openDevice(fieldAddr)
+	if err != nil {
+		return fmt.Errorf("打开I2C设备失败: %w", err)
+	}
+	defer device.Close()
+
+	reg := target.register
+	data, err := device.ReadBytesCtx(cmd.Context(), reg.Address, reg.Bytes())
+	if err != nil {
+		return fmt.Errorf("读取寄存器失败: %w", err)
+	}
+
+	value := reg.Value(data)
+	fieldVal := (value & regmap.Mask(target.msb, target.lsb)) >> target.lsb
+	fmt.Printf("设备 0x%02X 寄存器 0x%02X 的值: 0x%0*X\n", fieldAddr, reg.Address, reg.Bytes()*2, value)
+	fmt.Printf("  %s = %d (0x%X)\n", target.name, fieldVal, fieldVal)
+	return nil
+}
+
+// modifyField 对位域执行读-改-写并打印修改前后的值，action 只用于输出；
+// checkValue 为 true 时先检查 --value 是否在位域范围内
+func modifyField(cmd *cobra.Command, action string, checkValue bool, modify func(old, mask uint64, lsb int) uint64) error {
+	target, err := resolveField(cmd)
+	if err != nil {
+		return err
+	}
+
+	width := target.msb - target.lsb + 1
+	if checkValue && width < 64 && fieldValue >= uint64(1)<<width {
+		return fmt.Errorf("值 %d 超出 %d 位位域的范围", fieldValue, width)
+	}
+
+	device, err := openDevice(fieldAddr)
+	if err != nil {
+		return fmt.Errorf("打开I2C设备失败: %w", err)
+	}
+	defer device.Close()
+
+	reg := target.register
+	mask := regmap.Mask(target.msb, target.lsb)
+	before, after, err := i2c.ReadModifyWriteCtx(cmd.Context(), device, reg.Address, reg.Bytes(), func(old []byte) []byte {
+		return reg.Encode(modify(reg.Value(old), mask, target.lsb))
+	})
+	if before == nil {
+		return err
+	}
+
+	oldValue := reg.Value(before)
+	fmt.Printf("设备 0x%02X 寄存器 0x%02X %s位域 %s:\n", fieldAddr, reg.Address, action, target.name)
+	fmt.Printf("  修改前: 0x%0*X  位域值: %d\n", reg.Bytes()*2, oldValue, (oldValue&mask)>>target.lsb)
+	if after != nil {
+		newValue := reg.Value(after)
+		fmt.Printf("  修改后: 0x%0*X  位域值: %d\n", reg.Bytes()*2, newValue, (newValue&mask)>>target.lsb)
+	}
+	return err
+}
@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"sensorcli/i2c"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	mockInput  string
+	mockOutput string
+)
+
+func init() {
+	mockCmd := &cobra.Command{
+		Use:   "mock",
+		Short: "管理模拟总线的持久化状态",
+		Long: `管理模拟模式下跨命令调用保存的寄存器状态。
+
+状态文件保存在 ~/.sensorcli/mock/bus<N>.json，模拟设备打开时加载、关闭时写回。
+
+示例:
+  sensorcli mock show --bus 1
+  sensorcli mock export --bus 1 --output state.json
+  sensorcli mock import --bus 1 --input state.json
+  sensorcli mock reset --bus 1`,
+	}
+
+	resetMockCmd := &cobra.Command{
+		Use:   "reset",
+		Short: "清除持久化状态，恢复描述文件中的初始值",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return resetMockState()
+		},
+	}
+
+	showMockCmd := &cobra.Command{
+		Use:   "show",
+		Short: "显示模拟总线的当前寄存器值",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return showMockState()
+		},
+	}
+
+	exportMockCmd := &cobra.Command{
+		Use:   "export",
+		Short: "导出模拟总线状态为 JSON",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return exportMockState()
+		},
+	}
+
+	importMockCmd := &cobra.Command{
+		Use:   "import",
+		Short: "从 JSON 导入模拟总线状态",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return importMockState()
+		},
+	}
+
+	mockCmd.AddCommand(resetMockCmd)
+	mockCmd.AddCommand(showMockCmd)
+	mockCmd.AddCommand(exportMockCmd)
+	mockCmd.AddCommand(importMockCmd)
+
+	exportMockCmd.Flags().StringVarP(&mockOutput, "output", "o", "", "输出文件路径")
+	importMockCmd.Flags().StringVarP(&mockInput, "input", "i", "", "输入文件路径")
+	importMockCmd.MarkFlagRequired("input")
+
+	rootCmd.AddCommand(mockCmd)
+}
+
+// loadMockBus 构建描述文件与持久化状态叠加后的模拟总线
+func loadMockBus() (*i2c.MockBus, error) {
+	profile := i2c.DefaultMockProfile()
+	if mockProfile != "" {
+		var err error
+		profile, err = i2c.LoadMockProfile(mockProfile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	bus := i2c.NewMockBus(appConfig.DefaultBus, profile)
+	state, err := i2c.LoadMockState(i2c.DefaultMockStateDir(), appConfig.DefaultBus)
+	if err != nil {
+		return nil, err
+	}
+	if err := bus.ApplyState(state); err != nil {
+		return nil, err
+	}
+	return bus, nil
+}
+
+func resetMockState() error {
+	if err := i2c.ResetMockState(i2c.DefaultMockStateDir(), appConfig.DefaultBus); err != nil {
+		return err
+	}
+
+	fmt.Printf("模拟总线 %d 的状态已重置\n", appConfig.DefaultBus)
+	return nil
+}
+
+func showMockState() error {
+	bus, err := loadMockBus()
+	if err != nil {
+		return err
+	}
+
+	state := bus.Snapshot()
+	addrs, err := state.Addresses()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("模拟总线 %d:\n", appConfig.DefaultBus)
+	for _, addr := range addrs {
+		regs, err := state.Registers(addr)
+		if err != nil {
+			return err
+		}
+
+		name := bus.Name(addr)
+		if name != "" {
+			fmt.Printf("设备 0x%02X (%s):\n", addr, name)
+		} else {
+			fmt.Printf("设备 0x%02X:\n", addr)
+		}
+
+		keys := make([]int, 0, len(regs))
+		for reg := range regs {
+			keys = append(keys, int(reg))
+		}
+		sort.Ints(keys)
+		for _, reg := range keys {
+			value := regs[uint8(reg)]
+			fmt.Printf("  0x%02X: 0x%02X (%d)\n", reg, value, value)
+		}
+	}
+
+	fmt.Printf("\n状态文件路径: %s\n", i2c.MockStatePath(i2c.DefaultMockStateDir(), appConfig.DefaultBus))
+	return nil
+}
+
+func exportMockState() error {
+	bus, err := loadMockBus()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(bus.Snapshot(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("JSON编码失败: %v", err)
+	}
+
+	if mockOutput != "" {
+		return os.WriteFile(mockOutput, data, 0644)
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+func importMockState() error {
+	data, err := os.ReadFile(mockInput)
+	if err != nil {
+		return fmt.Errorf("读取文件失败: %v", err)
+	}
+
+	state := i2c.NewMockState(appConfig.DefaultBus)
+	if err := json.Unmarshal(data, state); err != nil {
+		return fmt.Errorf("解析模拟状态失败: %v", err)
+	}
+	state.Bus = appConfig.DefaultBus
+
+	if err := i2c.SaveMockState(i2c.DefaultMockStateDir(), state); err != nil {
+		return err
+	}
+
+	fmt.Printf("已导入模拟总线 %d 的状态: %d 个设备\n", appConfig.DefaultBus, len(state.Devices))
+	return nil
+}
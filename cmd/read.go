@@ -1,18 +1,17 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 
-	"sensorcli/i2c"
-
 	"github.com/spf13/cobra"
 )
 
 var (
-	readAddr  uint8
-	readReg   uint8
-	readBus   int
-	readCount int
+	readAddr   uint8
+	readReg    uint8
+	readCount  int
+	readRegMap string
 )
 
 var readCmd = &cobra.Command{
@@ -22,9 +21,10 @@ var readCmd = &cobra.Command{
 
 示例:
   sensorcli read --addr 0x48 --reg 0x01 --bus 1
-  sensorcli read --addr 0x48 --reg 0x01 --count 4 --bus 1`,
+  sensorcli read --addr 0x48 --reg 0x01 --count 4 --bus 1
+  sensorcli read --addr 0x48 --reg 0x01 --regmap tmp102`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return readRegister()
+		return readRegister(cmd.Context())
 	},
 }
 
@@ -34,45 +34,64 @@ func init() {
 	// 添加参数
 	readCmd.Flags().Uint8VarP(&readAddr, "addr", "a", 0, "I2C设备地址 (十六进制)")
 	readCmd.Flags().Uint8VarP(&readReg, "reg", "r", 0, "寄存器地址 (十六进制)")
-	readCmd.Flags().IntVarP(&readBus, "bus", "b", 1, "I2C总线号")
 	readCmd.Flags().IntVarP(&readCount, "count", "c", 1, "读取字节数")
+	readCmd.Flags().StringVar(&readRegMap, "regmap", "", "寄存器映射 (内置名称或 YAML 文件)，用于解码位域")
 
 	// 设置必需参数
 	readCmd.MarkFlagRequired("addr")
 	readCmd.MarkFlagRequired("reg")
 }
 
-func readRegister() error {
+func readRegister(ctx context.Context) error {
+	regMap, err := loadRegMap(readRegMap)
+	if err != nil {
+		return err
+	}
+
+	// 多字节寄存器按映射中的位宽读取
+	count := readCount
+	if regMap != nil {
+		if reg, ok := regMap.Register(readReg); ok && count < reg.Bytes() {
+			count = reg.Bytes()
+		}
+	}
+
 	// 打开I2C设备
-	device, err := i2c.Open(readBus, readAddr)
+	device, err := openDevice(readAddr)
 	if err != nil {
-		return fmt.Errorf("打开I2C设备失败: %v", err)
+		return fmt.Errorf("打开I2C设备失败: %w", err)
 	}
 	defer device.Close()
 
-	if readCount == 1 {
+	var data []byte
+	if count == 1 {
 		// 读取单个寄存器
-		value, err := device.ReadRegister(readReg)
+		value, err := device.ReadRegisterCtx(ctx, readReg)
 		if err != nil {
-			return fmt.Errorf("读取寄存器失败: %v", err)
+			return fmt.Errorf("读取寄存器失败: %w", err)
 		}
 
 		fmt.Printf("设备 0x%02X 寄存器 0x%02X 的值: 0x%02X (%d)\n",
 			readAddr, readReg, value, value)
+		data = []byte{value}
 	} else {
 		// 读取多个字节
-		data, err := device.ReadBytes(readReg, readCount)
+		data, err = device.ReadBytesCtx(ctx, readReg, count)
 		if err != nil {
-			return fmt.Errorf("读取数据失败: %v", err)
+			return fmt.Errorf("读取数据失败: %w", err)
 		}
 
 		fmt.Printf("设备 0x%02X 寄存器 0x%02X 的 %d 字节数据:\n",
-			readAddr, readReg, readCount)
+			readAddr, readReg, count)
 
 		for i, value := range data {
 			fmt.Printf("  0x%02X: 0x%02X (%d)\n", readReg+uint8(i), value, value)
 		}
 	}
 
+	if regMap != nil {
+		printDecoded(regMap.DecodeBytes(readReg, data))
+	}
+
 	return nil
 }
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"sensorcli/regmap"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	regmapCmd := &cobra.Command{
+		Use:   "regmap",
+		Short: "查看寄存器映射",
+		Long: `查看用于位域解码的寄存器映射。
+
+read 和 dump 命令通过 --regmap 指定内置映射名称或 YAML 文件路径。
+
+示例:
+  sensorcli regmap list
+  sensorcli regmap show tmp102
+  sensorcli read --addr 0x48 --reg 0x01 --regmap tmp102`,
+	}
+
+	listRegmapCmd := &cobra.Command{
+		Use:   "list",
+		Short: "列出内置寄存器映射",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			for _, name := range regmap.BuiltinNames() {
+				m, err := regmap.Builtin(name)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("  %-10s %s\n", name, m.Description)
+			}
+			return nil
+		},
+	}
+
+	showRegmapCmd := &cobra.Command{
+		Use:   "show <名称或文件>",
+		Short: "显示寄存器映射的内容",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			m, err := regmap.Lookup(args[0])
+			if err != nil {
+				return err
+			}
+			printRegMap(m)
+			return nil
+		},
+	}
+
+	regmapCmd.AddCommand(listRegmapCmd)
+	regmapCmd.AddCommand(showRegmapCmd)
+	rootCmd.AddCommand(regmapCmd)
+}
+
+// loadRegMap 加载 --regmap 参数指定的寄存器映射，未指定时返回 nil
+func loadRegMap(nameOrPath string) (*regmap.Map, error) {
+	if nameOrPath == "" {
+		return nil, nil
+	}
+
+	m, err := regmap.Lookup(nameOrPath)
+	if err != nil {
+		return nil, fmt.Errorf("加载寄存器映射失败: %v", err)
+	}
+	return m, nil
+}
+
+// printDecoded 打印解码后的寄存器位域
+func printDecoded(decoded []regmap.Decoded) {
+	for _, d := range decoded {
+		fmt.Printf("  %s (0x%02X) = 0x%X\n", d.Register, d.Address, d.Value)
+		for _, fv := range d.Fields {
+			fmt.Printf("    %s\n", fv)
+		}
+	}
+}
+
+// printRegMap 打印寄存器映射定义
+func printRegMap(m *regmap.Map) {
+	fmt.Printf("%s: %s\n", m.Name, m.Description)
+	for _, reg := range m.Registers {
+		fmt.Printf("  0x%02X %-12s %2d位 %s  %s\n", reg.Address, reg.Name, reg.Width, reg.Access, reg.Description)
+		for _, field := range reg.Fields {
+			fmt.Printf("         %-16s [%s] %s\n", field.Name, field.Bits, field.Description)
+			values := make([]int, 0, len(field.Enum))
+			for value := range field.Enum {
+				values = append(values, value)
+			}
+			sort.Ints(values)
+			for _, value := range values {
+				fmt.Printf("           %d = %s\n", value, field.Enum[value])
+			}
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"fmt"
+
+	"sensorcli/regmap"
+	"sensorcli/snapshot"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	restoreInput  string
+	restoreAddr   uint8
+	restoreRegMap string
+	restoreDryRun bool
+)
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "将导出的寄存器数据写回设备",
+	Long: `将 dump 命令导出的快照 (JSON/CSV/HEX) 写回设备，写入后回读校验。
+
+连续的寄存器合并为一次写入。提供寄存器映射时跳过只读寄存器，
+只写寄存器不参与校验。任何寄存器校验不一致时返回错误。
+
+未指定 --addr 和 --bus 时使用快照中记录的设备地址和总线号。
+
+示例:
+  sensorcli restore --input data.json
+  sensorcli restore --input data.json --regmap tmp102
+  sensorcli restore --input data.csv --addr 0x48 --dry-run`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return restoreRegisters(cmd)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(restoreCmd)
+
+	restoreCmd.Flags().StringVarP(&restoreInput, "input", "i", "", "快照文件路径")
+	restoreCmd.Flags().Uint8VarP(&restoreAddr, "addr", "a", 0, "I2C设备地址 (十六进制)，默认使用快照中的地址")
+	restoreCmd.Flags().StringVar(&restoreRegMap, "regmap", "", "寄存器映射 (内置名称或 YAML 文件)，用于跳过只读寄存器")
+	restoreCmd.Flags().BoolVar(&restoreDryRun, "dry-run", false, "只显示将要写入的内容，不访问设备")
+
+	restoreCmd.MarkFlagRequired("input")
+}
+
+func restoreRegisters(cmd *cobra.Command) error {
+	snap, err := snapshot.Load(restoreInput)
+	if err != nil {
+		return err
+	}
+	if len(snap.Registers) == 0 {
+		return fmt.Errorf("快照中没有寄存器数据")
+	}
+
+	addr := restoreAddr
+	if !cmd.Flags().Changed("addr") {
+		// CSV 和 hex 快照不记录设备地址
+		if snap.DeviceAddr == 0 {
+			return fmt.Errorf("快照不含设备地址，请用 --addr 指定")
+		}
+		addr = snap.DeviceAddr
+	}
+	bus := appConfig.DefaultBus
+	if !cmd.Flags().Changed("bus") && snap.Bus != 0 {
+		bus = snap.Bus
+	}
+
+	regMap, err := loadRegMap(restoreRegMap)
+	if err != nil {
+		return err
+	}
+	access := registerAccess(regMap)
+
+	// 去掉只读寄存器后重新分段
+	var writable snapshot.Snapshot
+	var skipped []snapshot.Register
+	for _, r := range snap.Registers {
+		if access[r.Addr] == regmap.AccessReadOnly {
+			skipped = append(skipped, r)
+			continue
+		}
+		writable.Registers = append(writable.Registers, r)
+	}
+	runs := writable.Runs()
+
+	fmt.Printf("从 %s 恢复到设备 0x%02X (总线 %d):\n", restoreInput, addr, bus)
+	for _, r := range skipped {
+		fmt.Printf("  跳过只读寄存器 0x%02X%s\n", r.Addr, registerName(regMap, r.Addr))
+	}
+	for _, run := range runs {
+		fmt.Printf("  写入 0x%02X-0x%02X (%d 字节): % X\n", run.Start, int(run.Start)+len(run.Data)-1, len(run.Data), run.Data)
+	}
+	if restoreDryRun {
+		return nil
+	}
+
+	device, err := openBusDevice(bus, addr)
+	if err != nil {
+		return fmt.Errorf("打开I2C设备失败: %w", err)
+	}
+	defer device.Close()
+
+	for _, run := range runs {
+		if err := device.WriteBytesCtx(cmd.Context(), run.Start, run.Data); err != nil {
+			return fmt.Errorf("写入寄存器 0x%02X 失败: %w", run.Start, err)
+		}
+	}
+
+	// 回读校验，只写寄存器无法回读
+	verified, mismatches := 0, 0
+	fmt.Println("校验结果:")
+	for _, run := range runs {
+		actual, err := device.ReadBytesCtx(cmd.Context(), run.Start, len(run.Data))
+		if err != nil {
+			return fmt.Errorf("回读寄存器 0x%02X 失败: %w", run.Start, err)
+		}
+		for i, want := range run.Data {
+			reg := run.Start + uint8(i)
+			if access[reg] == regmap.AccessWriteOnly {
+				continue
+			}
+			verified++
+			if actual[i] != want {
+				mismatches++
+				fmt.Printf("  0x%02X%s: 期望 0x%02X，实际 0x%02X\n", reg, registerName(regMap, reg), want, actual[i])
+			}
+		}
+	}
+	if mismatches == 0 {
+		fmt.Println("  全部一致")
+	}
+
+	fmt.Printf("恢复完成: 写入 %d 个寄存器，跳过 %d 个，校验 %d 个，不一致 %d 个\n",
+		len(writable.Registers), len(skipped), verified, mismatches)
+	if mismatches > 0 {
+		return fmt.Errorf("%d 个寄存器校验不一致", mismatches)
+	}
+	return nil
+}
+
+// registerAccess 按字节地址整理寄存器映射中的访问模式
+func registerAccess(m *regmap.Map) map[uint8]string {
+	access := make(map[uint8]string)
+	if m == nil {
+		return access
+	}
+	for _, reg := range m.Registers {
+		for i := 0; i < reg.Bytes(); i++ {
+			access[reg.Address+uint8(i)] = reg.Access
+		}
+	}
+	return access
+}
+
+// registerName 返回寄存器映射中包含该地址的寄存器名称，形如 " (CONFIG)"
+func registerName(m *regmap.Map, addr uint8) string {
+	if m == nil {
+		return ""
+	}
+	if reg := m.Containing(addr); reg != nil {
+		return fmt.Sprintf(" (%s)", reg.Name)
+	}
+	return ""
+}
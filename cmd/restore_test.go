@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRestoreRequiresAddr(t *testing.T) {
+	setupConfigTest(t, "")
+	input := filepath.Join(t.TempDir(), "data.csv")
+	if err := os.WriteFile(input, []byte("Register,Value,Decimal\n0x01,0x60,96\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	oldInput, oldDryRun := restoreInput, restoreDryRun
+	t.Cleanup(func() { restoreInput, restoreDryRun = oldInput, oldDryRun })
+	restoreInput, restoreDryRun = input, true
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{"CSV 快照未指定地址", nil, "--addr"},
+		{"指定地址", []string{"-a", "0x48"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := newConfigTestCmd(t, tt.args...)
+			if err := loadAppConfig(cmd); err != nil {
+				t.Fatal(err)
+			}
+			err := restoreRegisters(cmd)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("期望成功，得到 %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("期望包含 %q 的错误，得到 %v", tt.wantErr, err)
+			}
+		})
+	}
+}
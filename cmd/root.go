@@ -1,14 +1,42 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"sensorcli/config"
+	"sensorcli/i2c"
+	"sensorcli/logger"
 
 	"github.com/spf13/cobra"
 )
 
 const Version = "1.0.0"
 
+var (
+	mockProfile string
+	configPath  string
+	flagBus     int
+	flagTimeout time.Duration
+	flagRetries int
+	flagMock    bool
+	flagLogLvl  string
+	flagLogFile string
+	flagLogFmt  string
+	flagReplay  string
+	flagRplMode string
+	tracePath   string
+	recordPath  string
+
+	// appConfig 合并配置文件、环境变量和命令行参数后的生效配置
+	appConfig = config.DefaultConfig()
+)
+
 var rootCmd = &cobra.Command{
 	Use:   "sensorcli",
 	Short: "I2C 调试命令行工具",
@@ -24,17 +52,225 @@ var rootCmd = &cobra.Command{
   sensorcli scan --bus 1
   sensorcli read --addr 0x48 --reg 0x01 --bus 1
   sensorcli write --addr 0x48 --reg 0x02 --value 0x55 --bus 1
-  sensorcli dump --addr 0x48 --reg 0x00 --count 16 --format json`,
+  sensorcli dump --addr 0x48 --reg 0x00 --count 16 --format json
+
+全局参数的默认值依次来自命令行参数、SENSORCLI_* 环境变量、配置文件和内置默认值。`,
 	Version: Version,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-		// 全局初始化逻辑
-		return nil
+		return loadAppConfig(cmd)
+	},
+	PersistentPostRunE: func(cmd *cobra.Command, args []string) error {
+		return finishSession()
 	},
 }
 
+func init() {
+	defaults := config.DefaultConfig()
+
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", "", "配置文件路径，指定后只读取该文件 (或 $"+config.EnvConfig+")，默认合并系统级、用户级和项目级配置")
+	rootCmd.PersistentFlags().IntVarP(&flagBus, "bus", "b", defaults.DefaultBus, "I2C总线号")
+	rootCmd.PersistentFlags().DurationVar(&flagTimeout, "timeout", defaults.Timeout(), "I2C操作超时时间")
+	rootCmd.PersistentFlags().IntVar(&flagRetries, "retries", defaults.DefaultRetries, "I2C操作重试次数")
+	rootCmd.PersistentFlags().BoolVar(&flagMock, "mock", defaults.MockMode, "使用模拟I2C总线")
+	rootCmd.PersistentFlags().StringVar(&flagLogLvl, "log-level", defaults.LogLevel, "日志级别 (debug, info, warn, error)")
+	rootCmd.PersistentFlags().StringVar(&flagLogFile, "log-file", "", "日志文件路径 (默认写入标准错误)")
+	rootCmd.PersistentFlags().StringVar(&flagLogFmt, "log-format", defaults.LogFormat, "日志格式 (text, json)")
+	rootCmd.PersistentFlags().StringVar(&mockProfile, "mock-profile", "", "模拟总线描述文件 (YAML/JSON)")
+	rootCmd.PersistentFlags().StringVar(&tracePath, "trace", "", "把总线事务写入 pcap 文件，可用 Wireshark 或 sensorcli trace show 查看")
+	rootCmd.PersistentFlags().StringVar(&recordPath, "record", "", "把所有设备请求和响应录制到文件，供 --replay 回放")
+	rootCmd.PersistentFlags().StringVar(&flagReplay, "replay", "", "回放录制文件，不访问总线")
+	rootCmd.PersistentFlags().StringVar(&flagRplMode, "replay-mode", defaults.ReplayMode, "回放匹配模式 (strict, lenient)")
+
+	cobra.OnFinalize(closeSession)
+}
+
+// loadAppConfig 按 参数 > 环境变量 > 配置文件 > 默认值 的优先级生成生效配置
+func loadAppConfig(cmd *cobra.Command) error {
+	cfg, err := config.LoadConfig(configFilePath())
+	if err != nil {
+		return fmt.Errorf("加载配置失败: %v", err)
+	}
+	if err := cfg.ApplyEnv(); err != nil {
+		return err
+	}
+
+	flags := cmd.Flags()
+	if err := applyDevice(cfg, flags); err != nil {
+		return err
+	}
+	for _, k := range config.Keys() {
+		if k.Flag == "" || !flags.Changed(k.Flag) {
+			continue
+		}
+		src := config.Source{Kind: config.SourceFlag, Detail: k.Flag}
+		if err := cfg.Set(k.Name, flags.Lookup(k.Flag).Value.String(), src); err != nil {
+			return fmt.Errorf("参数 --%s 无效: %v", k.Flag, err)
+		}
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("配置无效: %v", err)
+	}
+
+	if err := logger.Setup(cfg.LogOptions()); err != nil {
+		return err
+	}
+
+	appConfig = cfg
+	return nil
+}
+
+// configFilePath 返回 --config 或 SENSORCLI_CONFIG 指定的配置文件，都未指定时为空
+func configFilePath() string {
+	if configPath != "" {
+		return configPath
+	}
+	return os.Getenv(config.EnvConfig)
+}
+
+// openDevice 在全局参数指定的总线上打开I2C设备
+func openDevice(addr uint8) (i2c.ContextDevice, error) {
+	return openBusDevice(appConfig.DefaultBus, addr)
+}
+
+// openBusDevice 在指定总线上打开I2C设备，所有操作记录到日志
+func openBusDevice(bus int, addr uint8) (i2c.ContextDevice, error) {
+	device, err := openRawDevice(bus, addr)
+	if err != nil {
+		return nil, err
+	}
+	return i2c.WithContext(i2c.NewLoggingDevice(device)), nil
+}
+
+// openRawDevice 在指定总线上打开I2C设备，其余设置来自生效配置
+func openRawDevice(bus int, addr uint8) (i2c.ContextDevice, error) {
+	dc := deviceConfig(bus, addr)
+	dc.Retry = sessionRetryPolicy(dc)
+	return openConfigDevice(dc)
+}
+
+// openProbeDevice 打开扫描探测用的设备。不重试: 空地址未应答是预期的结果，
+// 而许多适配器把地址阶段的未应答报告为可重试的 NACK
+func openProbeDevice(bus int, addr uint8) (i2c.ContextDevice, error) {
+	dc := deviceConfig(bus, addr)
+	dc.Retries = 0
+	return openConfigDevice(dc)
+}
+
+// deviceConfig 按生效配置生成设备配置
+func deviceConfig(bus int, addr uint8) *i2c.DeviceConfig {
+	dc := i2c.DefaultConfig()
+	dc.Bus = bus
+	dc.Address = addr
+	dc.Timeout = appConfig.Timeout()
+	dc.Retries = appConfig.DefaultRetries
+	dc.MockMode = appConfig.MockMode
+	dc.MockProfile = mockProfile
+	dc.MockStateDir = i2c.DefaultMockStateDir()
+	return dc
+}
+
+// openConfigDevice 按回放、录制或直接访问总线的方式打开设备
+func openConfigDevice(dc *i2c.DeviceConfig) (i2c.ContextDevice, error) {
+	device, err := openSessionDevice(dc)
+	if err != nil {
+		return nil, err
+	}
+	return i2c.WithContext(device), nil
+}
+
+// exitError 以指定退出码结束程序，err 为空时不打印错误信息，
+// 用于 diff 等以退出码表示结果的命令
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	if e.err != nil {
+		return e.err.Error()
+	}
+	return fmt.Sprintf("退出码 %d", e.code)
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// 退出码，按 I2C 错误分类区分，见 README 的“退出码”一节
+const (
+	exitFailure        = 1
+	exitInvalidAddress = 3
+	exitNoDevice       = 4
+	exitNACK           = 5
+	exitTimeout        = 6
+	exitBusBusy        = 7
+	exitClosed         = 8
+	exitCanceled       = 130
+)
+
+// exitCodes 错误分类对应的退出码，按顺序匹配
+var exitCodes = []struct {
+	err  error
+	code int
+}{
+	{i2c.ErrInvalidAddress, exitInvalidAddress},
+	{i2c.ErrNoDevice, exitNoDevice},
+	{i2c.ErrNACK, exitNACK},
+	{i2c.ErrTimeout, exitTimeout},
+	{context.DeadlineExceeded, exitTimeout},
+	{i2c.ErrBusBusy, exitBusBusy},
+	{i2c.ErrClosed, exitClosed},
+}
+
+// exitCode 返回错误对应的退出码，未分类的错误为 1
+func exitCode(err error) int {
+	var exit *exitError
+	if errors.As(err, &exit) {
+		return exit.code
+	}
+	for _, c := range exitCodes {
+		if errors.Is(err, c.err) {
+			return c.code
+		}
+	}
+	return exitFailure
+}
+
+// quietOnCancel 命令因取消而失败时不打印错误和用法，由 Execute 统一提示
+func quietOnCancel(c *cobra.Command) {
+	if run := c.RunE; run != nil {
+		c.RunE = func(cmd *cobra.Command, args []string) error {
+			err := run(cmd, args)
+			if err != nil && cmd.Context().Err() != nil {
+				cmd.SilenceErrors = true
+				cmd.SilenceUsage = true
+			}
+			return err
+		}
+	}
+	for _, sub := range c.Commands() {
+		quietOnCancel(sub)
+	}
+}
+
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
-		os.Exit(1)
+	// Ctrl-C 或 SIGTERM 取消命令的 context，正在进行的传输被中止
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	quietOnCancel(rootCmd)
+	rootCmd.SetArgs(expandDeviceArgs(os.Args[1:]))
+	err := rootCmd.ExecuteContext(ctx)
+	if err != nil && ctx.Err() != nil {
+		fmt.Fprintln(os.Stderr, "已取消")
+		stop()
+		os.Exit(exitCanceled)
+	}
+	if err != nil {
+		var exit *exitError
+		if !errors.As(err, &exit) || exit.err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		}
+		os.Exit(exitCode(err))
 	}
 }
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"sensorcli/config"
+
+	"github.com/spf13/cobra"
+)
+
+// newConfigTestCmd 创建带有全局参数的命令并解析 args，不修改 rootCmd 的参数
+func newConfigTestCmd(t *testing.T, args ...string) *cobra.Command {
+	t.Helper()
+	var (
+		bus, retries     int
+		timeout          string
+		logLevel, regMap string
+		addr             uint8
+	)
+	cmd := &cobra.Command{Use: "test"}
+	flags := cmd.Flags()
+	flags.IntVarP(&bus, "bus", "b", 1, "")
+	flags.IntVar(&retries, "retries", 3, "")
+	flags.StringVar(&timeout, "timeout", "", "")
+	flags.StringVar(&logLevel, "log-level", "", "")
+	flags.Uint8VarP(&addr, "addr", "a", 0, "")
+	flags.StringVar(&regMap, "regmap", "", "")
+	if err := flags.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	return cmd
+}
+
+// setupConfigTest 写入临时配置文件并清空 SENSORCLI_* 环境变量，测试结束后恢复全局状态
+func setupConfigTest(t *testing.T, content string) {
+	t.Helper()
+	for _, k := range config.Keys() {
+		if k.Env != "" {
+			t.Setenv(k.Env, "")
+		}
+	}
+	t.Setenv(config.EnvConfig, "")
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	oldPath, oldDevice, oldConfig := configPath, deviceName, appConfig
+	t.Cleanup(func() { configPath, deviceName, appConfig = oldPath, oldDevice, oldConfig })
+	configPath = path
+	deviceName = ""
+}
+
+func TestLoadAppConfigPrecedence(t *testing.T) {
+	const file = "default_bus: 3\ndefault_retries: 2\n"
+
+	tests := []struct {
+		name     string
+		env      string
+		args     []string
+		wantBus  int
+		wantKind string
+	}{
+		{"文件", "", nil, 3, config.SourceFile},
+		{"环境变量覆盖文件", "4", nil, 4, config.SourceEnv},
+		{"参数覆盖环境变量", "4", []string{"--bus", "5"}, 5, config.SourceFlag},
+		{"参数覆盖文件", "", []string{"-b", "6"}, 6, config.SourceFlag},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupConfigTest(t, file)
+			t.Setenv(config.EnvBus, tt.env)
+
+			if err := loadAppConfig(newConfigTestCmd(t, tt.args...)); err != nil {
+				t.Fatal(err)
+			}
+			if appConfig.DefaultBus != tt.wantBus {
+				t.Errorf("总线号: 期望 %d，实际 %d", tt.wantBus, appConfig.DefaultBus)
+			}
+			if src := appConfig.Source("default_bus"); src.Kind != tt.wantKind {
+				t.Errorf("总线号来源: 期望 %s，实际 %s", tt.wantKind, src)
+			}
+
+			// 其他配置项不受影响: 文件中的重试次数和未设置的默认超时
+			if appConfig.DefaultRetries != 2 || appConfig.Source("default_retries").Kind != config.SourceFile {
+				t.Errorf("重试次数应来自文件: %d (%s)", appConfig.DefaultRetries, appConfig.Source("default_retries"))
+			}
+			if src := appConfig.Source("default_timeout"); src.Kind != config.SourceDefault {
+				t.Errorf("超时应为默认值，实际来自 %s", src)
+			}
+		})
+	}
+}
+
+func TestLoadAppConfigInvalidEnv(t *testing.T) {
+	setupConfigTest(t, "default_bus: 3\n")
+	t.Setenv(config.EnvRetries, "many")
+
+	if err := loadAppConfig(newConfigTestCmd(t)); err == nil {
+		t.Error("无效的环境变量应返回错误")
+	}
+}
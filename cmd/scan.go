@@ -1,14 +1,12 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 
-	"github.com/spf13/cobra"
-	"sensorcli/i2c"
-)
+	"sensorcli/logger"
 
-var (
-	scanBus int
+	"github.com/spf13/cobra"
 )
 
 var scanCmd = &cobra.Command{
@@ -19,19 +17,16 @@ var scanCmd = &cobra.Command{
 示例:
   sensorcli scan --bus 1`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return scanDevices()
+		return scanDevices(cmd.Context())
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(scanCmd)
-	
-	// 添加参数
-	scanCmd.Flags().IntVarP(&scanBus, "bus", "b", 1, "I2C总线号")
 }
 
-func scanDevices() error {
-	fmt.Printf("扫描I2C总线 %d 上的设备...\n", scanBus)
+func scanDevices(ctx context.Context) error {
+	fmt.Printf("扫描I2C总线 %d 上的设备...\n", appConfig.DefaultBus)
 	
 	foundDevices := 0
 	
@@ -41,14 +36,20 @@ func scanDevices() error {
 		if addr >= 0x78 && addr <= 0x7F {
 			continue
 		}
+		// 扫描中忽略读取错误，取消时需要单独退出
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		
-		device, err := i2c.Open(scanBus, addr)
+		// 空地址必然读取失败，不逐次记录，只记录扫描结果
+		device, err := openProbeDevice(appConfig.DefaultBus, addr)
 		if err != nil {
 			continue
 		}
 		
 		// 尝试读取一个寄存器来检测设备是否存在
-		_, err = device.ReadRegister(0x00)
+		_, err = device.ReadRegisterCtx(ctx, 0x00)
+		logger.NewDeviceLogger(appConfig.DefaultBus, addr).LogScan(err == nil)
 		if err == nil {
 			fmt.Printf("发现设备: 0x%02X\n", addr)
 			foundDevices++
@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"sensorcli/i2c"
+	"sensorcli/logger"
+	"sensorcli/trace"
+)
+
+// 一次命令执行期间的总线跟踪 (--trace)、录制 (--record) 和回放 (--replay)，
+// 第一次打开设备时创建，命令结束时保存
+var (
+	traceWriter *trace.Writer
+	recorder    *i2c.Recorder
+	player      *i2c.Player
+
+	// sessionFinished 命令成功结束时已由 finishSession 保存
+	sessionFinished bool
+
+	// retryStats 本次命令所有设备的重试统计
+	retryStats i2c.RetryStats
+
+	// metrics 本次命令所有设备的操作统计
+	metrics = i2c.NewMetrics()
+)
+
+// sessionRetryPolicy 按 --retries 和 --timeout 生成重试策略，每次尝试的超时为 --timeout
+func sessionRetryPolicy(dc *i2c.DeviceConfig) *i2c.RetryPolicy {
+	policy := i2c.DefaultRetryPolicy()
+	policy.MaxAttempts = dc.Retries + 1
+	policy.AttemptTimeout = dc.Timeout
+	policy.Stats = &retryStats
+	return policy
+}
+
+// openSessionDevice 按回放、录制或直接访问总线的方式打开设备，所有操作计入统计，
+// 指定 --trace 时记录所有总线事务
+func openSessionDevice(dc *i2c.DeviceConfig) (i2c.Device, error) {
+	open, err := sessionOpener()
+	if err != nil {
+		return nil, err
+	}
+
+	if tracePath != "" && traceWriter == nil {
+		w, err := trace.Create(tracePath)
+		if err != nil {
+			return nil, err
+		}
+		traceWriter = w
+	}
+	// 跟踪和统计在重试之内，记录每次实际发生在总线上的尝试
+	dc.BusMiddleware = append(dc.BusMiddleware, metrics.Middleware())
+	if traceWriter != nil {
+		dc.BusMiddleware = append(dc.BusMiddleware, i2c.Tracing(traceWriter))
+	}
+	return open(dc)
+}
+
+// sessionOpener 返回打开设备的函数，回放时不访问总线
+func sessionOpener() (func(*i2c.DeviceConfig) (i2c.Device, error), error) {
+	switch {
+	case appConfig.ReplayFile != "":
+		if recordPath != "" {
+			return nil, fmt.Errorf("--record 不能与回放 (%s) 同时使用", appConfig.ReplayFile)
+		}
+		if player == nil {
+			cassette, err := i2c.LoadCassette(appConfig.ReplayFile)
+			if err != nil {
+				return nil, err
+			}
+			p, err := i2c.NewPlayer(cassette, appConfig.ReplayMode)
+			if err != nil {
+				return nil, err
+			}
+			player = p
+		}
+		return player.Open, nil
+	case recordPath != "":
+		if recorder == nil {
+			recorder = i2c.NewRecorder()
+		}
+		return recorder.Open, nil
+	default:
+		return i2c.OpenWithConfig, nil
+	}
+}
+
+// finishSession 在命令成功结束后保存录制文件，并检查回放是否有不匹配或未执行的请求
+func finishSession() error {
+	sessionFinished = true
+	if err := saveRecording(); err != nil {
+		return err
+	}
+	if player != nil {
+		return player.Finish()
+	}
+	return nil
+}
+
+// saveRecording 保存 --record 指定的录制文件
+func saveRecording() error {
+	if recorder == nil {
+		return nil
+	}
+	if err := recorder.Save(recordPath); err != nil {
+		return err
+	}
+	logger.Info("已录制 %d 个请求到 %s", len(recorder.Cassette().Interactions), recordPath)
+	return nil
+}
+
+// closeSession 在程序结束时执行，命令失败时也保存录制文件并关闭跟踪文件
+func closeSession() {
+	if !sessionFinished {
+		if err := saveRecording(); err != nil {
+			logger.Error("%v", err)
+		}
+	}
+
+	if c := retryStats.Counts(); c.Retries > 0 {
+		logger.Info("%d 个操作中 %d 个经过重试，共重试 %d 次，%d 个最终失败", c.Operations, c.Retried, c.Retries, c.Failures)
+	}
+
+	for _, kind := range []string{i2c.OpRead, i2c.OpWrite, i2c.OpTransfer} {
+		if st, ok := metrics.Stats()[kind]; ok {
+			logger.Debug("%s: %d 次，%d 次失败，%d 字节，平均耗时 %v",
+				kind, st.Count, st.Errors, st.Bytes, st.Latency/time.Duration(st.Count))
+		}
+	}
+
+	if traceWriter != nil {
+		if err := traceWriter.Close(); err != nil {
+			logger.Error("%v", err)
+		} else {
+			logger.Info("已记录 %d 条 I2C 消息到 %s", traceWriter.Count(), tracePath)
+		}
+	}
+	traceWriter, recorder, player, sessionFinished = nil, nil, nil, false
+	metrics = i2c.NewMetrics()
+}
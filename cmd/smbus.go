@@ -0,0 +1,269 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"sensorcli/i2c"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	smbusAddr uint8
+	smbusPEC  bool
+	smbusCmd  uint8
+	smbusByte uint8
+	smbusWord uint16
+	smbusData []string
+	smbusRead bool
+)
+
+func init() {
+	smbusCmdRoot := &cobra.Command{
+		Use:   "smbus",
+		Short: "执行SMBus协议命令",
+		Long: `在I2C设备上执行SMBus协议命令，可选启用包错误校验(PEC)。
+
+示例:
+  sensorcli smbus read-word --addr 0x0B --cmd 0x09
+  sensorcli smbus write-byte --addr 0x0B --cmd 0x01 --value 0x80 --pec
+  sensorcli smbus block-read --addr 0x0B --cmd 0x20
+  sensorcli smbus block-write --addr 0x0B --cmd 0x20 --data 0x01,0x02,0x03`,
+	}
+
+	quickCmd := &cobra.Command{
+		Use:   "quick",
+		Short: "快速命令",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSMBus(cmd.Context(), func(bus *i2c.SMBus) error {
+				if err := bus.QuickCommand(smbusRead); err != nil {
+					return err
+				}
+				fmt.Printf("设备 0x%02X 应答快速命令\n", smbusAddr)
+				return nil
+			})
+		},
+	}
+	quickCmd.Flags().BoolVar(&smbusRead, "read", false, "发送读方向的快速命令")
+
+	sendByteCmd := &cobra.Command{
+		Use:   "send-byte",
+		Short: "发送字节",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSMBus(cmd.Context(), func(bus *i2c.SMBus) error {
+				if err := bus.SendByte(smbusByte); err != nil {
+					return err
+				}
+				fmt.Printf("已向设备 0x%02X 发送字节: 0x%02X\n", smbusAddr, smbusByte)
+				return nil
+			})
+		},
+	}
+	sendByteCmd.Flags().Uint8VarP(&smbusByte, "value", "v", 0, "发送的字节 (十六进制)")
+
+	receiveByteCmd := &cobra.Command{
+		Use:   "receive-byte",
+		Short: "接收字节",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSMBus(cmd.Context(), func(bus *i2c.SMBus) error {
+				value, err := bus.ReceiveByte()
+				if err != nil {
+					return err
+				}
+				fmt.Printf("设备 0x%02X 返回字节: 0x%02X (%d)\n", smbusAddr, value, value)
+				return nil
+			})
+		},
+	}
+
+	readByteCmd := &cobra.Command{
+		Use:   "read-byte",
+		Short: "读字节数据",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSMBus(cmd.Context(), func(bus *i2c.SMBus) error {
+				value, err := bus.ReadByteData(smbusCmd)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("设备 0x%02X 命令 0x%02X 的值: 0x%02X (%d)\n", smbusAddr, smbusCmd, value, value)
+				return nil
+			})
+		},
+	}
+
+	writeByteCmd := &cobra.Command{
+		Use:   "write-byte",
+		Short: "写字节数据",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSMBus(cmd.Context(), func(bus *i2c.SMBus) error {
+				if err := bus.WriteByteData(smbusCmd, smbusByte); err != nil {
+					return err
+				}
+				fmt.Printf("已写入设备 0x%02X 命令 0x%02X: 0x%02X (%d)\n", smbusAddr, smbusCmd, smbusByte, smbusByte)
+				return nil
+			})
+		},
+	}
+	writeByteCmd.Flags().Uint8VarP(&smbusByte, "value", "v", 0, "写入的字节 (十六进制)")
+
+	readWordCmd := &cobra.Command{
+		Use:   "read-word",
+		Short: "读字数据",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSMBus(cmd.Context(), func(bus *i2c.SMBus) error {
+				value, err := bus.ReadWordData(smbusCmd)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("设备 0x%02X 命令 0x%02X 的值: 0x%04X (%d)\n", smbusAddr, smbusCmd, value, value)
+				return nil
+			})
+		},
+	}
+
+	writeWordCmd := &cobra.Command{
+		Use:   "write-word",
+		Short: "写字数据",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSMBus(cmd.Context(), func(bus *i2c.SMBus) error {
+				if err := bus.WriteWordData(smbusCmd, smbusWord); err != nil {
+					return err
+				}
+				fmt.Printf("已写入设备 0x%02X 命令 0x%02X: 0x%04X (%d)\n", smbusAddr, smbusCmd, smbusWord, smbusWord)
+				return nil
+			})
+		},
+	}
+	writeWordCmd.Flags().Uint16VarP(&smbusWord, "value", "v", 0, "写入的字 (十六进制)")
+
+	blockReadCmd := &cobra.Command{
+		Use:   "block-read",
+		Short: "块读取",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSMBus(cmd.Context(), func(bus *i2c.SMBus) error {
+				data, err := bus.ReadBlockData(smbusCmd)
+				if err != nil {
+					return err
+				}
+				printSMBusBlock("读取", data)
+				return nil
+			})
+		},
+	}
+
+	blockWriteCmd := &cobra.Command{
+		Use:   "block-write",
+		Short: "块写入",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := parseByteList(smbusData)
+			if err != nil {
+				return err
+			}
+			return runSMBus(cmd.Context(), func(bus *i2c.SMBus) error {
+				if err := bus.WriteBlockData(smbusCmd, data); err != nil {
+					return err
+				}
+				printSMBusBlock("写入", data)
+				return nil
+			})
+		},
+	}
+	blockWriteCmd.Flags().StringSliceVarP(&smbusData, "data", "d", nil, "写入的字节数据 (逗号分隔的十六进制值)")
+	blockWriteCmd.MarkFlagRequired("data")
+
+	processCallCmd := &cobra.Command{
+		Use:   "process-call",
+		Short: "过程调用",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSMBus(cmd.Context(), func(bus *i2c.SMBus) error {
+				value, err := bus.ProcessCall(smbusCmd, smbusWord)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("设备 0x%02X 命令 0x%02X 过程调用: 0x%04X -> 0x%04X (%d)\n",
+					smbusAddr, smbusCmd, smbusWord, value, value)
+				return nil
+			})
+		},
+	}
+	processCallCmd.Flags().Uint16VarP(&smbusWord, "value", "v", 0, "写入的字 (十六进制)")
+
+	blockProcessCallCmd := &cobra.Command{
+		Use:   "block-process-call",
+		Short: "块过程调用",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := parseByteList(smbusData)
+			if err != nil {
+				return err
+			}
+			return runSMBus(cmd.Context(), func(bus *i2c.SMBus) error {
+				result, err := bus.BlockProcessCall(smbusCmd, data)
+				if err != nil {
+					return err
+				}
+				printSMBusBlock("返回", result)
+				return nil
+			})
+		},
+	}
+	blockProcessCallCmd.Flags().StringSliceVarP(&smbusData, "data", "d", nil, "写入的字节数据 (逗号分隔的十六进制值)")
+	blockProcessCallCmd.MarkFlagRequired("data")
+
+	// 需要命令码的子命令
+	for _, c := range []*cobra.Command{readByteCmd, writeByteCmd, readWordCmd, writeWordCmd,
+		blockReadCmd, blockWriteCmd, processCallCmd, blockProcessCallCmd} {
+		c.Flags().Uint8VarP(&smbusCmd, "cmd", "c", 0, "SMBus 命令码 (十六进制)")
+		c.MarkFlagRequired("cmd")
+	}
+
+	smbusCmdRoot.AddCommand(quickCmd, sendByteCmd, receiveByteCmd, readByteCmd, writeByteCmd,
+		readWordCmd, writeWordCmd, blockReadCmd, blockWriteCmd, processCallCmd, blockProcessCallCmd)
+
+	// 公共参数
+	smbusCmdRoot.PersistentFlags().Uint8VarP(&smbusAddr, "addr", "a", 0, "I2C设备地址 (十六进制)")
+	smbusCmdRoot.PersistentFlags().BoolVar(&smbusPEC, "pec", false, "启用包错误校验 (CRC-8)")
+	smbusCmdRoot.MarkPersistentFlagRequired("addr")
+
+	rootCmd.AddCommand(smbusCmdRoot)
+}
+
+// runSMBus 打开设备并在其上执行SMBus操作
+func runSMBus(ctx context.Context, fn func(bus *i2c.SMBus) error) error {
+	device, err := openDevice(smbusAddr)
+	if err != nil {
+		return fmt.Errorf("打开I2C设备失败: %w", err)
+	}
+	defer device.Close()
+
+	bus, err := i2c.NewSMBus(device)
+	if err != nil {
+		return err
+	}
+	bus = bus.WithContext(ctx)
+	bus.SetPEC(smbusPEC)
+
+	return fn(bus)
+}
+
+// printSMBusBlock 打印块数据
+func printSMBusBlock(action string, data []byte) {
+	fmt.Printf("设备 0x%02X 命令 0x%02X %s %d 字节数据:\n", smbusAddr, smbusCmd, action, len(data))
+	for i, value := range data {
+		fmt.Printf("  [%d]: 0x%02X (%d)\n", i, value, value)
+	}
+}
+
+// parseByteList 解析十六进制字节列表
+func parseByteList(values []string) ([]byte, error) {
+	data := make([]byte, len(values))
+	for i, hexStr := range values {
+		var value uint8
+		_, err := fmt.Sscanf(hexStr, "%x", &value)
+		if err != nil {
+			return nil, fmt.Errorf("解析数据失败 %s: %v", hexStr, err)
+		}
+		data[i] = value
+	}
+	return data, nil
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"sensorcli/trace"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	traceCmd := &cobra.Command{
+		Use:   "trace",
+		Short: "查看总线事务跟踪文件",
+		Long: `查看 --trace 记录的总线事务。
+
+任何命令加上 --trace out.pcap 都会把总线上的每条消息 (起始条件、地址、读写方向、
+数据、未应答和时间) 写入 pcap 文件。文件使用 Linux I2C 链路类型，可以直接用
+Wireshark 打开，也可以用 trace show 以文本查看。
+
+示例:
+  sensorcli dump --addr 0x48 --count 16 --trace out.pcap
+  sensorcli trace show out.pcap
+  wireshark out.pcap`,
+	}
+
+	showTraceCmd := &cobra.Command{
+		Use:   "show <文件>",
+		Short: "以文本显示跟踪文件中的总线事务",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return showTrace(args[0])
+		},
+	}
+
+	traceCmd.AddCommand(showTraceCmd)
+	rootCmd.AddCommand(traceCmd)
+}
+
+func showTrace(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("打开跟踪文件失败: %v", err)
+	}
+	defer f.Close()
+
+	rd, err := trace.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	sum, err := trace.WriteText(os.Stdout, rd)
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+
+	fmt.Printf("\n共 %d 条消息，%d 个事务，%d 个失败，时长 %.6f 秒\n",
+		sum.Packets, sum.Transactions, sum.NACKs, sum.Duration.Seconds())
+	return nil
+}
@@ -0,0 +1,222 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"sensorcli/expr"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	watchAddr     uint8
+	watchReg      uint8
+	watchCount    int
+	watchInterval time.Duration
+	watchUntil    string
+	watchJSON     bool
+)
+
+// ANSI 控制序列
+const (
+	ansiHighlight = "\033[1;7m"
+	ansiReset     = "\033[0m"
+	ansiClearLine = "\033[K"
+)
+
+// WatchSample 一次采样的结果，非终端输出时按行输出 JSON
+type WatchSample struct {
+	Sample     int              `json:"sample"`
+	Timestamp  time.Time        `json:"timestamp"`
+	DeviceAddr string           `json:"device_addr"`
+	StartReg   string           `json:"start_reg"`
+	Data       map[string]uint8 `json:"data"`
+	Changed    []string         `json:"changed,omitempty"`
+}
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "实时监视寄存器",
+	Long: `按固定间隔读取寄存器并原地刷新显示，高亮与上一次采样不同的字节。
+
+--until 指定停止条件，寄存器以 reg[地址] 引用，运算符与 Go 语言相同，
+结果非零时停止。输出不是终端时按行输出 JSON (NDJSON)。
+
+示例:
+  sensorcli watch --addr 0x48 --reg 0x00 --count 4
+  sensorcli watch --addr 0x48 --reg 0x00 --count 4 --interval 100ms
+  sensorcli watch --addr 0x48 --reg 0x00 --count 4 --until "reg[0x00]&0x80"
+  sensorcli watch --addr 0x48 --reg 0x00 --count 4 | jq .data`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return watchRegisters(cmd.Context())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+
+	watchCmd.Flags().Uint8VarP(&watchAddr, "addr", "a", 0, "I2C设备地址 (十六进制)")
+	watchCmd.Flags().Uint8VarP(&watchReg, "reg", "r", 0, "起始寄存器地址 (十六进制)")
+	watchCmd.Flags().IntVarP(&watchCount, "count", "c", 1, "读取字节数")
+	watchCmd.Flags().DurationVarP(&watchInterval, "interval", "i", 500*time.Millisecond, "采样间隔")
+	watchCmd.Flags().StringVarP(&watchUntil, "until", "u", "", "停止条件，如 \"reg[0x00]&0x80\"")
+	watchCmd.Flags().BoolVar(&watchJSON, "json", false, "强制按行输出 JSON")
+
+	watchCmd.MarkFlagRequired("addr")
+}
+
+func watchRegisters(ctx context.Context) error {
+	if watchCount <= 0 || int(watchReg)+watchCount > 0x100 {
+		return fmt.Errorf("无效的读取范围: 起始 0x%02X，%d 字节", watchReg, watchCount)
+	}
+	if watchInterval <= 0 {
+		return fmt.Errorf("采样间隔必须大于 0")
+	}
+
+	var until *expr.Expr
+	if watchUntil != "" {
+		var err error
+		if until, err = expr.Parse(watchUntil); err != nil {
+			return err
+		}
+		for _, reg := range until.Registers() {
+			if int(reg) < int(watchReg) || int(reg) >= int(watchReg)+watchCount {
+				return fmt.Errorf("停止条件引用的寄存器 0x%02X 不在监视范围内", reg)
+			}
+		}
+	}
+
+	device, err := openDevice(watchAddr)
+	if err != nil {
+		return fmt.Errorf("打开I2C设备失败: %w", err)
+	}
+	defer device.Close()
+
+	var view watchView
+	if watchJSON || !isTerminal(os.Stdout) {
+		view = &jsonWatchView{enc: json.NewEncoder(os.Stdout)}
+	} else {
+		view = &tableWatchView{out: os.Stdout}
+	}
+
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	var prev []byte
+	for sample := 1; ; sample++ {
+		data, err := device.ReadBytesCtx(ctx, watchReg, watchCount)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("读取数据失败: %w", err)
+		}
+
+		view.Show(sample, time.Now(), data, prev)
+		prev = data
+
+		if until != nil {
+			ok, err := until.True(expr.Bytes(watchReg, data))
+			if err != nil {
+				return fmt.Errorf("计算停止条件失败: %v", err)
+			}
+			if ok {
+				fmt.Fprintf(os.Stderr, "条件满足: %s (第 %d 次采样)\n", until, sample)
+				return nil
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// isTerminal 判断文件是否为终端
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// watchView 采样结果的显示方式
+type watchView interface {
+	Show(sample int, t time.Time, data, prev []byte)
+}
+
+// tableWatchView 在终端中原地刷新的表格
+type tableWatchView struct {
+	out   io.Writer
+	lines int
+}
+
+func (v *tableWatchView) Show(sample int, t time.Time, data, prev []byte) {
+	var b strings.Builder
+
+	// 光标移回上一帧的起始行
+	if v.lines > 0 {
+		fmt.Fprintf(&b, "\033[%dA\r", v.lines)
+	}
+
+	lines := 0
+	line := func(format string, args ...interface{}) {
+		fmt.Fprintf(&b, format, args...)
+		b.WriteString(ansiClearLine + "\n")
+		lines++
+	}
+
+	line("设备 0x%02X 总线 %d  采样 #%d  %s  间隔 %v  (Ctrl+C 退出)",
+		watchAddr, appConfig.DefaultBus, sample, t.Format("15:04:05.000"), watchInterval)
+	line("")
+
+	header := "      "
+	for i := 0; i < 16 && i < len(data); i++ {
+		header += fmt.Sprintf(" +%X", i)
+	}
+	line("%s", header)
+
+	for offset := 0; offset < len(data); offset += 16 {
+		row := fmt.Sprintf("0x%02X: ", int(watchReg)+offset)
+		for i := offset; i < offset+16 && i < len(data); i++ {
+			cell := fmt.Sprintf("%02X", data[i])
+			if prev != nil && prev[i] != data[i] {
+				cell = ansiHighlight + cell + ansiReset
+			}
+			row += " " + cell
+		}
+		line("%s", row)
+	}
+
+	v.lines = lines
+	io.WriteString(v.out, b.String())
+}
+
+// jsonWatchView 按行输出 JSON
+type jsonWatchView struct {
+	enc *json.Encoder
+}
+
+func (v *jsonWatchView) Show(sample int, t time.Time, data, prev []byte) {
+	s := WatchSample{
+		Sample:     sample,
+		Timestamp:  t,
+		DeviceAddr: fmt.Sprintf("0x%02X", watchAddr),
+		StartReg:   fmt.Sprintf("0x%02X", watchReg),
+		Data:       make(map[string]uint8, len(data)),
+	}
+	for i, value := range data {
+		reg := fmt.Sprintf("0x%02X", int(watchReg)+i)
+		s.Data[reg] = value
+		if prev != nil && prev[i] != value {
+			s.Changed = append(s.Changed, reg)
+		}
+	}
+	v.enc.Encode(s)
+}
@@ -1,17 +1,16 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/spf13/cobra"
-	"sensorcli/i2c"
 )
 
 var (
 	writeAddr  uint8
 	writeReg   uint8
 	writeValue uint8
-	writeBus   int
 	writeData  []string
 )
 
@@ -24,7 +23,7 @@ var writeCmd = &cobra.Command{
   sensorcli write --addr 0x48 --reg 0x02 --value 0x55 --bus 1
   sensorcli write --addr 0x48 --reg 0x02 --data 0x55,0x66,0x77 --bus 1`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return writeRegister()
+		return writeRegister(cmd.Context())
 	},
 }
 
@@ -35,7 +34,6 @@ func init() {
 	writeCmd.Flags().Uint8VarP(&writeAddr, "addr", "a", 0, "I2C设备地址 (十六进制)")
 	writeCmd.Flags().Uint8VarP(&writeReg, "reg", "r", 0, "寄存器地址 (十六进制)")
 	writeCmd.Flags().Uint8VarP(&writeValue, "value", "v", 0, "写入值 (十六进制)")
-	writeCmd.Flags().IntVarP(&writeBus, "bus", "b", 1, "I2C总线号")
 	writeCmd.Flags().StringSliceVarP(&writeData, "data", "d", nil, "写入的字节数据 (逗号分隔的十六进制值)")
 	
 	// 设置必需参数
@@ -43,19 +41,19 @@ func init() {
 	writeCmd.MarkFlagRequired("reg")
 }
 
-func writeRegister() error {
+func writeRegister(ctx context.Context) error {
 	// 打开I2C设备
-	device, err := i2c.Open(writeBus, writeAddr)
+	device, err := openDevice(writeAddr)
 	if err != nil {
-		return fmt.Errorf("打开I2C设备失败: %v", err)
+		return fmt.Errorf("打开I2C设备失败: %w", err)
 	}
 	defer device.Close()
 
 	if len(writeData) == 0 {
 		// 写入单个值
-		err := device.WriteRegister(writeReg, writeValue)
+		err := device.WriteRegisterCtx(ctx, writeReg, writeValue)
 		if err != nil {
-			return fmt.Errorf("写入寄存器失败: %v", err)
+			return fmt.Errorf("写入寄存器失败: %w", err)
 		}
 		
 		fmt.Printf("已写入设备 0x%02X 寄存器 0x%02X: 0x%02X (%d)\n", 
@@ -72,9 +70,9 @@ func writeRegister() error {
 			data[i] = value
 		}
 		
-		err := device.WriteBytes(writeReg, data)
+		err := device.WriteBytesCtx(ctx, writeReg, data)
 		if err != nil {
-			return fmt.Errorf("写入数据失败: %v", err)
+			return fmt.Errorf("写入数据失败: %w", err)
 		}
 		
 		fmt.Printf("已写入设备 0x%02X 寄存器 0x%02X 的 %d 字节数据:\n", 
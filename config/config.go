@@ -4,76 +4,189 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"sensorcli/i2c"
+	"sensorcli/logger"
+)
+
+// 环境变量名称
+const (
+	EnvConfig       = "SENSORCLI_CONFIG"
+	EnvBus          = "SENSORCLI_BUS"
+	EnvTimeout      = "SENSORCLI_TIMEOUT"
+	EnvRetries      = "SENSORCLI_RETRIES"
+	EnvMock         = "SENSORCLI_MOCK"
+	EnvLogLevel     = "SENSORCLI_LOG_LEVEL"
+	EnvLogFile      = "SENSORCLI_LOG_FILE"
+	EnvLogFormat    = "SENSORCLI_LOG_FORMAT"
+	EnvLogLevels    = "SENSORCLI_LOG_LEVELS"
+	EnvOutputFormat = "SENSORCLI_OUTPUT_FORMAT"
+	EnvReplay       = "SENSORCLI_REPLAY"
+	EnvReplayMode   = "SENSORCLI_REPLAY_MODE"
 )
 
 // Config 全局配置结构
 type Config struct {
 	DefaultBus     int    `json:"default_bus"`
 	DefaultTimeout int    `json:"default_timeout"`
+	DefaultRetries int    `json:"default_retries"`
 	LogLevel       string `json:"log_level"`
+	LogFile        string `json:"log_file,omitempty"`
+	LogFormat      string `json:"log_format"`
+	LogLevels      string `json:"log_levels,omitempty"`
+	LogMaxSize     int    `json:"log_max_size"`
+	LogMaxFiles    int    `json:"log_max_files"`
 	OutputFormat   string `json:"output_format"`
 	MockMode       bool   `json:"mock_mode"`
+	ReplayFile     string `json:"replay_file,omitempty"`
+	ReplayMode     string `json:"replay_mode"`
+
+	Devices map[string]Device `json:"devices,omitempty"`
+
+	// Files 按优先级从低到高排列的已加载配置文件
+	Files []string `json:"-"`
+
+	sources       map[string]Source
+	deviceSources map[string]string
 }
 
-// DefaultConfig 默认配置
-func DefaultConfig() *Config {
-	return &Config{
-		DefaultBus:     1,
-		DefaultTimeout: 1000, // 毫秒
-		LogLevel:       "info",
-		OutputFormat:   "json",
-		MockMode:       true, // Windows 下默认使用模拟模式
-	}
+// Device 命名设备，命令中用 --device 或 @名称 代替总线号和地址
+type Device struct {
+	Bus     int     `json:"bus"`
+	Address HexByte `json:"addr"`
+	RegMap  string  `json:"regmap,omitempty"`
+	Driver  string  `json:"driver,omitempty"`
 }
 
-// LoadConfig 加载配置文件
-func LoadConfig(configPath string) (*Config, error) {
-	config := DefaultConfig()
+// HexByte 在配置文件中以 "0x48" 形式保存的字节
+type HexByte uint8
 
-	if configPath == "" {
-		// 尝试从默认位置加载
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return config, nil
-		}
-		configPath = filepath.Join(homeDir, ".sensorcli", "config.json")
-	}
+func (b HexByte) String() string {
+	return fmt.Sprintf("0x%02X", uint8(b))
+}
+
+func (b HexByte) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
 
-	// 检查配置文件是否存在
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		// 创建默认配置文件
-		if err := SaveConfig(config, configPath); err != nil {
-			return config, fmt.Errorf("创建默认配置文件失败: %v", err)
+// UnmarshalJSON 同时接受 "0x48" 和数字，YAML/TOML 中未加引号的地址会解码为数字
+func (b *HexByte) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
 		}
-		return config, nil
+		return b.UnmarshalText([]byte(s))
 	}
+	return b.UnmarshalText(data)
+}
 
-	// 读取配置文件
-	data, err := os.ReadFile(configPath)
+func (b *HexByte) UnmarshalText(text []byte) error {
+	v, err := strconv.ParseUint(string(text), 0, 8)
 	if err != nil {
-		return config, fmt.Errorf("读取配置文件失败: %v", err)
+		return fmt.Errorf("无效的地址: %q", text)
 	}
+	*b = HexByte(v)
+	return nil
+}
 
-	if err := json.Unmarshal(data, config); err != nil {
-		return config, fmt.Errorf("解析配置文件失败: %v", err)
+var deviceNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*$`)
+
+// ValidDeviceName 检查设备别名，只允许字母开头的字母、数字、下划线和连字符
+func ValidDeviceName(name string) error {
+	if !deviceNamePattern.MatchString(name) {
+		return fmt.Errorf("无效的设备名称: %q (只能包含字母、数字、_ 和 -，并以字母开头)", name)
 	}
+	return nil
+}
 
-	return config, nil
+// Device 按别名查找设备
+func (c *Config) Device(name string) (Device, error) {
+	dev, ok := c.Devices[name]
+	if !ok {
+		if len(c.Devices) == 0 {
+			return dev, fmt.Errorf("未知设备: %s (尚未定义任何设备)", name)
+		}
+		return dev, fmt.Errorf("未知设备: %s (可用设备: %s)", name, strings.Join(c.DeviceNames(), ", "))
+	}
+	return dev, nil
+}
+
+// DeviceSource 返回定义命名设备的配置文件，多个文件定义同名设备时为生效的那个
+func (c *Config) DeviceSource(name string) string {
+	return c.deviceSources[name]
 }
 
-// SaveConfig 保存配置文件
-func SaveConfig(config *Config, configPath string) error {
-	// 确保目录存在
-	dir := filepath.Dir(configPath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return fmt.Errorf("创建配置目录失败: %v", err)
+// DeviceNames 返回排序后的设备别名
+func (c *Config) DeviceNames() []string {
+	names := make([]string, 0, len(c.Devices))
+	for name := range c.Devices {
+		names = append(names, name)
 	}
+	sort.Strings(names)
+	return names
+}
 
-	data, err := json.MarshalIndent(config, "", "  ")
-	if err != nil {
-		return fmt.Errorf("序列化配置失败: %v", err)
+// DefaultConfig 默认配置
+func DefaultConfig() *Config {
+	return &Config{
+		DefaultBus:     1,
+		DefaultTimeout: 1000, // 毫秒
+		DefaultRetries: 3,
+		LogLevel:       "info",
+		LogFormat:      "text",
+		LogMaxSize:     10, // MB
+		LogMaxFiles:    5,
+		OutputFormat:   "json",
+		MockMode:       true, // Windows 下默认使用模拟模式
+		ReplayMode:     i2c.ReplayStrict,
+	}
+}
+
+// LogOptions 生成日志输出设置，调用前应已通过 Validate 校验
+func (c *Config) LogOptions() logger.Options {
+	level, _ := logger.ParseLevel(c.LogLevel)
+	subsystems, _ := logger.ParseSubsystemLevels(c.LogLevels)
+	return logger.Options{
+		Level:      level,
+		Subsystems: subsystems,
+		Format:     c.LogFormat,
+		File:       c.LogFile,
+		MaxSize:    int64(c.LogMaxSize) << 20,
+		MaxFiles:   c.LogMaxFiles,
+	}
+}
+
+// Timeout 以 time.Duration 表示的默认超时
+func (c *Config) Timeout() time.Duration {
+	return time.Duration(c.DefaultTimeout) * time.Millisecond
+}
+
+// ApplyEnv 用 SENSORCLI_* 环境变量覆盖配置
+//
+// SENSORCLI_TIMEOUT 可以是毫秒数或 "500ms" 这样的时长。
+func (c *Config) ApplyEnv() error {
+	for _, k := range keys {
+		if k.Env == "" {
+			continue
+		}
+		if v, ok := lookupEnv(k.Env); ok {
+			if err := c.Set(k.Name, v, Source{Kind: SourceEnv, Detail: k.Env}); err != nil {
+				return fmt.Errorf("环境变量 %s 无效: %v", k.Env, err)
+			}
+		}
 	}
+	return nil
+}
 
-	return os.WriteFile(configPath, data, 0644)
+// lookupEnv 读取非空的环境变量
+func lookupEnv(name string) (string, bool) {
+	v, ok := os.LookupEnv(name)
+	v = strings.TrimSpace(v)
+	return v, ok && v != ""
 }
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApplyEnv(t *testing.T) {
+	t.Setenv(EnvBus, "2")
+	t.Setenv(EnvTimeout, "250ms")
+	t.Setenv(EnvRetries, "5")
+	t.Setenv(EnvMock, "false")
+	t.Setenv(EnvLogLevel, "DEBUG")
+
+	cfg := DefaultConfig()
+	if err := cfg.ApplyEnv(); err != nil {
+		t.Fatalf("ApplyEnv: %v", err)
+	}
+	if cfg.DefaultBus != 2 || cfg.Timeout() != 250*time.Millisecond || cfg.DefaultRetries != 5 {
+		t.Errorf("bus/timeout/retries = %d/%v/%d", cfg.DefaultBus, cfg.Timeout(), cfg.DefaultRetries)
+	}
+	if cfg.MockMode || cfg.LogLevel != "debug" {
+		t.Errorf("mock/log = %t/%s", cfg.MockMode, cfg.LogLevel)
+	}
+	if cfg.OutputFormat != DefaultConfig().OutputFormat {
+		t.Errorf("未设置的环境变量不应覆盖配置: %s", cfg.OutputFormat)
+	}
+}
+
+func TestApplyEnvTimeoutMillis(t *testing.T) {
+	t.Setenv(EnvTimeout, "1500")
+
+	cfg := DefaultConfig()
+	if err := cfg.ApplyEnv(); err != nil {
+		t.Fatalf("ApplyEnv: %v", err)
+	}
+	if cfg.Timeout() != 1500*time.Millisecond {
+		t.Errorf("Timeout = %v", cfg.Timeout())
+	}
+}
+
+func TestApplyEnvInvalid(t *testing.T) {
+	for _, name := range []string{EnvBus, EnvTimeout, EnvRetries, EnvMock} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(name, "bogus")
+			if err := DefaultConfig().ApplyEnv(); err == nil {
+				t.Errorf("%s=bogus 应当报错", name)
+			}
+		})
+	}
+}
+
+func TestDevicesRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+
+	cfg := DefaultConfig()
+	cfg.Devices = map[string]Device{
+		"board_temp": {Bus: 2, Address: 0x48, RegMap: "tmp102", Driver: "tmp102"},
+	}
+	if err := SaveConfig(cfg, path); err != nil {
+		t.Fatalf("SaveConfig: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), `"addr": "0x48"`) {
+		t.Errorf("地址应以十六进制保存:\n%s", data)
+	}
+
+	loaded, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	dev, err := loaded.Device("board_temp")
+	if err != nil {
+		t.Fatalf("Device: %v", err)
+	}
+	if dev != cfg.Devices["board_temp"] {
+		t.Errorf("Device = %+v", dev)
+	}
+	if _, err := loaded.Device("missing"); err == nil || !strings.Contains(err.Error(), "board_temp") {
+		t.Errorf("未知设备的错误应列出可用设备: %v", err)
+	}
+}
+
+func TestValidDeviceName(t *testing.T) {
+	for name, valid := range map[string]bool{
+		"board_temp": true,
+		"imu-2":      true,
+		"":           false,
+		"2nd":        false,
+		"a:b":        false,
+		"with space": false,
+	} {
+		if err := ValidDeviceName(name); (err == nil) != valid {
+			t.Errorf("ValidDeviceName(%q) = %v", name, err)
+		}
+	}
+}
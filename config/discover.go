@@ -0,0 +1,150 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// SystemDir 系统级配置目录
+var SystemDir = "/etc/sensorcli"
+
+// DefaultPath 默认配置文件路径 ~/.sensorcli/config.json，无法确定主目录时为空
+func DefaultPath() string {
+	dir := userDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "config.json")
+}
+
+func userDir() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(homeDir, ".sensorcli")
+}
+
+// WritePath 返回修改配置时写入的文件: 指定的路径、已存在的用户配置文件或 DefaultPath
+func WritePath(configPath string) string {
+	if configPath != "" {
+		return configPath
+	}
+	if p := findFile(userDir(), "config"); p != "" {
+		return p
+	}
+	return DefaultPath()
+}
+
+// Discover 返回按优先级从低到高排列的配置文件:
+// 系统级 /etc/sensorcli/config.*、用户级 ~/.sensorcli/config.*，
+// 以及从根目录到 dir 逐级目录中的项目配置 .sensorcli.*，离 dir 越近优先级越高
+func Discover(dir string) []string {
+	var paths []string
+	if p := findFile(SystemDir, "config"); p != "" {
+		paths = append(paths, p)
+	}
+	if p := findFile(userDir(), "config"); p != "" {
+		paths = append(paths, p)
+	}
+
+	var project []string
+	if abs, err := filepath.Abs(dir); err == nil {
+		for d := abs; ; d = filepath.Dir(d) {
+			if p := findFile(d, ".sensorcli"); p != "" {
+				project = append(project, p)
+			}
+			if filepath.Dir(d) == d {
+				break
+			}
+		}
+	}
+	for i := len(project) - 1; i >= 0; i-- {
+		paths = append(paths, project[i])
+	}
+	return paths
+}
+
+// findFile 返回 dir 中第一个存在的 base.{yaml,yml,toml,json}
+func findFile(dir, base string) string {
+	if dir == "" {
+		return ""
+	}
+	for _, ext := range extensions {
+		p := filepath.Join(dir, base+ext)
+		if info, err := os.Stat(p); err == nil && !info.IsDir() {
+			return p
+		}
+	}
+	return ""
+}
+
+// LoadConfig 加载配置。指定路径时只读取该文件，否则合并 Discover 找到的所有文件。
+// 不存在的文件视为空配置，不会自动创建
+func LoadConfig(configPath string) (*Config, error) {
+	if configPath != "" {
+		return LoadFiles(configPath)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		wd = "."
+	}
+	return LoadFiles(Discover(wd)...)
+}
+
+// LoadFiles 按顺序合并配置文件，后面的文件覆盖前面的配置项，
+// 命名设备按名称合并。记录每个配置项来自哪个文件
+func LoadFiles(paths ...string) (*Config, error) {
+	config := DefaultConfig()
+	devices := make(map[string]any)
+
+	for _, path := range paths {
+		values, exists, err := readFile(path)
+		if err != nil {
+			return config, err
+		}
+		if !exists {
+			continue
+		}
+		config.Files = append(config.Files, path)
+
+		src := Source{Kind: SourceFile, Detail: path}
+		for name, v := range values {
+			switch name {
+			case "version":
+			case "devices":
+				m, ok := v.(map[string]any)
+				if !ok {
+					return config, fmt.Errorf("%s: devices 必须是以设备名称为键的表", path)
+				}
+				for devName, dev := range m {
+					if err := ValidDeviceName(devName); err != nil {
+						return config, fmt.Errorf("%s: %v", path, err)
+					}
+					devices[devName] = dev
+					if config.deviceSources == nil {
+						config.deviceSources = make(map[string]string)
+					}
+					config.deviceSources[devName] = path
+				}
+			default:
+				if err := config.Set(name, scalarString(v), src); err != nil {
+					return config, fmt.Errorf("%s: %v", path, err)
+				}
+			}
+		}
+	}
+
+	if len(devices) > 0 {
+		data, err := json.Marshal(devices)
+		if err == nil {
+			err = json.Unmarshal(data, &config.Devices)
+		}
+		if err != nil {
+			return config, fmt.Errorf("解析命名设备失败: %v", err)
+		}
+	}
+	return config, nil
+}
@@ -0,0 +1,142 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDiscoverAndMerge(t *testing.T) {
+	root := t.TempDir()
+	home := filepath.Join(root, "home")
+	t.Setenv("HOME", home)
+	old := SystemDir
+	SystemDir = filepath.Join(root, "etc")
+	defer func() { SystemDir = old }()
+
+	system := filepath.Join(SystemDir, "config.json")
+	user := filepath.Join(home, ".sensorcli", "config.toml")
+	outer := filepath.Join(root, "repo", ".sensorcli.yaml")
+	inner := filepath.Join(root, "repo", "board", ".sensorcli.json")
+	writeTestFile(t, system, `{"default_bus": 5, "default_retries": 1}`)
+	writeTestFile(t, user, "default_retries = 2\nlog_level = \"debug\"\n")
+	writeTestFile(t, outer, "default_bus: 3\ndevices:\n  temp:\n    bus: 3\n    addr: 0x48\n")
+	writeTestFile(t, inner, `{"devices": {"imu": {"bus": 3, "addr": "0x68"}}}`)
+
+	dir := filepath.Join(root, "repo", "board", "src")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	paths := Discover(dir)
+	if want := []string{system, user, outer, inner}; !reflect.DeepEqual(paths, want) {
+		t.Fatalf("Discover = %v, want %v", paths, want)
+	}
+
+	cfg, err := LoadFiles(paths...)
+	if err != nil {
+		t.Fatalf("LoadFiles: %v", err)
+	}
+	if cfg.DefaultBus != 3 || cfg.DefaultRetries != 2 || cfg.LogLevel != "debug" {
+		t.Errorf("bus/retries/log = %d/%d/%s", cfg.DefaultBus, cfg.DefaultRetries, cfg.LogLevel)
+	}
+	if got := cfg.Source("default_bus").Detail; got != outer {
+		t.Errorf("default_bus 来源 = %s", got)
+	}
+	if got := cfg.Source("default_retries").Detail; got != user {
+		t.Errorf("default_retries 来源 = %s", got)
+	}
+	if !reflect.DeepEqual(cfg.DeviceNames(), []string{"imu", "temp"}) || cfg.Devices["temp"].Address != 0x48 {
+		t.Errorf("Devices = %+v", cfg.Devices)
+	}
+	if got := cfg.DeviceSource("imu"); got != inner {
+		t.Errorf("imu 来源 = %s", got)
+	}
+}
+
+func TestLoadDoesNotCreateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("不应创建配置文件: %v", err)
+	}
+	if len(cfg.Files) != 0 || cfg.DefaultBus != DefaultConfig().DefaultBus {
+		t.Errorf("cfg = %+v", cfg)
+	}
+}
+
+func TestMigrateUnversioned(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeTestFile(t, path, `{"log_level": "WARNING", "output_format": "CSV"}`)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if cfg.LogLevel != "warn" || cfg.OutputFormat != "csv" {
+		t.Errorf("log/format = %s/%s", cfg.LogLevel, cfg.OutputFormat)
+	}
+
+	values, err := ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if err := WriteFile(path, values); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), `"version": 1`) || !strings.Contains(string(data), `"warn"`) {
+		t.Errorf("迁移后的文件:\n%s", data)
+	}
+}
+
+func TestRejectNewerVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeTestFile(t, path, "version: 99\n")
+	if _, err := LoadConfig(path); err == nil {
+		t.Error("高于当前版本的配置文件应当报错")
+	}
+}
+
+func TestRejectUnknownKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	writeTestFile(t, path, "defualt_bus = 2\n")
+	if _, err := LoadConfig(path); err == nil || !strings.Contains(err.Error(), "defualt_bus") {
+		t.Errorf("未知配置项应当报错: %v", err)
+	}
+}
+
+func TestWriteFileFormats(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"config.yaml", "config.toml", "config.json"} {
+		path := filepath.Join(dir, name)
+		values := map[string]any{
+			"default_bus": 2,
+			"devices":     map[string]Device{"temp": {Bus: 2, Address: 0x48, RegMap: "tmp102"}},
+		}
+		if err := WriteFile(path, values); err != nil {
+			t.Fatalf("%s: WriteFile: %v", name, err)
+		}
+		cfg, err := LoadConfig(path)
+		if err != nil {
+			t.Fatalf("%s: LoadConfig: %v", name, err)
+		}
+		if cfg.DefaultBus != 2 || cfg.Devices["temp"] != values["devices"].(map[string]Device)["temp"] {
+			t.Errorf("%s: cfg = %+v", name, cfg)
+		}
+	}
+}
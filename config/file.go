@@ -0,0 +1,210 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentVersion 当前配置文件格式版本，保存在文件的 version 字段中
+const CurrentVersion = 1
+
+// extensions 支持的配置文件扩展名，同一位置存在多个文件时按此顺序取第一个
+var extensions = []string{".yaml", ".yml", ".toml", ".json"}
+
+// migrations[n] 把版本 n 的配置迁移到版本 n+1
+var migrations = []func(values map[string]any){
+	migrateV0,
+}
+
+// migrateV0 迁移没有版本号的文件: 旧的 config set 不校验取值，
+// log_level 和 output_format 可能是大写或 "warning" 这样的写法
+func migrateV0(values map[string]any) {
+	if s, ok := values["log_level"].(string); ok {
+		s = strings.ToLower(s)
+		if s == "warning" {
+			s = "warn"
+		}
+		values["log_level"] = s
+	}
+	if s, ok := values["output_format"].(string); ok {
+		values["output_format"] = strings.ToLower(s)
+	}
+}
+
+// migrate 把读取的键值迁移到当前版本
+func migrate(values map[string]any) error {
+	version := 0
+	if v, ok := values["version"]; ok {
+		n, err := strconv.Atoi(scalarString(v))
+		if err != nil || n < 0 {
+			return fmt.Errorf("无效的版本号: %v", v)
+		}
+		version = n
+	}
+	if version > CurrentVersion {
+		return fmt.Errorf("配置文件版本 %d 高于支持的版本 %d，请升级 sensorcli", version, CurrentVersion)
+	}
+
+	for ; version < CurrentVersion; version++ {
+		migrations[version](values)
+	}
+	values["version"] = CurrentVersion
+	return nil
+}
+
+// ReadFile 按扩展名 (.yaml/.yml/.toml/.json) 读取配置文件的键值并迁移到当前版本，
+// 文件不存在时返回空表
+func ReadFile(configPath string) (map[string]any, error) {
+	values, _, err := readFile(configPath)
+	return values, err
+}
+
+func readFile(configPath string) (map[string]any, bool, error) {
+	values := make(map[string]any)
+	data, err := os.ReadFile(configPath)
+	if os.IsNotExist(err) {
+		return values, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("读取配置文件失败: %v", err)
+	}
+
+	switch fileFormat(configPath) {
+	case ".yaml":
+		err = yaml.Unmarshal(data, &values)
+	case ".toml":
+		_, err = toml.Decode(string(data), &values)
+	default:
+		if len(bytes.TrimSpace(data)) > 0 {
+			err = json.Unmarshal(data, &values)
+		}
+	}
+	if err != nil {
+		return nil, true, fmt.Errorf("解析配置文件 %s 失败: %v", configPath, err)
+	}
+	if values == nil {
+		values = make(map[string]any)
+	}
+
+	if err := migrate(values); err != nil {
+		return nil, true, fmt.Errorf("%s: %v", configPath, err)
+	}
+	return values, true, nil
+}
+
+// WriteFile 按扩展名对应的格式保存键值，同时写入当前版本号
+func WriteFile(configPath string, values map[string]any) error {
+	values["version"] = CurrentVersion
+
+	// 命名设备按结构体重新编码，YAML 中写成数字的地址统一保存为 "0x48"
+	if devices, ok := values["devices"]; ok {
+		var typed map[string]Device
+		data, err := json.Marshal(devices)
+		if err == nil {
+			err = json.Unmarshal(data, &typed)
+		}
+		if err != nil {
+			return fmt.Errorf("序列化命名设备失败: %v", err)
+		}
+		values["devices"] = typed
+	}
+
+	// 经过 JSON 转换后结构体字段使用 json 标签中的名称
+	var generic map[string]any
+	data, err := json.Marshal(values)
+	if err == nil {
+		err = json.Unmarshal(data, &generic)
+	}
+	if err != nil {
+		return fmt.Errorf("序列化配置失败: %v", err)
+	}
+
+	switch fileFormat(configPath) {
+	case ".yaml":
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		err = enc.Encode(normalizeNumbers(generic))
+		data = buf.Bytes()
+	case ".toml":
+		var buf bytes.Buffer
+		err = toml.NewEncoder(&buf).Encode(normalizeNumbers(generic))
+		data = buf.Bytes()
+	default:
+		data, err = json.MarshalIndent(generic, "", "  ")
+	}
+	if err != nil {
+		return fmt.Errorf("序列化配置失败: %v", err)
+	}
+
+	// 确保目录存在
+	dir := filepath.Dir(configPath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("创建配置目录失败: %v", err)
+	}
+	return os.WriteFile(configPath, data, 0644)
+}
+
+// SaveConfig 保存完整的配置
+func SaveConfig(config *Config, configPath string) error {
+	var values map[string]any
+	data, err := json.Marshal(config)
+	if err == nil {
+		err = json.Unmarshal(data, &values)
+	}
+	if err != nil {
+		return fmt.Errorf("序列化配置失败: %v", err)
+	}
+	return WriteFile(configPath, values)
+}
+
+// fileFormat 返回配置文件格式对应的扩展名，未知扩展名按 JSON 处理
+func fileFormat(configPath string) string {
+	switch strings.ToLower(filepath.Ext(configPath)) {
+	case ".yaml", ".yml":
+		return ".yaml"
+	case ".toml":
+		return ".toml"
+	default:
+		return ".json"
+	}
+}
+
+// normalizeNumbers 把 JSON 解码得到的整数值 float64 转为 int64，避免 YAML/TOML 中写成浮点数
+func normalizeNumbers(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, item := range v {
+			v[k] = normalizeNumbers(item)
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = normalizeNumbers(item)
+		}
+		return v
+	case float64:
+		if v == float64(int64(v)) {
+			return int64(v)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// scalarString 把解码得到的标量转为字符串，整数不使用科学计数法
+func scalarString(v any) string {
+	if f, ok := v.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
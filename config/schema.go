@@ -0,0 +1,281 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"sensorcli/i2c"
+	"sensorcli/logger"
+)
+
+// 配置项类型
+const (
+	TypeInt      = "int"
+	TypeBool     = "bool"
+	TypeString   = "string"
+	TypeDuration = "duration" // 以毫秒保存，也接受 "500ms" 这样的时长
+)
+
+// Key 描述一个配置项: 类型、允许的取值、说明以及对应的环境变量和命令行参数
+type Key struct {
+	Name    string
+	Type    string
+	Allowed []string
+	Doc     string
+	Env     string
+	Flag    string
+
+	// validate 对字符串取值的额外校验
+	validate func(value string) error
+
+	get func(c *Config) any
+	set func(c *Config, v any)
+}
+
+var keys = []*Key{
+	{
+		Name: "default_bus", Type: TypeInt, Env: EnvBus, Flag: "bus",
+		Doc: "默认 I2C 总线号",
+		get: func(c *Config) any { return c.DefaultBus },
+		set: func(c *Config, v any) { c.DefaultBus = v.(int) },
+	},
+	{
+		Name: "default_timeout", Type: TypeDuration, Env: EnvTimeout, Flag: "timeout",
+		Doc: "I2C 操作超时时间 (毫秒)",
+		get: func(c *Config) any { return c.DefaultTimeout },
+		set: func(c *Config, v any) { c.DefaultTimeout = v.(int) },
+	},
+	{
+		Name: "default_retries", Type: TypeInt, Env: EnvRetries, Flag: "retries",
+		Doc: "I2C 操作重试次数",
+		get: func(c *Config) any { return c.DefaultRetries },
+		set: func(c *Config, v any) { c.DefaultRetries = v.(int) },
+	},
+	{
+		Name: "log_level", Type: TypeString, Env: EnvLogLevel, Flag: "log-level",
+		Allowed: []string{"debug", "info", "warn", "error"},
+		Doc:     "日志级别",
+		get:     func(c *Config) any { return c.LogLevel },
+		set:     func(c *Config, v any) { c.LogLevel = v.(string) },
+	},
+	{
+		Name: "log_file", Type: TypeString, Env: EnvLogFile, Flag: "log-file",
+		Doc: "日志文件路径，为空时写入标准错误",
+		get: func(c *Config) any { return c.LogFile },
+		set: func(c *Config, v any) { c.LogFile = v.(string) },
+	},
+	{
+		Name: "log_format", Type: TypeString, Env: EnvLogFormat, Flag: "log-format",
+		Allowed: []string{logger.FormatText, logger.FormatJSON},
+		Doc:     "日志格式",
+		get:     func(c *Config) any { return c.LogFormat },
+		set:     func(c *Config, v any) { c.LogFormat = v.(string) },
+	},
+	{
+		Name: "log_levels", Type: TypeString, Env: EnvLogLevels,
+		Doc: "按子系统覆盖日志级别，如 i2c=debug,scan=warn",
+		validate: func(value string) error {
+			_, err := logger.ParseSubsystemLevels(value)
+			return err
+		},
+		get: func(c *Config) any { return c.LogLevels },
+		set: func(c *Config, v any) { c.LogLevels = v.(string) },
+	},
+	{
+		Name: "log_max_size", Type: TypeInt,
+		Doc: "日志文件轮转大小 (MB)，0 表示不轮转",
+		get: func(c *Config) any { return c.LogMaxSize },
+		set: func(c *Config, v any) { c.LogMaxSize = v.(int) },
+	},
+	{
+		Name: "log_max_files", Type: TypeInt,
+		Doc: "日志轮转时保留的旧文件数",
+		get: func(c *Config) any { return c.LogMaxFiles },
+		set: func(c *Config, v any) { c.LogMaxFiles = v.(int) },
+	},
+	{
+		Name: "output_format", Type: TypeString, Env: EnvOutputFormat,
+		Allowed: []string{"json", "csv", "hex"},
+		Doc:     "dump 命令的默认输出格式",
+		get:     func(c *Config) any { return c.OutputFormat },
+		set:     func(c *Config, v any) { c.OutputFormat = v.(string) },
+	},
+	{
+		Name: "mock_mode", Type: TypeBool, Env: EnvMock, Flag: "mock",
+		Doc: "使用模拟 I2C 总线",
+		get: func(c *Config) any { return c.MockMode },
+		set: func(c *Config, v any) { c.MockMode = v.(bool) },
+	},
+	{
+		Name: "replay_file", Type: TypeString, Env: EnvReplay, Flag: "replay",
+		Doc: "回放录制文件，设置后不访问总线，由录制的响应代替",
+		get: func(c *Config) any { return c.ReplayFile },
+		set: func(c *Config, v any) { c.ReplayFile = v.(string) },
+	},
+	{
+		Name: "replay_mode", Type: TypeString, Env: EnvReplayMode, Flag: "replay-mode",
+		Allowed: []string{i2c.ReplayStrict, i2c.ReplayLenient},
+		Doc:     "回放匹配模式，strict 要求顺序和内容一致，lenient 只按内容匹配",
+		get:     func(c *Config) any { return c.ReplayMode },
+		set:     func(c *Config, v any) { c.ReplayMode = v.(string) },
+	},
+}
+
+// Keys 返回所有配置项的描述
+func Keys() []*Key {
+	return keys
+}
+
+// LookupKey 按名称查找配置项，未知名称返回错误
+func LookupKey(name string) (*Key, error) {
+	for _, k := range keys {
+		if k.Name == name {
+			return k, nil
+		}
+	}
+	names := make([]string, len(keys))
+	for i, k := range keys {
+		names[i] = k.Name
+	}
+	sort.Strings(names)
+	return nil, fmt.Errorf("未知配置项: %s (可用配置项: %s)", name, strings.Join(names, ", "))
+}
+
+// Parse 按配置项的类型解析并校验取值
+func (k *Key) Parse(value string) (any, error) {
+	value = strings.TrimSpace(value)
+	switch k.Type {
+	case TypeInt:
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("%s 需要非负整数: %q", k.Name, value)
+		}
+		return n, nil
+	case TypeDuration:
+		ms, err := strconv.Atoi(value)
+		if err != nil {
+			d, derr := time.ParseDuration(value)
+			if derr != nil {
+				return nil, fmt.Errorf("%s 需要毫秒数或时长 (如 500ms): %q", k.Name, value)
+			}
+			ms = int(d / time.Millisecond)
+		}
+		if ms < 0 {
+			return nil, fmt.Errorf("%s 不能为负数: %q", k.Name, value)
+		}
+		return ms, nil
+	case TypeBool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s 需要 true 或 false: %q", k.Name, value)
+		}
+		return b, nil
+	default:
+		if k.validate != nil {
+			if err := k.validate(value); err != nil {
+				return nil, fmt.Errorf("%s 的取值无效: %v", k.Name, err)
+			}
+		}
+		if len(k.Allowed) == 0 {
+			return value, nil
+		}
+		s := strings.ToLower(value)
+		if !contains(k.Allowed, s) {
+			return nil, fmt.Errorf("%s 的取值无效: %q (可选: %s)", k.Name, value, strings.Join(k.Allowed, ", "))
+		}
+		return s, nil
+	}
+}
+
+// Default 返回配置项的内置默认值
+func (k *Key) Default() string {
+	return fmt.Sprint(k.get(DefaultConfig()))
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// 配置值来源
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+)
+
+// Source 记录一个配置值来自哪里
+type Source struct {
+	Kind   string // default, file, env, flag
+	Detail string // 文件路径、环境变量名或参数名
+}
+
+func (s Source) String() string {
+	switch s.Kind {
+	case SourceFile:
+		return "文件 " + s.Detail
+	case SourceEnv:
+		return "环境变量 " + s.Detail
+	case SourceFlag:
+		return "参数 --" + s.Detail
+	default:
+		return "默认值"
+	}
+}
+
+// Get 返回配置项的当前值
+func (c *Config) Get(name string) (string, error) {
+	k, err := LookupKey(name)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprint(k.get(c)), nil
+}
+
+// Set 解析并设置配置项，同时记录取值来源
+func (c *Config) Set(name, value string, src Source) error {
+	k, err := LookupKey(name)
+	if err != nil {
+		return err
+	}
+	v, err := k.Parse(value)
+	if err != nil {
+		return err
+	}
+	k.set(c, v)
+	c.setSource(name, src)
+	return nil
+}
+
+// Source 返回配置项当前取值的来源
+func (c *Config) Source(name string) Source {
+	if src, ok := c.sources[name]; ok {
+		return src
+	}
+	return Source{Kind: SourceDefault}
+}
+
+func (c *Config) setSource(name string, src Source) {
+	if c.sources == nil {
+		c.sources = make(map[string]Source)
+	}
+	c.sources[name] = src
+}
+
+// Validate 按模式检查所有配置项，错误信息中包含取值来源
+func (c *Config) Validate() error {
+	for _, k := range keys {
+		if _, err := k.Parse(fmt.Sprint(k.get(c))); err != nil {
+			return fmt.Errorf("%v (来自%s)", err, c.Source(k.Name))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestKeyParse(t *testing.T) {
+	tests := []struct {
+		key, value string
+		want       any
+		ok         bool
+	}{
+		{"default_bus", "2", 2, true},
+		{"default_bus", "-1", nil, false},
+		{"default_timeout", "1500", 1500, true},
+		{"default_timeout", "2s", 2000, true},
+		{"default_timeout", "soon", nil, false},
+		{"mock_mode", "false", false, true},
+		{"mock_mode", "maybe", nil, false},
+		{"log_level", "WARN", "warn", true},
+		{"log_level", "loud", nil, false},
+		{"output_format", "hex", "hex", true},
+		{"output_format", "xml", nil, false},
+	}
+	for _, tt := range tests {
+		k, err := LookupKey(tt.key)
+		if err != nil {
+			t.Fatalf("LookupKey(%s): %v", tt.key, err)
+		}
+		got, err := k.Parse(tt.value)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("%s.Parse(%q) = %v, %v", tt.key, tt.value, got, err)
+		}
+	}
+
+	if _, err := LookupKey("devices"); err == nil {
+		t.Error("未知配置项应当报错")
+	}
+}
+
+func TestSources(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"default_bus": 2, "log_level": "debug"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(EnvLogLevel, "warn")
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if err := cfg.ApplyEnv(); err != nil {
+		t.Fatalf("ApplyEnv: %v", err)
+	}
+	if err := cfg.Set("mock_mode", "false", Source{Kind: SourceFlag, Detail: "mock"}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	want := map[string]Source{
+		"default_bus":     {Kind: SourceFile, Detail: path},
+		"log_level":       {Kind: SourceEnv, Detail: EnvLogLevel},
+		"mock_mode":       {Kind: SourceFlag, Detail: "mock"},
+		"default_timeout": {Kind: SourceDefault},
+	}
+	for name, src := range want {
+		if got := cfg.Source(name); got != src {
+			t.Errorf("Source(%s) = %v, want %v", name, got, src)
+		}
+	}
+	if cfg.LogLevel != "warn" || cfg.MockMode {
+		t.Errorf("log_level/mock_mode = %s/%t", cfg.LogLevel, cfg.MockMode)
+	}
+}
+
+func TestLoadRejectsInvalidValue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"output_format": "xml"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := LoadConfig(path)
+	if err == nil || !strings.Contains(err.Error(), path) {
+		t.Errorf("无效的 output_format 应当报错并指出文件: %v", err)
+	}
+}
+
+func TestReadWriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "config.json")
+
+	values, err := ReadFile(path)
+	if err != nil || len(values) != 0 {
+		t.Fatalf("ReadFile 不存在的文件 = %v, %v", values, err)
+	}
+	values["default_bus"] = 3
+	if err := WriteFile(path, values); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if cfg.DefaultBus != 3 || cfg.DefaultRetries != DefaultConfig().DefaultRetries {
+		t.Errorf("bus/retries = %d/%d", cfg.DefaultBus, cfg.DefaultRetries)
+	}
+}
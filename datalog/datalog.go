@@ -0,0 +1,127 @@
+// Package datalog 实现寄存器时间序列采集的调度、记录格式和轮转文件输出
+package datalog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Source 一个采集源：设备上从 Reg 开始的 Count 个字节
+type Source struct {
+	Addr  uint8 `json:"addr"`
+	Reg   uint8 `json:"reg"`
+	Count int   `json:"count"`
+}
+
+// ParseSource 解析 "ADDR:REG[:COUNT]" 形式的采集源，如 "0x48:0x00:2"
+func ParseSource(s string) (Source, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return Source{}, fmt.Errorf("无效的采集源 %q，格式为 ADDR:REG[:COUNT]", s)
+	}
+
+	addr, err := strconv.ParseUint(parts[0], 0, 8)
+	if err != nil {
+		return Source{}, fmt.Errorf("采集源 %q: 无效的设备地址", s)
+	}
+	reg, err := strconv.ParseUint(parts[1], 0, 8)
+	if err != nil {
+		return Source{}, fmt.Errorf("采集源 %q: 无效的寄存器地址", s)
+	}
+
+	src := Source{Addr: uint8(addr), Reg: uint8(reg), Count: 1}
+	if len(parts) == 3 {
+		count, err := strconv.Atoi(parts[2])
+		if err != nil {
+			return Source{}, fmt.Errorf("采集源 %q: 无效的字节数", s)
+		}
+		src.Count = count
+	}
+
+	if src.Count <= 0 || int(src.Reg)+src.Count > 0x100 {
+		return Source{}, fmt.Errorf("采集源 %q: 超出寄存器地址范围", s)
+	}
+	return src, nil
+}
+
+// String 以 "0x48:0x00:2" 形式格式化采集源
+func (s Source) String() string {
+	return fmt.Sprintf("0x%02X:0x%02X:%d", s.Addr, s.Reg, s.Count)
+}
+
+// Columns 返回采集源每个字节的列名，如 "0x48:0x00"
+func (s Source) Columns() []string {
+	columns := make([]string, s.Count)
+	for i := range columns {
+		columns[i] = fmt.Sprintf("0x%02X:0x%02X", s.Addr, int(s.Reg)+i)
+	}
+	return columns
+}
+
+// Header 日志文件头，描述采集配置
+type Header struct {
+	Version  string        `json:"version"`
+	Bus      int           `json:"bus"`
+	Interval time.Duration `json:"interval_ns"`
+	Started  time.Time     `json:"started"`
+	Sources  []Source      `json:"sources"`
+	Part     int           `json:"part"`
+}
+
+// Reading 一个采集源的读取结果，Err 非空时 Data 为 nil
+type Reading struct {
+	Source Source
+	Data   []byte
+	Err    error
+}
+
+// Sample 一次采样
+type Sample struct {
+	Seq      int64         // 采样序号，从 0 开始，跳过的采样点不占用序号
+	Time     time.Time     // 实际采样时间
+	Elapsed  time.Duration // 相对采集开始的时间
+	Missed   int64         // 本次采样前因处理过慢而跳过的采样点数
+	Readings []Reading
+}
+
+// Errors 返回采样中失败的读取数
+func (s *Sample) Errors() int {
+	n := 0
+	for _, r := range s.Readings {
+		if r.Err != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// Schedule 无漂移的固定间隔调度
+//
+// 第 n 个采样点固定在 start + n*interval，不受单次采样耗时影响，
+// 因此误差不会随时间累积。处理过慢时跳过已经错过的采样点。
+type Schedule struct {
+	start    time.Time
+	interval time.Duration
+	next     int64
+}
+
+// NewSchedule 创建从 start 开始的调度
+func NewSchedule(start time.Time, interval time.Duration) *Schedule {
+	return &Schedule{start: start, interval: interval}
+}
+
+// Next 返回当前时间之后的下一个采样点，以及因已经错过而跳过的采样点数
+func (s *Schedule) Next(now time.Time) (time.Time, int64) {
+	tick := s.start.Add(time.Duration(s.next) * s.interval)
+
+	var missed int64
+	if late := now.Sub(tick); late >= s.interval {
+		missed = int64(late / s.interval)
+		tick = tick.Add(time.Duration(missed) * s.interval)
+	}
+
+	s.next += missed + 1
+	return tick, missed
+}
@@ -0,0 +1,160 @@
+package datalog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSource(t *testing.T) {
+	src, err := ParseSource("0x48:0x00:2")
+	if err != nil || src != (Source{Addr: 0x48, Reg: 0x00, Count: 2}) {
+		t.Fatalf("解析失败: %+v, %v", src, err)
+	}
+	if cols := src.Columns(); len(cols) != 2 || cols[1] != "0x48:0x01" {
+		t.Errorf("列名错误: %v", cols)
+	}
+
+	if src, err := ParseSource("0x68:0x3B"); err != nil || src.Count != 1 {
+		t.Errorf("默认字节数应为 1: %+v, %v", src, err)
+	}
+
+	for _, s := range []string{"", "0x48", "0x48:0x00:0", "0x48:0xFF:2", "0x148:0x00", "a:b", "1:2:3:4"} {
+		if _, err := ParseSource(s); err == nil {
+			t.Errorf("ParseSource(%q) 应该失败", s)
+		}
+	}
+}
+
+func TestScheduleIsDriftFree(t *testing.T) {
+	start := time.Unix(1000, 0)
+	s := NewSchedule(start, 100*time.Millisecond)
+
+	// 每次采样都耗时 30ms，采样点仍固定在 100ms 的整数倍上
+	now := start
+	for i := 0; i < 5; i++ {
+		tick, missed := s.Next(now)
+		if want := start.Add(time.Duration(i) * 100 * time.Millisecond); !tick.Equal(want) || missed != 0 {
+			t.Fatalf("第 %d 个采样点 %v (跳过 %d)，期望 %v", i, tick.Sub(start), missed, want.Sub(start))
+		}
+		now = tick.Add(30 * time.Millisecond)
+	}
+
+	// 处理耗时 350ms，跳过错过的采样点后回到原来的节拍上
+	tick, missed := s.Next(start.Add(850 * time.Millisecond))
+	if tick.Sub(start) != 800*time.Millisecond || missed != 3 {
+		t.Errorf("期望跳过 3 个采样点并对齐到 800ms，实际 %v (跳过 %d)", tick.Sub(start), missed)
+	}
+	if tick, _ := s.Next(tick); tick.Sub(start) != 900*time.Millisecond {
+		t.Errorf("期望下一个采样点 900ms，实际 %v", tick.Sub(start))
+	}
+}
+
+func testSample(seq int64, t time.Time, err error) *Sample {
+	src := Source{Addr: 0x48, Reg: 0x00, Count: 2}
+	r := Reading{Source: src, Data: []byte{0x19, byte(seq)}}
+	if err != nil {
+		r = Reading{Source: src, Err: err}
+	}
+	return &Sample{Seq: seq, Time: t, Readings: []Reading{r}}
+}
+
+func TestCSVSinkRotation(t *testing.T) {
+	start := time.Unix(1000, 0)
+	header := Header{Version: "test", Bus: 1, Interval: time.Second, Started: start,
+		Sources: []Source{{Addr: 0x48, Reg: 0x00, Count: 2}}}
+
+	prefix := filepath.Join(t.TempDir(), "run", "log")
+	sink, err := NewFileSink(prefix+".csv", FormatCSV, RotateOptions{MaxAge: 3 * time.Second}, header)
+	if err != nil {
+		t.Fatalf("创建日志失败: %v", err)
+	}
+	for i := int64(0); i < 5; i++ {
+		var readErr error
+		if i == 1 {
+			readErr = errors.New("无应答")
+		}
+		if err := sink.Write(testSample(i, start.Add(time.Duration(i)*time.Second), readErr)); err != nil {
+			t.Fatalf("写入失败: %v", err)
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("关闭失败: %v", err)
+	}
+
+	files := sink.Files()
+	if len(files) != 2 || files[0] != prefix+"-001.csv" {
+		t.Fatalf("期望轮转为 2 个文件，实际 %v", files)
+	}
+
+	data, _ := os.ReadFile(files[0])
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if !strings.HasPrefix(lines[0], "# sensorcli test") {
+		t.Errorf("缺少文件头: %q", lines[0])
+	}
+	if lines[6] != "timestamp,elapsed_ms,seq,missed,0x48:0x00,0x48:0x01,errors" {
+		t.Errorf("列名错误: %q", lines[6])
+	}
+	if len(lines) != 10 || !strings.HasSuffix(lines[8], ",,,0x48: 无应答") {
+		t.Errorf("第一个文件内容错误:\n%s", data)
+	}
+
+	data, _ = os.ReadFile(files[1])
+	if !strings.Contains(string(data), "# part: 2") || !strings.Contains(string(data), ",3,0,0x19,0x03,") {
+		t.Errorf("第二个文件内容错误:\n%s", data)
+	}
+
+	// 相同前缀再次记录时不覆盖已有文件
+	first, _ := os.ReadFile(files[0])
+	if _, err := NewFileSink(prefix, FormatCSV, RotateOptions{}, header); !errors.Is(err, ErrExists) {
+		t.Errorf("已有文件时期望 ErrExists，得到 %v", err)
+	}
+	if after, _ := os.ReadFile(files[0]); !bytes.Equal(after, first) {
+		t.Error("已有的日志文件被修改")
+	}
+}
+
+func TestNDJSONSink(t *testing.T) {
+	start := time.Unix(1000, 0)
+	header := Header{Version: "test", Bus: 1, Interval: time.Second, Started: start,
+		Sources: []Source{{Addr: 0x48, Reg: 0x00, Count: 2}}}
+
+	sink, err := NewFileSink(filepath.Join(t.TempDir(), "log"), FormatNDJSON, RotateOptions{MaxBytes: 1}, header)
+	if err != nil {
+		t.Fatalf("创建日志失败: %v", err)
+	}
+	sink.Write(testSample(0, start, nil))
+	sink.Write(testSample(1, start.Add(time.Second), errors.New("超时")))
+	sink.Close()
+
+	// 每个文件只容纳一次采样
+	if len(sink.Files()) != 2 {
+		t.Fatalf("期望 2 个文件，实际 %v", sink.Files())
+	}
+
+	f, _ := os.Open(sink.Files()[1])
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+
+	var lines []map[string]interface{}
+	for scanner.Scan() {
+		var line map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("无效的 JSON 行: %v", err)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) != 2 || lines[0]["type"] != "header" || lines[0]["part"] != 2.0 {
+		t.Fatalf("文件头错误: %v", lines)
+	}
+	readings := lines[1]["readings"].([]interface{})
+	if r := readings[0].(map[string]interface{}); r["error"] != "超时" || r["data"] != nil {
+		t.Errorf("读取错误应该被记录: %v", r)
+	}
+}
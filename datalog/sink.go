@@ -0,0 +1,306 @@
+package datalog
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 日志文件格式
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// 缓冲数据的最长保留时间，超过后写入磁盘
+const flushInterval = time.Second
+
+// encoder 将文件头和采样编码为某种文件格式
+type encoder interface {
+	header(w io.Writer, h *Header) error
+	sample(w io.Writer, s *Sample) error
+}
+
+func newEncoder(format string) (encoder, error) {
+	switch format {
+	case FormatCSV:
+		return csvEncoder{}, nil
+	case FormatNDJSON:
+		return ndjsonEncoder{}, nil
+	default:
+		return nil, fmt.Errorf("不支持的日志格式: %s", format)
+	}
+}
+
+// RotateOptions 日志文件轮转条件，为零表示不限制
+type RotateOptions struct {
+	MaxBytes int64
+	MaxAge   time.Duration
+}
+
+// FileSink 写入按大小或时间轮转的日志文件
+//
+// 文件名为 <prefix>-<序号>.<格式>，每个文件都以描述采集配置的文件头开始。
+type FileSink struct {
+	prefix string
+	format string
+	enc    encoder
+	opts   RotateOptions
+	header Header
+
+	file    *os.File
+	buf     *bufio.Writer
+	written int64
+	samples int
+	opened  time.Time
+	flushed time.Time
+	files   []string
+}
+
+// ErrExists 日志文件已存在，FileSink 不覆盖已有文件
+var ErrExists = errors.New("日志文件已存在")
+
+// NewFileSink 创建日志输出并打开第一个文件
+func NewFileSink(prefix, format string, opts RotateOptions, header Header) (*FileSink, error) {
+	enc, err := newEncoder(format)
+	if err != nil {
+		return nil, err
+	}
+
+	// 允许 --output run1.csv 这样带扩展名的写法
+	prefix = strings.TrimSuffix(prefix, "."+format)
+	if dir := filepath.Dir(prefix); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("创建日志目录失败: %v", err)
+		}
+	}
+
+	sink := &FileSink{prefix: prefix, format: format, enc: enc, opts: opts, header: header}
+	if err := sink.rotate(header.Started); err != nil {
+		return nil, err
+	}
+	return sink, nil
+}
+
+// Files 返回已创建的所有日志文件
+func (s *FileSink) Files() []string {
+	return s.files
+}
+
+// Write 写入一次采样，必要时先轮转文件
+func (s *FileSink) Write(sample *Sample) error {
+	if s.needRotate(sample.Time) {
+		if err := s.rotate(sample.Time); err != nil {
+			return err
+		}
+	}
+
+	if err := s.enc.sample(sinkWriter{s}, sample); err != nil {
+		return fmt.Errorf("写入日志失败: %v", err)
+	}
+	s.samples++
+
+	if sample.Time.Sub(s.flushed) >= flushInterval {
+		s.flushed = sample.Time
+		return s.Flush()
+	}
+	return nil
+}
+
+// Flush 将缓冲的数据写入磁盘
+func (s *FileSink) Flush() error {
+	if err := s.buf.Flush(); err != nil {
+		return fmt.Errorf("写入日志失败: %v", err)
+	}
+	return nil
+}
+
+// Close 写入缓冲数据并关闭当前文件
+func (s *FileSink) Close() error {
+	if s.file == nil {
+		return nil
+	}
+
+	err := s.Flush()
+	if cerr := s.file.Close(); err == nil && cerr != nil {
+		err = fmt.Errorf("关闭日志文件失败: %v", cerr)
+	}
+	s.file = nil
+	return err
+}
+
+// writeBytes 写入缓冲并统计当前文件的字节数
+func (s *FileSink) writeBytes(p []byte) (int, error) {
+	n, err := s.buf.Write(p)
+	s.written += int64(n)
+	return n, err
+}
+
+// needRotate 判断是否需要轮转，每个文件至少包含一次采样
+func (s *FileSink) needRotate(now time.Time) bool {
+	if s.samples == 0 {
+		return false
+	}
+	if s.opts.MaxBytes > 0 && s.written >= s.opts.MaxBytes {
+		return true
+	}
+	return s.opts.MaxAge > 0 && now.Sub(s.opened) >= s.opts.MaxAge
+}
+
+func (s *FileSink) rotate(now time.Time) error {
+	if err := s.Close(); err != nil {
+		return err
+	}
+
+	s.header.Part = len(s.files) + 1
+	path := fmt.Sprintf("%s-%03d.%s", s.prefix, s.header.Part, s.format)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0644)
+	if os.IsExist(err) {
+		return fmt.Errorf("%w: %s", ErrExists, path)
+	}
+	if err != nil {
+		return fmt.Errorf("创建日志文件失败: %v", err)
+	}
+
+	s.file = file
+	s.buf = bufio.NewWriter(file)
+	s.written = 0
+	s.samples = 0
+	s.opened = now
+	s.flushed = now
+	s.files = append(s.files, path)
+
+	if err := s.enc.header(sinkWriter{s}, &s.header); err != nil {
+		return fmt.Errorf("写入日志文件头失败: %v", err)
+	}
+	return nil
+}
+
+// sinkWriter 让编码器的写入经过字节统计
+type sinkWriter struct {
+	s *FileSink
+}
+
+func (w sinkWriter) Write(p []byte) (int, error) {
+	return w.s.writeBytes(p)
+}
+
+// csvEncoder 以 "# " 开头的注释行作为文件头，每个寄存器字节一列
+type csvEncoder struct{}
+
+func (csvEncoder) header(w io.Writer, h *Header) error {
+	sources := make([]string, len(h.Sources))
+	for i, src := range h.Sources {
+		sources[i] = src.String()
+	}
+
+	fmt.Fprintf(w, "# sensorcli %s 数据记录\n", h.Version)
+	fmt.Fprintf(w, "# bus: %d\n", h.Bus)
+	fmt.Fprintf(w, "# interval: %v\n", h.Interval)
+	fmt.Fprintf(w, "# started: %s\n", h.Started.Format(time.RFC3339Nano))
+	fmt.Fprintf(w, "# sources: %s\n", strings.Join(sources, " "))
+	fmt.Fprintf(w, "# part: %d\n", h.Part)
+
+	columns := []string{"timestamp", "elapsed_ms", "seq", "missed"}
+	for _, src := range h.Sources {
+		columns = append(columns, src.Columns()...)
+	}
+	columns = append(columns, "errors")
+
+	cw := csv.NewWriter(w)
+	cw.Write(columns)
+	cw.Flush()
+	return cw.Error()
+}
+
+func (csvEncoder) sample(w io.Writer, s *Sample) error {
+	record := []string{
+		s.Time.Format(time.RFC3339Nano),
+		strconv.FormatFloat(float64(s.Elapsed)/float64(time.Millisecond), 'f', 3, 64),
+		strconv.FormatInt(s.Seq, 10),
+		strconv.FormatInt(s.Missed, 10),
+	}
+
+	var errs []string
+	for _, r := range s.Readings {
+		for i := 0; i < r.Source.Count; i++ {
+			if r.Err != nil {
+				record = append(record, "")
+			} else {
+				record = append(record, fmt.Sprintf("0x%02X", r.Data[i]))
+			}
+		}
+		if r.Err != nil {
+			errs = append(errs, fmt.Sprintf("0x%02X: %v", r.Source.Addr, r.Err))
+		}
+	}
+	record = append(record, strings.Join(errs, "; "))
+
+	cw := csv.NewWriter(w)
+	cw.Write(record)
+	cw.Flush()
+	return cw.Error()
+}
+
+// ndjsonEncoder 第一行为 type=header 的对象，之后每行一次采样
+type ndjsonEncoder struct{}
+
+type ndjsonHeader struct {
+	Type string `json:"type"`
+	*Header
+}
+
+type ndjsonReading struct {
+	Addr  string `json:"addr"`
+	Reg   string `json:"reg"`
+	Data  []int  `json:"data,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+type ndjsonSample struct {
+	Type      string          `json:"type"`
+	Timestamp time.Time       `json:"timestamp"`
+	ElapsedMs float64         `json:"elapsed_ms"`
+	Seq       int64           `json:"seq"`
+	Missed    int64           `json:"missed,omitempty"`
+	Readings  []ndjsonReading `json:"readings"`
+}
+
+func (ndjsonEncoder) header(w io.Writer, h *Header) error {
+	return json.NewEncoder(w).Encode(ndjsonHeader{Type: "header", Header: h})
+}
+
+func (ndjsonEncoder) sample(w io.Writer, s *Sample) error {
+	out := ndjsonSample{
+		Type:      "sample",
+		Timestamp: s.Time,
+		ElapsedMs: float64(s.Elapsed) / float64(time.Millisecond),
+		Seq:       s.Seq,
+		Missed:    s.Missed,
+		Readings:  make([]ndjsonReading, len(s.Readings)),
+	}
+	for i, r := range s.Readings {
+		reading := ndjsonReading{
+			Addr: fmt.Sprintf("0x%02X", r.Source.Addr),
+			Reg:  fmt.Sprintf("0x%02X", r.Source.Reg),
+		}
+		if r.Err != nil {
+			reading.Error = r.Err.Error()
+		} else {
+			reading.Data = make([]int, len(r.Data))
+			for j, b := range r.Data {
+				reading.Data[j] = int(b)
+			}
+		}
+		out.Readings[i] = reading
+	}
+	return json.NewEncoder(w).Encode(out)
+}
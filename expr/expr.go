@@ -0,0 +1,426 @@
+// Package expr 实现用于寄存器条件判断的简单整数表达式
+//
+// 表达式支持整数字面量（十进制、0x 十六进制、0b 二进制）、寄存器引用 reg[N]、
+// 括号以及以下运算符，优先级与 Go 语言相同（从高到低）:
+//
+//	一元   - ^ !
+//	5      * / % << >> & &^
+//	4      + - | ^
+//	3      == != < <= > >=
+//	2      &&
+//	1      ||
+//
+// 非零值视为真，比较和逻辑运算的结果为 1 或 0。
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Env 表达式求值时读取寄存器值，寄存器不可用时返回 false
+type Env func(reg uint8) (uint8, bool)
+
+// Expr 解析后的表达式
+type Expr struct {
+	source string
+	root   node
+}
+
+// Parse 解析表达式
+func Parse(source string) (*Expr, error) {
+	p := &parser{lex: lexer{src: source}}
+	p.next()
+
+	root, err := p.parseBinary(1)
+	if err != nil {
+		return nil, fmt.Errorf("解析表达式 %q 失败: %v", source, err)
+	}
+	if p.tok.kind != tokEOF {
+		return nil, fmt.Errorf("解析表达式 %q 失败: 位置 %d 处有多余的 %q", source, p.tok.pos, p.tok.text)
+	}
+	return &Expr{source: source, root: root}, nil
+}
+
+// String 返回表达式原文
+func (e *Expr) String() string {
+	return e.source
+}
+
+// Registers 返回表达式引用的所有寄存器地址
+func (e *Expr) Registers() []uint8 {
+	var regs []uint8
+	seen := make(map[uint8]bool)
+	walk(e.root, func(n node) {
+		if r, ok := n.(regNode); ok && !seen[uint8(r)] {
+			seen[uint8(r)] = true
+			regs = append(regs, uint8(r))
+		}
+	})
+	return regs
+}
+
+// Eval 计算表达式的值
+func (e *Expr) Eval(env Env) (int64, error) {
+	return e.root.eval(env)
+}
+
+// True 计算表达式并判断结果是否为真
+func (e *Expr) True(env Env) (bool, error) {
+	v, err := e.Eval(env)
+	return v != 0, err
+}
+
+// Bytes 返回读取从 start 开始连续数据的求值环境
+func Bytes(start uint8, data []byte) Env {
+	return func(reg uint8) (uint8, bool) {
+		offset := int(reg) - int(start)
+		if offset < 0 || offset >= len(data) {
+			return 0, false
+		}
+		return data[offset], true
+	}
+}
+
+// 语法树
+
+type node interface {
+	eval(env Env) (int64, error)
+}
+
+type numNode int64
+
+type regNode uint8
+
+type unaryNode struct {
+	op string
+	x  node
+}
+
+type binaryNode struct {
+	op   string
+	x, y node
+}
+
+func (n numNode) eval(Env) (int64, error) {
+	return int64(n), nil
+}
+
+func (n regNode) eval(env Env) (int64, error) {
+	v, ok := env(uint8(n))
+	if !ok {
+		return 0, fmt.Errorf("寄存器 0x%02X 不在读取范围内", uint8(n))
+	}
+	return int64(v), nil
+}
+
+func (n unaryNode) eval(env Env) (int64, error) {
+	x, err := n.x.eval(env)
+	if err != nil {
+		return 0, err
+	}
+	switch n.op {
+	case "-":
+		return -x, nil
+	case "^":
+		return ^x, nil
+	default: // "!"
+		return boolInt(x == 0), nil
+	}
+}
+
+func (n binaryNode) eval(env Env) (int64, error) {
+	x, err := n.x.eval(env)
+	if err != nil {
+		return 0, err
+	}
+
+	// 逻辑运算短路求值
+	switch n.op {
+	case "&&":
+		if x == 0 {
+			return 0, nil
+		}
+	case "||":
+		if x != 0 {
+			return 1, nil
+		}
+	}
+
+	y, err := n.y.eval(env)
+	if err != nil {
+		return 0, err
+	}
+
+	switch n.op {
+	case "&&", "||":
+		return boolInt(y != 0), nil
+	case "==":
+		return boolInt(x == y), nil
+	case "!=":
+		return boolInt(x != y), nil
+	case "<":
+		return boolInt(x < y), nil
+	case "<=":
+		return boolInt(x <= y), nil
+	case ">":
+		return boolInt(x > y), nil
+	case ">=":
+		return boolInt(x >= y), nil
+	case "+":
+		return x + y, nil
+	case "-":
+		return x - y, nil
+	case "|":
+		return x | y, nil
+	case "^":
+		return x ^ y, nil
+	case "*":
+		return x * y, nil
+	case "/", "%":
+		if y == 0 {
+			return 0, fmt.Errorf("除数为零")
+		}
+		if n.op == "/" {
+			return x / y, nil
+		}
+		return x % y, nil
+	case "<<", ">>":
+		if y < 0 || y > 63 {
+			return 0, fmt.Errorf("无效的移位位数 %d", y)
+		}
+		if n.op == "<<" {
+			return x << y, nil
+		}
+		return x >> y, nil
+	case "&":
+		return x & y, nil
+	default: // "&^"
+		return x &^ y, nil
+	}
+}
+
+func boolInt(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func walk(n node, fn func(node)) {
+	fn(n)
+	switch n := n.(type) {
+	case unaryNode:
+		walk(n.x, fn)
+	case binaryNode:
+		walk(n.x, fn)
+		walk(n.y, fn)
+	}
+}
+
+// 词法分析
+
+type tokKind int
+
+const (
+	tokEOF tokKind = iota
+	tokNum
+	tokIdent
+	tokOp
+)
+
+type token struct {
+	kind tokKind
+	text string
+	pos  int
+}
+
+type lexer struct {
+	src string
+	pos int
+}
+
+// 按长度从长到短排列，保证最长匹配
+var operators = []string{
+	"&&", "||", "==", "!=", "<=", ">=", "<<", ">>", "&^",
+	"+", "-", "*", "/", "%", "&", "|", "^", "!", "<", ">", "(", ")", "[", "]",
+}
+
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.src) && unicode.IsSpace(rune(l.src[l.pos])) {
+		l.pos++
+	}
+	if l.pos >= len(l.src) {
+		return token{kind: tokEOF, pos: l.pos}, nil
+	}
+
+	start := l.pos
+	c := l.src[l.pos]
+	switch {
+	case c >= '0' && c <= '9':
+		for l.pos < len(l.src) && isWordChar(l.src[l.pos]) {
+			l.pos++
+		}
+		return token{kind: tokNum, text: l.src[start:l.pos], pos: start}, nil
+	case isWordChar(c):
+		for l.pos < len(l.src) && isWordChar(l.src[l.pos]) {
+			l.pos++
+		}
+		return token{kind: tokIdent, text: l.src[start:l.pos], pos: start}, nil
+	}
+
+	for _, op := range operators {
+		if strings.HasPrefix(l.src[l.pos:], op) {
+			l.pos += len(op)
+			return token{kind: tokOp, text: op, pos: start}, nil
+		}
+	}
+	return token{}, fmt.Errorf("位置 %d 处有无效字符 %q", start, c)
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// 语法分析
+
+type parser struct {
+	lex lexer
+	tok token
+	err error
+}
+
+func (p *parser) next() {
+	if p.err != nil {
+		return
+	}
+	p.tok, p.err = p.lex.next()
+	if p.err != nil {
+		p.tok = token{kind: tokEOF, pos: p.lex.pos}
+	}
+}
+
+func precedence(op string) int {
+	switch op {
+	case "||":
+		return 1
+	case "&&":
+		return 2
+	case "==", "!=", "<", "<=", ">", ">=":
+		return 3
+	case "+", "-", "|", "^":
+		return 4
+	case "*", "/", "%", "<<", ">>", "&", "&^":
+		return 5
+	}
+	return 0
+}
+
+// parseBinary 解析优先级不低于 prec 的二元表达式
+func (p *parser) parseBinary(prec int) (node, error) {
+	x, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.tok.kind == tokOp && precedence(p.tok.text) >= prec {
+		op := p.tok.text
+		p.next()
+		y, err := p.parseBinary(precedence(op) + 1)
+		if err != nil {
+			return nil, err
+		}
+		x = binaryNode{op: op, x: x, y: y}
+	}
+	return x, p.err
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.tok.kind == tokOp {
+		switch op := p.tok.text; op {
+		case "-", "^", "!":
+			p.next()
+			x, err := p.parseUnary()
+			if err != nil {
+				return nil, err
+			}
+			return unaryNode{op: op, x: x}, nil
+		case "(":
+			p.next()
+			x, err := p.parseBinary(1)
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return x, nil
+		}
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	tok := p.tok
+	switch tok.kind {
+	case tokNum:
+		p.next()
+		v, err := parseNumber(tok.text)
+		if err != nil {
+			return nil, err
+		}
+		return numNode(v), nil
+	case tokIdent:
+		if !strings.EqualFold(tok.text, "reg") {
+			return nil, fmt.Errorf("位置 %d 处有未知标识符 %q", tok.pos, tok.text)
+		}
+		p.next()
+		if err := p.expect("["); err != nil {
+			return nil, err
+		}
+		addr := p.tok
+		if addr.kind != tokNum {
+			return nil, fmt.Errorf("位置 %d 处应为寄存器地址", addr.pos)
+		}
+		v, err := parseNumber(addr.text)
+		if err != nil {
+			return nil, err
+		}
+		if v < 0 || v > 0xFF {
+			return nil, fmt.Errorf("寄存器地址 %s 超出范围", addr.text)
+		}
+		p.next()
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		return regNode(v), nil
+	case tokEOF:
+		return nil, fmt.Errorf("表达式不完整")
+	}
+	return nil, fmt.Errorf("位置 %d 处有意外的 %q", tok.pos, tok.text)
+}
+
+func (p *parser) expect(op string) error {
+	if p.err != nil {
+		return p.err
+	}
+	if p.tok.kind != tokOp || p.tok.text != op {
+		return fmt.Errorf("位置 %d 处应为 %q", p.tok.pos, op)
+	}
+	p.next()
+	return p.err
+}
+
+func parseNumber(text string) (int64, error) {
+	v, err := strconv.ParseInt(text, 0, 64)
+	if err != nil {
+		return 0, fmt.Errorf("无效的数字 %q", text)
+	}
+	return v, nil
+}
@@ -0,0 +1,74 @@
+package expr
+
+import "testing"
+
+func TestEval(t *testing.T) {
+	env := Bytes(0x10, []byte{0x80, 0x05, 0xFF})
+
+	cases := []struct {
+		source string
+		want   int64
+	}{
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3", 9},
+		{"reg[0x10]", 0x80},
+		{"reg[0x10] & 0x80", 0x80},
+		{"reg[0x10]&0x80 == 0x80", 1},
+		{"reg[0x11] >> 1 | 0b1000", 0x0A},
+		{"reg[0x11] > 4 && reg[0x12] == 255", 1},
+		{"!reg[0x10] || reg[0x11] &^ 0x04 == 1", 1},
+		{"-reg[0x11] + 10", 5},
+		{"^0 & 0xF", 0xF},
+		{"REG[16] % 3", 2},
+	}
+	for _, c := range cases {
+		e, err := Parse(c.source)
+		if err != nil {
+			t.Errorf("解析 %q 失败: %v", c.source, err)
+			continue
+		}
+		got, err := e.Eval(env)
+		if err != nil || got != c.want {
+			t.Errorf("%q = %d, %v，期望 %d", c.source, got, err, c.want)
+		}
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	env := Bytes(0x00, []byte{0x01})
+
+	for _, source := range []string{"reg[0x05]", "1 / reg[0x00] / 0", "1 << 64"} {
+		e, err := Parse(source)
+		if err != nil {
+			t.Fatalf("解析 %q 失败: %v", source, err)
+		}
+		if _, err := e.Eval(env); err == nil {
+			t.Errorf("%q 求值应该失败", source)
+		}
+	}
+
+	// 短路求值不访问右侧的寄存器
+	e, _ := Parse("reg[0x00] == 1 || reg[0x05]")
+	if ok, err := e.True(env); !ok || err != nil {
+		t.Errorf("短路求值失败: %v, %v", ok, err)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, source := range []string{"", "1 +", "(1", "reg[]", "reg[0x100]", "foo", "1 2", "reg 1", "1 @ 2", "0xZZ"} {
+		if _, err := Parse(source); err == nil {
+			t.Errorf("解析 %q 应该失败", source)
+		}
+	}
+}
+
+func TestRegisters(t *testing.T) {
+	e, err := Parse("reg[0x01] & 1 && (reg[0x03] > reg[0x01])")
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	regs := e.Registers()
+	if len(regs) != 2 || regs[0] != 0x01 || regs[1] != 0x03 {
+		t.Errorf("期望引用 0x01 和 0x03，实际 %X", regs)
+	}
+}
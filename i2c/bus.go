@@ -0,0 +1,318 @@
+package i2c
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// adapter 总线适配器，为总线上的地址打开设备，关闭设备不关闭适配器
+type adapter interface {
+	open(config *DeviceConfig) (Device, error)
+	close() error
+}
+
+// mockAdapter 模拟总线适配器，模拟总线的状态在进程内按描述文件和总线号共享
+type mockAdapter struct{}
+
+func (mockAdapter) open(config *DeviceConfig) (Device, error) {
+	return openMock(config)
+}
+
+func (mockAdapter) close() error {
+	return nil
+}
+
+// Bus 多个 goroutine 共享的I2C总线
+//
+// Bus 持有底层适配器 (如 /dev/i2c-N)，为各地址提供设备句柄。所有句柄的操作在总线上
+// 按请求顺序依次执行，一个操作 (一次读写或组合传输) 完成前其他操作等待；重试在两次
+// 尝试之间让出总线。需要连续执行的多个操作 (如读-改-写) 用 Do 独占总线。
+// OpenBus 和每个句柄各持有一个引用，Close 和所有句柄都关闭后关闭适配器。
+type Bus struct {
+	config  DeviceConfig
+	adapter adapter
+	lock    fairMutex
+
+	mu     sync.Mutex
+	refs   int
+	closed bool
+}
+
+// OpenBus 打开 config.Bus 指定的总线，config 中除地址外的设置 (超时、重试、中间件等)
+// 用于总线上的所有设备
+func OpenBus(config *DeviceConfig) (*Bus, error) {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
+	a, err := openAdapter(config)
+	if err != nil {
+		return nil, err
+	}
+	return &Bus{config: *config, adapter: a, refs: 1}, nil
+}
+
+// Device 返回地址 addr 上的设备句柄，可在多个 goroutine 中使用，用完后需要关闭
+func (b *Bus) Device(addr uint8) (Device, error) {
+	if err := checkAddress(addr); err != nil {
+		return nil, err
+	}
+	if err := b.acquire(); err != nil {
+		return nil, opError(OpOpen, b.config.Bus, addr, -1, err)
+	}
+
+	config := b.config
+	config.Address = addr
+
+	// 打开时设置的适配器参数 (超时等) 对总线上的所有设备生效，同样需要独占总线
+	b.lock.lock(context.Background())
+	dev, err := b.adapter.open(&config)
+	b.lock.unlock()
+	if err != nil {
+		b.release()
+		return nil, err
+	}
+
+	handle := &busDevice{bus: b, dev: WithContext(dev), addr: addr}
+	return config.chain(handle), nil
+}
+
+// Exclusive 可以独占总线连续执行多个操作的设备，Bus 的句柄和包装它的 Chain 设备都支持
+type Exclusive interface {
+	// Do 独占总线执行 fn，期间其他操作等待；不在共享总线上的设备直接执行 fn
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// busLockKey 标记 ctx 所在的 Do 已经独占的总线
+type busLockKey struct{}
+
+// Do 独占总线执行 fn，等待总线时可以被 ctx 中止
+//
+// fn 中的操作必须使用 fn 收到的 ctx: 带有该 ctx 的操作不再等待总线，其他 ctx 的操作
+// 会一直等到 fn 返回。fn 中的重试不让出总线，嵌套调用 Do 直接执行 fn。
+func (b *Bus) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(busLockKey{}) == b {
+		return fn(ctx)
+	}
+	if err := b.lock.lock(ctx); err != nil {
+		return fmt.Errorf("等待总线 %d 失败: %w", b.config.Bus, err)
+	}
+	defer b.lock.unlock()
+	return fn(context.WithValue(ctx, busLockKey{}, b))
+}
+
+// Close 释放 OpenBus 持有的引用，之后不能再打开设备；已打开的句柄仍可使用，
+// 最后一个句柄关闭时关闭适配器
+func (b *Bus) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	b.mu.Unlock()
+	return b.release()
+}
+
+// acquire 为新句柄增加引用
+func (b *Bus) acquire() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return ErrClosed
+	}
+	b.refs++
+	return nil
+}
+
+// release 减少引用，没有引用时关闭适配器
+func (b *Bus) release() error {
+	b.mu.Lock()
+	b.refs--
+	last := b.refs == 0
+	b.mu.Unlock()
+
+	if last {
+		return b.adapter.close()
+	}
+	return nil
+}
+
+// busDevice 总线上的设备句柄，每个操作独占总线执行
+type busDevice struct {
+	bus  *Bus
+	dev  ContextDevice
+	addr uint8
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// do 等待并独占总线执行 fn，等待可以被 ctx 中止；ctx 来自 Bus.Do 时总线已被独占
+func (d *busDevice) do(ctx context.Context, op string, reg int, fn func() error) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.closed {
+		return opError(op, d.bus.config.Bus, d.addr, reg, ErrClosed)
+	}
+	if ctx.Value(busLockKey{}) == d.bus {
+		return fn()
+	}
+
+	if err := d.bus.lock.lock(ctx); err != nil {
+		return opError(op, d.bus.config.Bus, d.addr, reg, err)
+	}
+	defer d.bus.lock.unlock()
+	return fn()
+}
+
+// Do 在句柄所在的总线上执行 Bus.Do
+func (d *busDevice) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return d.bus.Do(ctx, fn)
+}
+
+func (d *busDevice) ReadRegister(reg uint8) (uint8, error) {
+	return d.ReadRegisterCtx(context.Background(), reg)
+}
+
+func (d *busDevice) WriteRegister(reg, value uint8) error {
+	return d.WriteRegisterCtx(context.Background(), reg, value)
+}
+
+func (d *busDevice) ReadBytes(reg uint8, count int) ([]byte, error) {
+	return d.ReadBytesCtx(context.Background(), reg, count)
+}
+
+func (d *busDevice) WriteBytes(reg uint8, data []byte) error {
+	return d.WriteBytesCtx(context.Background(), reg, data)
+}
+
+func (d *busDevice) ReadRegisterCtx(ctx context.Context, reg uint8) (value uint8, err error) {
+	err = d.do(ctx, OpRead, int(reg), func() error {
+		value, err = d.dev.ReadRegisterCtx(ctx, reg)
+		return err
+	})
+	return value, err
+}
+
+func (d *busDevice) WriteRegisterCtx(ctx context.Context, reg, value uint8) error {
+	return d.do(ctx, OpWrite, int(reg), func() error {
+		return d.dev.WriteRegisterCtx(ctx, reg, value)
+	})
+}
+
+func (d *busDevice) ReadBytesCtx(ctx context.Context, reg uint8, count int) (data []byte, err error) {
+	err = d.do(ctx, OpRead, int(reg), func() error {
+		data, err = d.dev.ReadBytesCtx(ctx, reg, count)
+		return err
+	})
+	return data, err
+}
+
+func (d *busDevice) WriteBytesCtx(ctx context.Context, reg uint8, data []byte) error {
+	return d.do(ctx, OpWrite, int(reg), func() error {
+		return d.dev.WriteBytesCtx(ctx, reg, data)
+	})
+}
+
+func (d *busDevice) Transfer(msgs []Msg) error {
+	return d.TransferCtx(context.Background(), msgs)
+}
+
+func (d *busDevice) TransferCtx(ctx context.Context, msgs []Msg) error {
+	tr, ok := d.dev.(Transferer)
+	if !ok {
+		return fmt.Errorf("设备不支持原始I2C传输")
+	}
+	return d.do(ctx, OpTransfer, -1, func() error {
+		return transferCtx(ctx, tr, msgs)
+	})
+}
+
+// SetPEC 转发给底层设备，使模拟设备能模拟 SMBus PEC
+func (d *busDevice) SetPEC(enabled bool) {
+	if s, ok := d.dev.(pecSetter); ok {
+		s.SetPEC(enabled)
+	}
+}
+
+// Close 关闭句柄，等待进行中的操作完成；重复关闭无效
+func (d *busDevice) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return nil
+	}
+	d.closed = true
+
+	err := d.dev.Close()
+	if rerr := d.bus.release(); err == nil {
+		err = rerr
+	}
+	return err
+}
+
+func (d *busDevice) GetAddress() uint8 {
+	return d.addr
+}
+
+func (d *busDevice) GetBus() int {
+	return d.bus.config.Bus
+}
+
+// fairMutex 按请求顺序授予的互斥锁，释放时直接交给等待最久的请求，
+// 避免某个 goroutine 连续抢到锁而使其他 goroutine 长时间等待
+type fairMutex struct {
+	mu      sync.Mutex
+	locked  bool
+	waiters []chan struct{}
+}
+
+// lock 获取锁，ctx 结束时放弃等待并返回 ctx.Err()
+func (m *fairMutex) lock(ctx context.Context) error {
+	m.mu.Lock()
+	if !m.locked {
+		m.locked = true
+		m.mu.Unlock()
+		return nil
+	}
+	ready := make(chan struct{})
+	m.waiters = append(m.waiters, ready)
+	m.mu.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+	}
+
+	m.mu.Lock()
+	for i, w := range m.waiters {
+		if w == ready {
+			m.waiters = append(m.waiters[:i], m.waiters[i+1:]...)
+			m.mu.Unlock()
+			return ctx.Err()
+		}
+	}
+	m.mu.Unlock()
+
+	// 放弃等待的同时锁已交给自己，转交下一个请求
+	m.unlock()
+	return ctx.Err()
+}
+
+// unlock 释放锁，有等待的请求时直接交给最早的一个
+func (m *fairMutex) unlock() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.waiters) > 0 {
+		close(m.waiters[0])
+		m.waiters = m.waiters[1:]
+		return
+	}
+	m.locked = false
+}
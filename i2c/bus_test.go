@@ -0,0 +1,353 @@
+package i2c
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testAdapter 在同一块模拟芯片上打开设备，检查是否有操作同时访问总线
+type testAdapter struct {
+	chips    sync.Map // addr -> *mockChip
+	inflight atomic.Int32
+	overlaps atomic.Int32
+	closes   atomic.Int32
+}
+
+func (a *testAdapter) open(config *DeviceConfig) (Device, error) {
+	chip, _ := a.chips.LoadOrStore(config.Address, newMockChip())
+	dev := newMockDeviceOn(config, chip.(*mockChip))
+	return Chain(dev, func(next Handler) Handler {
+		return func(ctx context.Context, op *Op) ([]byte, error) {
+			if a.inflight.Add(1) > 1 {
+				a.overlaps.Add(1)
+			}
+			defer a.inflight.Add(-1)
+			time.Sleep(10 * time.Microsecond)
+			return next(ctx, op)
+		}
+	}), nil
+}
+
+func (a *testAdapter) close() error {
+	a.closes.Add(1)
+	return nil
+}
+
+func newTestBus(t *testing.T, a adapter) *Bus {
+	t.Helper()
+	return &Bus{config: DeviceConfig{Bus: 1}, adapter: a, refs: 1}
+}
+
+func TestBusConcurrentAccess(t *testing.T) {
+	a := &testAdapter{}
+	bus := newTestBus(t, a)
+	defer bus.Close()
+
+	const workers, rounds = 16, 50
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			addr := uint8(0x40 + w%4)
+			dev, err := bus.Device(addr)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer dev.Close()
+
+			// 每个 goroutine 只写自己的寄存器，读回的值必须是自己写入的
+			reg := uint8(w / 4 * 2)
+			for i := 0; i < rounds; i++ {
+				want := []byte{uint8(i), uint8(w)}
+				if err := dev.WriteBytes(reg, want); err != nil {
+					t.Error(err)
+					return
+				}
+				got, err := dev.ReadBytes(reg, 2)
+				if err != nil || got[0] != want[0] || got[1] != want[1] {
+					t.Errorf("设备 0x%02X 寄存器 0x%02X: 期望 % X，实际 % X, %v", addr, reg, want, got, err)
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	if n := a.overlaps.Load(); n > 0 {
+		t.Errorf("有 %d 次操作同时访问总线", n)
+	}
+}
+
+func TestBusRefCount(t *testing.T) {
+	a := &testAdapter{}
+	bus := newTestBus(t, a)
+
+	d1, err := bus.Device(0x48)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d2, err := bus.Device(0x49)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bus.Device(0x02); !errors.Is(err, ErrInvalidAddress) {
+		t.Errorf("期望 ErrInvalidAddress，得到 %v", err)
+	}
+
+	bus.Close()
+	if _, err := bus.Device(0x4A); !errors.Is(err, ErrClosed) {
+		t.Errorf("总线关闭后打开设备应返回 ErrClosed，得到 %v", err)
+	}
+	if _, err := d1.ReadRegister(0x00); err != nil {
+		t.Errorf("总线关闭后已打开的句柄应仍可使用: %v", err)
+	}
+
+	d1.Close()
+	d1.Close()
+	if _, err := d1.ReadRegister(0x00); !errors.Is(err, ErrClosed) {
+		t.Errorf("句柄关闭后期望 ErrClosed，得到 %v", err)
+	}
+	if n := a.closes.Load(); n != 0 {
+		t.Fatalf("还有句柄时不应关闭适配器")
+	}
+
+	d2.Close()
+	bus.Close()
+	if n := a.closes.Load(); n != 1 {
+		t.Errorf("最后一个句柄关闭时应关闭适配器一次，实际 %d 次", n)
+	}
+}
+
+func TestBusReadModifyWriteAtomic(t *testing.T) {
+	a := &testAdapter{}
+	bus := newTestBus(t, a)
+	defer bus.Close()
+
+	// 多个句柄同时对同一寄存器加一，读和写之间不能插入其他句柄的操作
+	const workers, rounds = 8, 20
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			dev, err := bus.Device(0x48)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer dev.Close()
+
+			for i := 0; i < rounds; i++ {
+				_, _, err := ReadModifyWriteCtx(context.Background(), WithContext(dev), 0x10, 1, func(old []byte) []byte {
+					return []byte{old[0] + 1}
+				})
+				if err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	dev, err := bus.Device(0x48)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dev.Close()
+	if v, err := dev.ReadRegister(0x10); err != nil || v != workers*rounds {
+		t.Errorf("期望 %d 次加一后为 0x%02X，实际 0x%02X, %v", workers*rounds, workers*rounds, v, err)
+	}
+	if n := a.overlaps.Load(); n > 0 {
+		t.Errorf("有 %d 次操作同时访问总线", n)
+	}
+}
+
+func TestBusDoNested(t *testing.T) {
+	bus := newTestBus(t, &testAdapter{})
+	defer bus.Close()
+	dev, err := bus.Device(0x48)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dev.Close()
+
+	// Do 中使用收到的 ctx 的操作和嵌套的 Do 不再等待总线
+	ex, ok := dev.(Exclusive)
+	if !ok {
+		t.Fatal("总线句柄应支持 Exclusive")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	err = ex.Do(ctx, func(ctx context.Context) error {
+		if err := WithContext(dev).WriteRegisterCtx(ctx, 0x01, 0x5A); err != nil {
+			return err
+		}
+		return bus.Do(ctx, func(ctx context.Context) error {
+			_, err := WithContext(dev).ReadRegisterCtx(ctx, 0x01)
+			return err
+		})
+	})
+	if err != nil {
+		t.Errorf("Do 中的操作应直接执行: %v", err)
+	}
+}
+
+func TestFairMutexOrder(t *testing.T) {
+	var m fairMutex
+	m.lock(context.Background())
+
+	// 按顺序排队，释放后应按相同顺序获得锁
+	var (
+		mu    sync.Mutex
+		order []int
+		wg    sync.WaitGroup
+	)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			m.lock(context.Background())
+			mu.Lock()
+			order = append(order, i)
+			mu.Unlock()
+			m.unlock()
+		}(i)
+		waitForWaiters(t, &m, i+1)
+	}
+
+	m.unlock()
+	wg.Wait()
+	for i, got := range order {
+		if got != i {
+			t.Fatalf("获得锁的顺序应与请求顺序一致: %v", order)
+		}
+	}
+}
+
+func TestFairMutexCancel(t *testing.T) {
+	var m fairMutex
+	m.lock(context.Background())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- m.lock(ctx) }()
+	waitForWaiters(t, &m, 1)
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("期望 context.Canceled，得到 %v", err)
+	}
+
+	// 放弃等待的请求不影响后续加锁
+	m.unlock()
+	lctx, lcancel := context.WithTimeout(context.Background(), time.Second)
+	defer lcancel()
+	if err := m.lock(lctx); err != nil {
+		t.Errorf("取消的请求不应占用锁: %v", err)
+	}
+}
+
+func TestBusDeviceWaitCanceled(t *testing.T) {
+	bus := newTestBus(t, &testAdapter{})
+	defer bus.Close()
+	dev, err := bus.Device(0x48)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dev.Close()
+
+	bus.lock.lock(context.Background())
+	defer bus.lock.unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = WithContext(dev).ReadRegisterCtx(ctx, 0x00)
+	var opErr *Error
+	if !errors.Is(err, context.DeadlineExceeded) || !errors.As(err, &opErr) || opErr.Addr != 0x48 {
+		t.Errorf("等待总线超时应返回带设备信息的 DeadlineExceeded，得到 %v", err)
+	}
+}
+
+func TestOpenBusMock(t *testing.T) {
+	profile := filepath.Join(t.TempDir(), "bus.yaml")
+	if err := os.WriteFile(profile, []byte(testProfileYAML), 0644); err != nil {
+		t.Fatal(err)
+	}
+	bus, err := OpenBus(&DeviceConfig{Bus: 1, MockMode: true, MockProfile: profile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bus.Close()
+
+	if _, err := bus.Device(0x50); !errors.Is(err, ErrNoDevice) {
+		t.Errorf("描述文件中没有的地址应返回 ErrNoDevice，得到 %v", err)
+	}
+
+	// 同一地址的多个句柄共享模拟芯片
+	d1, _ := bus.Device(0x48)
+	d2, _ := bus.Device(0x48)
+	defer d1.Close()
+	defer d2.Close()
+	d1.WriteRegister(0x01, 0x5A)
+	if v, err := d2.ReadRegister(0x01); err != nil || v != 0x5A {
+		t.Errorf("期望 0x5A，实际 0x%02X, %v", v, err)
+	}
+
+	// 句柄支持 SMBus 命令
+	smbus, err := NewSMBus(d1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	smbus.SetPEC(true)
+	if err := smbus.WriteWordData(0x10, 0xBEEF); err != nil {
+		t.Fatal(err)
+	}
+	if w, err := smbus.ReadWordData(0x10); err != nil || w != 0xBEEF {
+		t.Errorf("期望 0xBEEF，实际 0x%04X, %v", w, err)
+	}
+}
+
+func TestMockDeviceCloseWhileReading(t *testing.T) {
+	dev := NewMockDevice(&DeviceConfig{Bus: 1, Address: 0x48, MockMode: true})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if _, err := dev.ReadRegister(0x00); err != nil && !errors.Is(err, ErrClosed) {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	dev.SetFaults(&FaultConfig{Rules: []FaultRule{{Reg: hexByte(0x01), Kind: FaultNACK}}})
+	dev.Close()
+	wg.Wait()
+}
+
+// waitForWaiters 等待锁上有 n 个排队的请求
+func waitForWaiters(t *testing.T, m *fairMutex, n int) {
+	t.Helper()
+	for i := 0; i < 1000; i++ {
+		m.mu.Lock()
+		got := len(m.waiters)
+		m.mu.Unlock()
+		if got == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("等待 %d 个排队请求超时", n)
+}
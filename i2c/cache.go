@@ -0,0 +1,52 @@
+package i2c
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Cache 缓存寄存器读取结果的中间件，ttl 内相同起始寄存器和长度的读取直接返回缓存
+//
+// 任何写入或组合传输都会清空缓存。只适用于读取没有副作用 (读清除、FIFO 等) 的寄存器。
+func Cache(ttl time.Duration) Middleware {
+	return func(next Handler) Handler {
+		type key struct {
+			reg   uint8
+			count int
+		}
+		type entry struct {
+			data    []byte
+			expires time.Time
+		}
+		var (
+			mu      sync.Mutex
+			entries = make(map[key]entry)
+		)
+
+		return func(ctx context.Context, op *Op) ([]byte, error) {
+			if op.Kind != OpRead {
+				mu.Lock()
+				clear(entries)
+				mu.Unlock()
+				return next(ctx, op)
+			}
+
+			k := key{op.Reg, op.Count}
+			mu.Lock()
+			e, ok := entries[k]
+			mu.Unlock()
+			if ok && time.Now().Before(e.expires) {
+				return append([]byte(nil), e.data...), nil
+			}
+
+			data, err := next(ctx, op)
+			if err == nil {
+				mu.Lock()
+				entries[k] = entry{data: append([]byte(nil), data...), expires: time.Now().Add(ttl)}
+				mu.Unlock()
+			}
+			return data, err
+		}
+	}
+}
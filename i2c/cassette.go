@@ -0,0 +1,180 @@
+package i2c
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CassetteVersion 当前录制文件格式版本
+const CassetteVersion = 1
+
+// 录制的操作类型
+const (
+	CassetteOpen     = "open"
+	CassetteRead     = "read"
+	CassetteWrite    = "write"
+	CassetteTransfer = "transfer"
+)
+
+// HexBytes 在录制文件中以 "60 A0" 形式保存的字节序列
+type HexBytes []byte
+
+// MarshalJSON 输出为空格分隔的十六进制字符串
+func (b HexBytes) MarshalJSON() ([]byte, error) {
+	return json.Marshal(fmt.Sprintf("% X", []byte(b)))
+}
+
+// UnmarshalJSON 解析空格分隔的十六进制字节，每个字节可带 0x 前缀
+func (b *HexBytes) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	fields := strings.Fields(s)
+	out := make([]byte, len(fields))
+	for i, f := range fields {
+		v, err := strconv.ParseUint(strings.TrimPrefix(strings.ToLower(f), "0x"), 16, 8)
+		if err != nil {
+			return fmt.Errorf("无效的字节值 %q", f)
+		}
+		out[i] = byte(v)
+	}
+	*b = out
+	return nil
+}
+
+// CassetteMsg 组合传输中的一条消息
+type CassetteMsg struct {
+	Addr  HexByte `json:"addr"`
+	Flags uint16  `json:"flags,omitempty"`
+
+	// Len 读消息请求的缓冲区长度
+	Len int `json:"len,omitempty"`
+
+	// Data 写消息发送的数据，或读消息读回的数据
+	Data HexBytes `json:"data,omitempty"`
+}
+
+// Interaction 一次录制的请求及其响应
+type Interaction struct {
+	Op   string  `json:"op"`
+	Bus  int     `json:"bus"`
+	Addr HexByte `json:"addr"`
+
+	// Reg、Count 和 Write 描述 read/write 请求，ReadRegister 和 WriteRegister
+	// 分别记录为 count 为 1 的 read 和只有一个字节的 write
+	Reg   *HexByte `json:"reg,omitempty"`
+	Count int      `json:"count,omitempty"`
+	Write HexBytes `json:"write,omitempty"`
+
+	// Msgs 组合传输的消息，读消息的 Data 为响应
+	Msgs []CassetteMsg `json:"msgs,omitempty"`
+
+	// Read read 请求的响应
+	Read HexBytes `json:"read,omitempty"`
+
+	// Error 请求失败时的错误信息，回放时原样返回
+	Error string `json:"error,omitempty"`
+
+	// ErrorKind 错误的分类 (如 nack、timeout)，回放的错误同样可以用 errors.Is 判断
+	ErrorKind string `json:"error_kind,omitempty"`
+}
+
+// Request 返回请求部分的文本描述，回放时据此匹配，不包含响应
+func (in *Interaction) Request() string {
+	s := fmt.Sprintf("%s 总线 %d 地址 0x%02X", in.Op, in.Bus, uint8(in.Addr))
+	switch in.Op {
+	case CassetteRead:
+		s += fmt.Sprintf(" 寄存器 0x%02X 长度 %d", uint8(*in.Reg), in.Count)
+	case CassetteWrite:
+		s += fmt.Sprintf(" 寄存器 0x%02X 数据 [% X]", uint8(*in.Reg), []byte(in.Write))
+	case CassetteTransfer:
+		for _, msg := range in.Msgs {
+			if msg.Flags&MsgRead != 0 {
+				s += fmt.Sprintf(" R(0x%02X 0x%X %d)", uint8(msg.Addr), msg.Flags, msg.Len)
+			} else {
+				s += fmt.Sprintf(" W(0x%02X 0x%X [% X])", uint8(msg.Addr), msg.Flags, []byte(msg.Data))
+			}
+		}
+	}
+	return s
+}
+
+// err 返回录制的错误
+func (in *Interaction) err() error {
+	if in.Error == "" {
+		return nil
+	}
+	return &replayedError{msg: in.Error, kind: kindError(in.ErrorKind)}
+}
+
+// replayedError 回放的错误，信息与录制时相同，并保留错误分类
+type replayedError struct {
+	msg  string
+	kind error
+}
+
+func (e *replayedError) Error() string {
+	return e.msg
+}
+
+func (e *replayedError) Unwrap() error {
+	return e.kind
+}
+
+// Cassette 录制文件: 按发生顺序排列的请求和响应
+type Cassette struct {
+	Version      int           `json:"version"`
+	Recorded     time.Time     `json:"recorded"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// LoadCassette 读取录制文件
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取录制文件失败: %v", err)
+	}
+
+	var c Cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("解析录制文件 %s 失败: %v", path, err)
+	}
+	if c.Version > CassetteVersion {
+		return nil, fmt.Errorf("录制文件 %s 的版本 %d 高于支持的版本 %d", path, c.Version, CassetteVersion)
+	}
+	for i, in := range c.Interactions {
+		switch in.Op {
+		case CassetteOpen, CassetteTransfer:
+		case CassetteRead, CassetteWrite:
+			if in.Reg == nil {
+				return nil, fmt.Errorf("录制文件 %s 第 %d 条记录缺少 reg", path, i+1)
+			}
+		default:
+			return nil, fmt.Errorf("录制文件 %s 第 %d 条记录的操作无效: %q", path, i+1, in.Op)
+		}
+	}
+	return &c, nil
+}
+
+// Save 以 JSON 格式保存录制文件
+func (c *Cassette) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化录制文件失败: %v", err)
+	}
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("创建目录失败: %v", err)
+		}
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("保存录制文件失败: %v", err)
+	}
+	return nil
+}
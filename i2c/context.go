@@ -0,0 +1,135 @@
+package i2c
+
+import (
+	"context"
+	"time"
+)
+
+// ContextDevice 接受 context 的设备操作
+//
+// 后端原生实现时，ctx 取消或超时会中止尚未完成的传输 (如模拟设备注入的延迟、
+// Linux 下按截止时间设置的适配器超时)，并返回 ctx.Err()。
+type ContextDevice interface {
+	Device
+
+	// ReadRegisterCtx 读取寄存器值
+	ReadRegisterCtx(ctx context.Context, reg uint8) (uint8, error)
+
+	// WriteRegisterCtx 写入寄存器值
+	WriteRegisterCtx(ctx context.Context, reg, value uint8) error
+
+	// ReadBytesCtx 读取多个字节
+	ReadBytesCtx(ctx context.Context, reg uint8, count int) ([]byte, error)
+
+	// WriteBytesCtx 写入多个字节
+	WriteBytesCtx(ctx context.Context, reg uint8, data []byte) error
+}
+
+// ContextTransferer 接受 context 的原始组合传输
+type ContextTransferer interface {
+	Transferer
+
+	// TransferCtx 执行一次组合传输，读消息的数据写回对应的 Buf
+	TransferCtx(ctx context.Context, msgs []Msg) error
+}
+
+// WithContext 返回设备的 ContextDevice 形式
+//
+// 设备原生支持 context 时原样返回；否则返回适配器，每次操作前检查 ctx，
+// 但无法中止已经开始的操作。底层设备支持原始传输时返回的设备也支持。
+func WithContext(dev Device) ContextDevice {
+	if cd, ok := dev.(ContextDevice); ok {
+		return cd
+	}
+	ad := &contextAdapter{Device: dev}
+	if tr, ok := dev.(Transferer); ok {
+		return &contextAdapterTransferer{contextAdapter: ad, tr: tr}
+	}
+	return ad
+}
+
+// contextAdapter 为不支持 context 的设备提供 Ctx 方法
+type contextAdapter struct {
+	Device
+}
+
+// contextAdapterTransferer 同时适配原始组合传输
+type contextAdapterTransferer struct {
+	*contextAdapter
+	tr Transferer
+}
+
+func (d *contextAdapter) ReadRegisterCtx(ctx context.Context, reg uint8) (uint8, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return d.Device.ReadRegister(reg)
+}
+
+func (d *contextAdapter) WriteRegisterCtx(ctx context.Context, reg, value uint8) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return d.Device.WriteRegister(reg, value)
+}
+
+func (d *contextAdapter) ReadBytesCtx(ctx context.Context, reg uint8, count int) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return d.Device.ReadBytes(reg, count)
+}
+
+func (d *contextAdapter) WriteBytesCtx(ctx context.Context, reg uint8, data []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return d.Device.WriteBytes(reg, data)
+}
+
+func (d *contextAdapterTransferer) Transfer(msgs []Msg) error {
+	return d.tr.Transfer(msgs)
+}
+
+func (d *contextAdapterTransferer) TransferCtx(ctx context.Context, msgs []Msg) error {
+	return transferCtx(ctx, d.tr, msgs)
+}
+
+// transferCtx 用 ctx 执行原始传输，tr 不支持 context 时只在传输前检查 ctx
+func transferCtx(ctx context.Context, tr Transferer, msgs []Msg) error {
+	if ct, ok := tr.(ContextTransferer); ok {
+		return ct.TransferCtx(ctx, msgs)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return tr.Transfer(msgs)
+}
+
+// sleepCtx 等待 d 或直到 ctx 结束
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Timeout 为每次操作设置超时的中间件，超时的错误包装 ErrTimeout
+func Timeout(d time.Duration) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, op *Op) (data []byte, err error) {
+			err = WithTimeoutCtx(ctx, d, func(ctx context.Context) error {
+				data, err = next(ctx, op)
+				return err
+			})
+			return data, err
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package i2c
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// plainDevice 只实现 Device 接口，用于测试适配器
+type plainDevice struct {
+	Device
+	calls int
+}
+
+func (d *plainDevice) ReadRegister(reg uint8) (uint8, error) {
+	d.calls++
+	return d.Device.ReadRegister(reg)
+}
+
+func TestWithContextAdapter(t *testing.T) {
+	mock := NewMockDevice(&DeviceConfig{Bus: 1, Address: 0x48, MockMode: true})
+	mock.WriteRegister(0x10, 0x5A)
+
+	// 原生支持 context 的设备原样返回
+	if WithContext(mock) != ContextDevice(mock) {
+		t.Error("MockDevice 应原样返回")
+	}
+
+	plain := &plainDevice{Device: mock}
+	dev := WithContext(plain)
+	if _, ok := dev.(ContextTransferer); ok {
+		t.Error("底层设备不支持原始传输时适配器也不应支持")
+	}
+
+	value, err := dev.ReadRegisterCtx(context.Background(), 0x10)
+	if err != nil || value != 0x5A {
+		t.Errorf("期望读取 0x5A，实际 0x%02X (%v)", value, err)
+	}
+
+	// 已取消的 ctx 不访问设备
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := dev.ReadRegisterCtx(ctx, 0x10); !errors.Is(err, context.Canceled) {
+		t.Errorf("期望 context.Canceled，得到 %v", err)
+	}
+	if plain.calls != 1 {
+		t.Errorf("取消后不应访问设备，实际调用 %d 次", plain.calls)
+	}
+}
+
+func TestMockDeviceCancel(t *testing.T) {
+	device := newFaultyDevice(t, &FaultConfig{
+		Rules: []FaultRule{{Reg: hexByte(0x30), Latency: "500ms"}},
+	})
+
+	// 取消中止注入的延迟
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	start := time.Now()
+	if _, err := device.ReadBytesCtx(ctx, 0x30, 2); !errors.Is(err, context.Canceled) {
+		t.Errorf("期望 context.Canceled，得到 %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 300*time.Millisecond {
+		t.Errorf("取消后应立即返回，实际耗时 %v", elapsed)
+	}
+
+	// 装饰后的设备把 ctx 传给底层设备
+	traced := WithContext(NewTracingDevice(device, tracerFunc(func(Transaction) {})))
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := traced.WriteRegisterCtx(ctx, 0x30, 0x01); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("期望 context.DeadlineExceeded，得到 %v", err)
+	}
+}
+
+type tracerFunc func(Transaction)
+
+func (f tracerFunc) Trace(tx Transaction) { f(tx) }
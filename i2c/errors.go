@@ -0,0 +1,120 @@
+package i2c
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// 错误分类，所有后端返回的错误都可以用 errors.Is 判断属于哪一类
+var (
+	// ErrNoDevice 地址阶段无应答或总线不存在，通常表示地址上没有设备
+	ErrNoDevice = errors.New("设备无应答")
+
+	// ErrNACK 数据阶段无应答，设备忙 (如 EEPROM 写周期) 时可能出现
+	ErrNACK = errors.New("无应答 (NACK)")
+
+	// ErrTimeout 传输超时
+	ErrTimeout = errors.New("操作超时")
+
+	// ErrClosed 设备已关闭
+	ErrClosed = errors.New("设备已关闭")
+
+	// ErrInvalidAddress 地址超出 7 位地址的有效范围 0x03-0x77
+	ErrInvalidAddress = errors.New("无效的I2C地址")
+
+	// ErrBusBusy 总线被占用或仲裁失败
+	ErrBusBusy = errors.New("总线忙")
+)
+
+// OpOpen 和 OpTransfer 与故障规则中的 OpRead、OpWrite 一起用于 Error.Op
+const (
+	OpOpen     = "open"
+	OpTransfer = "transfer"
+)
+
+// Error 设备操作失败，附带总线、地址和寄存器
+type Error struct {
+	// Op 失败的操作: OpOpen, OpRead, OpWrite, OpTransfer
+	Op   string
+	Bus  int
+	Addr uint8
+
+	// Reg 操作的起始寄存器，不涉及寄存器 (打开、组合传输) 时为 -1
+	Reg int
+
+	// Err 底层错误，通常包装上面的某个分类错误
+	Err error
+}
+
+func (e *Error) Error() string {
+	action := map[string]string{
+		OpOpen:     "打开",
+		OpRead:     "读取",
+		OpWrite:    "写入",
+		OpTransfer: "传输",
+	}[e.Op]
+	if e.Reg < 0 {
+		return fmt.Sprintf("总线 %d 设备 0x%02X %s失败: %v", e.Bus, e.Addr, action, e.Err)
+	}
+	return fmt.Sprintf("总线 %d 设备 0x%02X %s寄存器 0x%02X 失败: %v", e.Bus, e.Addr, action, e.Reg, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// opError 为 err 附加操作上下文，err 已经带有上下文时原样返回
+func opError(op string, bus int, addr uint8, reg int, err error) error {
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return err
+	}
+	return &Error{Op: op, Bus: bus, Addr: addr, Reg: reg, Err: err}
+}
+
+// IsTransient 判断错误是否可能在重试后消失: 数据阶段无应答、超时和总线忙
+//
+// 取消以及调用方 ctx 的截止时间不算，重试没有意义。
+func IsTransient(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	return errors.Is(err, ErrNACK) || errors.Is(err, ErrTimeout) || errors.Is(err, ErrBusBusy)
+}
+
+// 录制文件中保存的错误分类名
+var errorKinds = []struct {
+	name string
+	err  error
+}{
+	{"no_device", ErrNoDevice},
+	{"nack", ErrNACK},
+	{"timeout", ErrTimeout},
+	{"closed", ErrClosed},
+	{"invalid_address", ErrInvalidAddress},
+	{"bus_busy", ErrBusBusy},
+}
+
+// errorKind 返回错误的分类名，未分类时为空
+func errorKind(err error) string {
+	for _, k := range errorKinds {
+		if errors.Is(err, k.err) {
+			return k.name
+		}
+	}
+	return ""
+}
+
+// kindError 返回分类名对应的分类错误
+func kindError(name string) error {
+	for _, k := range errorKinds {
+		if k.name == name {
+			return k.err
+		}
+	}
+	return nil
+}
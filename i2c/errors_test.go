@@ -0,0 +1,81 @@
+package i2c
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestErrorClassification(t *testing.T) {
+	mb := newTestMockBus(t)
+
+	_, err := mb.Open(&DeviceConfig{Bus: 1, Address: 0x50})
+	var opErr *Error
+	if !errors.Is(err, ErrNoDevice) || !errors.As(err, &opErr) || opErr.Op != OpOpen || opErr.Addr != 0x50 {
+		t.Errorf("未定义的地址应返回 ErrNoDevice，得到 %v", err)
+	}
+
+	if _, err := OpenWithConfig(&DeviceConfig{Bus: 1, Address: 0x78, MockMode: true}); !errors.Is(err, ErrInvalidAddress) {
+		t.Errorf("期望 ErrInvalidAddress，得到 %v", err)
+	}
+
+	device := openTestMockDevice(t, mb, 0x48)
+	device.SetFaults(&FaultConfig{Rules: []FaultRule{
+		{Reg: hexByte(0x01), Kind: FaultNACK},
+		{Reg: hexByte(0x02), Kind: FaultArbitration},
+	}})
+	_, err = device.ReadRegister(0x01)
+	if !errors.Is(err, ErrNACK) || !errors.As(err, &opErr) || opErr.Bus != 1 || opErr.Reg != 0x01 {
+		t.Errorf("期望寄存器 0x01 的 ErrNACK，得到 %v", err)
+	}
+	if err := device.WriteRegister(0x02, 0); !errors.Is(err, ErrBusBusy) {
+		t.Errorf("仲裁失败应返回 ErrBusBusy，得到 %v", err)
+	}
+
+	device.Close()
+	if _, err := device.ReadBytes(0x00, 1); !errors.Is(err, ErrClosed) {
+		t.Errorf("期望 ErrClosed，得到 %v", err)
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{opError(OpRead, 1, 0x48, 0, ErrNACK), true},
+		{ErrTimeout, true},
+		{ErrBusBusy, true},
+		{ErrNoDevice, false},
+		{ErrClosed, false},
+		{ErrInvalidAddress, false},
+		{context.DeadlineExceeded, false},
+		{opError(OpRead, 1, 0x48, 0, context.Canceled), false},
+		{errors.New("其他错误"), false},
+	}
+	for _, tt := range tests {
+		if got := IsTransient(tt.err); got != tt.want {
+			t.Errorf("IsTransient(%v) = %v，期望 %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestWithRetryStopsOnPermanentError(t *testing.T) {
+	calls := 0
+	err := WithRetry(3, func() error {
+		calls++
+		return opError(OpRead, 1, 0x48, 0, ErrClosed)
+	})
+	if calls != 1 || !errors.Is(err, ErrClosed) {
+		t.Errorf("非暂时性错误不应重试: 调用 %d 次，%v", calls, err)
+	}
+
+	calls = 0
+	err = WithRetry(2, func() error {
+		calls++
+		return ErrNACK
+	})
+	if calls != 3 || !errors.Is(err, ErrNACK) {
+		t.Errorf("暂时性错误应重试: 调用 %d 次，%v", calls, err)
+	}
+}
@@ -0,0 +1,215 @@
+package i2c
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// 故障类型
+const (
+	FaultNACK        = "nack"
+	FaultTimeout     = "timeout"
+	FaultArbitration = "arbitration"
+	FaultCorrupt     = "corrupt"
+)
+
+// 操作类型
+const (
+	OpRead  = "read"
+	OpWrite = "write"
+)
+
+// FaultRule 一条故障注入规则
+//
+// Address 为 0 时匹配所有地址，Reg 为空时匹配所有寄存器，Op 为空时匹配读写两种操作。
+// 规则在第 Nth 次匹配时确定触发，或按 Probability 的概率随机触发；两者都未设置时每次匹配都触发。
+type FaultRule struct {
+	Address     HexByte  `json:"address,omitempty" yaml:"address,omitempty"`
+	Reg         *HexByte `json:"reg,omitempty" yaml:"reg,omitempty"`
+	Op          string   `json:"op,omitempty" yaml:"op,omitempty"`
+	Kind        string   `json:"kind,omitempty" yaml:"kind,omitempty"`
+	Probability float64  `json:"probability,omitempty" yaml:"probability,omitempty"`
+	Nth         []int    `json:"nth,omitempty" yaml:"nth,omitempty"`
+	Latency     string   `json:"latency,omitempty" yaml:"latency,omitempty"`     // 注入的延迟，如 "50ms"
+	BitFlips    int      `json:"bit_flips,omitempty" yaml:"bit_flips,omitempty"` // corrupt 时翻转的位数，默认 1
+}
+
+// FaultConfig 模拟设备的故障模型
+type FaultConfig struct {
+	Seed  int64       `json:"seed,omitempty" yaml:"seed,omitempty"`
+	Rules []FaultRule `json:"rules" yaml:"rules"`
+}
+
+// Validate 校验故障模型
+func (fc *FaultConfig) Validate() error {
+	for i, rule := range fc.Rules {
+		switch rule.Kind {
+		case "", FaultNACK, FaultTimeout, FaultArbitration, FaultCorrupt:
+		default:
+			return fmt.Errorf("故障规则 %d: 无效的故障类型 %q", i+1, rule.Kind)
+		}
+
+		switch rule.Op {
+		case "", OpRead, OpWrite:
+		default:
+			return fmt.Errorf("故障规则 %d: 无效的操作类型 %q", i+1, rule.Op)
+		}
+
+		if rule.Probability < 0 || rule.Probability > 1 {
+			return fmt.Errorf("故障规则 %d: 概率必须在 0-1 之间", i+1)
+		}
+
+		for _, n := range rule.Nth {
+			if n <= 0 {
+				return fmt.Errorf("故障规则 %d: nth 必须为正整数", i+1)
+			}
+		}
+
+		if rule.Latency != "" {
+			if _, err := time.ParseDuration(rule.Latency); err != nil {
+				return fmt.Errorf("故障规则 %d: 无效的延迟 %q", i+1, rule.Latency)
+			}
+		}
+
+		if rule.Kind == "" && rule.Latency == "" {
+			return fmt.Errorf("故障规则 %d: 必须指定故障类型或延迟", i+1)
+		}
+	}
+	return nil
+}
+
+// faultState 规则的运行时状态
+type faultState struct {
+	rule    FaultRule
+	latency time.Duration
+	matches int
+}
+
+// faultInjector 按故障模型决定每次操作是否出错
+type faultInjector struct {
+	mu    sync.Mutex
+	rng   *rand.Rand
+	rules []*faultState
+}
+
+// faultOutcome 一次操作的故障注入结果
+type faultOutcome struct {
+	err      error
+	latency  time.Duration
+	bitFlips int
+	fi       *faultInjector
+}
+
+// newFaultInjector 根据故障模型创建注入器
+func newFaultInjector(fc *FaultConfig) (*faultInjector, error) {
+	if err := fc.Validate(); err != nil {
+		return nil, err
+	}
+
+	fi := &faultInjector{
+		rng: rand.New(rand.NewSource(fc.Seed)),
+	}
+	for _, rule := range fc.Rules {
+		state := &faultState{rule: rule}
+		if rule.Latency != "" {
+			state.latency, _ = time.ParseDuration(rule.Latency)
+		}
+		fi.rules = append(fi.rules, state)
+	}
+	return fi, nil
+}
+
+// check 判断一次操作要注入的故障
+func (fi *faultInjector) check(addr, reg uint8, op string) faultOutcome {
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+
+	out := faultOutcome{fi: fi}
+	for _, state := range fi.rules {
+		rule := state.rule
+		if rule.Address != 0 && uint8(rule.Address) != addr {
+			continue
+		}
+		if rule.Reg != nil && uint8(*rule.Reg) != reg {
+			continue
+		}
+		if rule.Op != "" && rule.Op != op {
+			continue
+		}
+
+		state.matches++
+		if !fi.fires(state) {
+			continue
+		}
+
+		out.latency += state.latency
+
+		switch rule.Kind {
+		case FaultCorrupt:
+			flips := rule.BitFlips
+			if flips <= 0 {
+				flips = 1
+			}
+			out.bitFlips += flips
+		case FaultNACK, FaultTimeout, FaultArbitration:
+			if out.err == nil {
+				out.err = faultError(rule.Kind)
+			}
+		}
+	}
+	return out
+}
+
+// fires 判断规则本次匹配是否触发，调用方需持有锁
+func (fi *faultInjector) fires(state *faultState) bool {
+	rule := state.rule
+	if len(rule.Nth) > 0 {
+		for _, n := range rule.Nth {
+			if n == state.matches {
+				return true
+			}
+		}
+		return false
+	}
+
+	if rule.Probability > 0 {
+		return fi.rng.Float64() < rule.Probability
+	}
+	return true
+}
+
+// corrupt 随机翻转数据中的若干位
+func (fi *faultInjector) corrupt(data []byte, flips int) {
+	if len(data) == 0 {
+		return
+	}
+
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+
+	for i := 0; i < flips; i++ {
+		bit := fi.rng.Intn(len(data) * 8)
+		data[bit/8] ^= 1 << (bit % 8)
+	}
+}
+
+// corrupt 按注入结果翻转数据中的位
+func (out faultOutcome) corrupt(data []byte) {
+	if out.bitFlips > 0 {
+		out.fi.corrupt(data, out.bitFlips)
+	}
+}
+
+// faultError 构造注入故障对应的错误，由设备附加地址和寄存器
+func faultError(kind string) error {
+	switch kind {
+	case FaultNACK:
+		return ErrNACK
+	case FaultTimeout:
+		return ErrTimeout
+	default:
+		return fmt.Errorf("%w: 仲裁失败", ErrBusBusy)
+	}
+}
@@ -0,0 +1,181 @@
+package i2c
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newFaultyDevice(t *testing.T, fc *FaultConfig) *MockDevice {
+	t.Helper()
+
+	device := NewMockDevice(&DeviceConfig{Bus: 1, Address: 0x48, MockMode: true})
+	if err := device.SetFaults(fc); err != nil {
+		t.Fatalf("设置故障模型失败: %v", err)
+	}
+	return device
+}
+
+func hexByte(v uint8) *HexByte {
+	h := HexByte(v)
+	return &h
+}
+
+func TestFaultSchedule(t *testing.T) {
+	device := newFaultyDevice(t, &FaultConfig{
+		Rules: []FaultRule{
+			{Reg: hexByte(0x10), Op: OpRead, Kind: FaultNACK, Nth: []int{3}},
+		},
+	})
+
+	for i := 1; i <= 5; i++ {
+		_, err := device.ReadRegister(0x10)
+		if i == 3 && err == nil {
+			t.Error("第 3 次读取 0x10 应该失败")
+		}
+		if i != 3 && err != nil {
+			t.Errorf("第 %d 次读取不应失败: %v", i, err)
+		}
+	}
+
+	// 其他寄存器和写操作不受影响
+	if _, err := device.ReadRegister(0x11); err != nil {
+		t.Errorf("读取 0x11 不应失败: %v", err)
+	}
+	if err := device.WriteRegister(0x10, 0x01); err != nil {
+		t.Errorf("写入 0x10 不应失败: %v", err)
+	}
+}
+
+func TestFaultProbabilityIsSeeded(t *testing.T) {
+	fc := &FaultConfig{
+		Seed:  42,
+		Rules: []FaultRule{{Kind: FaultArbitration, Probability: 0.5}},
+	}
+
+	run := func() []bool {
+		device := newFaultyDevice(t, fc)
+		results := make([]bool, 50)
+		for i := range results {
+			_, err := device.ReadRegister(0x00)
+			results[i] = err != nil
+		}
+		return results
+	}
+
+	first, second := run(), run()
+	failures := 0
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("相同种子的故障序列应该一致 (位置 %d)", i)
+		}
+		if first[i] {
+			failures++
+		}
+	}
+	if failures == 0 || failures == len(first) {
+		t.Errorf("概率 0.5 的故障次数异常: %d/%d", failures, len(first))
+	}
+}
+
+func TestFaultCorruption(t *testing.T) {
+	device := newFaultyDevice(t, &FaultConfig{
+		Seed:  1,
+		Rules: []FaultRule{{Op: OpRead, Kind: FaultCorrupt, BitFlips: 1, Nth: []int{1}}},
+	})
+	device.WriteBytes(0x00, []byte{0x00, 0x00, 0x00, 0x00})
+
+	data, err := device.ReadBytes(0x00, 4)
+	if err != nil {
+		t.Fatalf("读取失败: %v", err)
+	}
+
+	bits := 0
+	for _, b := range data {
+		for ; b != 0; b &= b - 1 {
+			bits++
+		}
+	}
+	if bits != 1 {
+		t.Errorf("期望翻转 1 位，实际 %d 位 (%X)", bits, data)
+	}
+
+	// 寄存器内容本身未被修改
+	if regs := device.GetRegisters(); regs[0x00]|regs[0x01]|regs[0x02]|regs[0x03] != 0 {
+		t.Error("读取损坏不应修改寄存器内容")
+	}
+}
+
+func TestFaultsWithRetryAndTimeout(t *testing.T) {
+	device := newFaultyDevice(t, &FaultConfig{
+		Rules: []FaultRule{
+			{Reg: hexByte(0x20), Kind: FaultTimeout, Nth: []int{1, 2}},
+			{Reg: hexByte(0x30), Latency: "200ms"},
+		},
+	})
+
+	// 前两次超时，重试后成功
+	err := WithRetry(3, func() error {
+		_, err := device.ReadRegister(0x20)
+		return err
+	})
+	if err != nil {
+		t.Errorf("重试后应该成功: %v", err)
+	}
+
+	// 注入的延迟触发超时包装器
+	err = WithTimeout(context.Background(), 20*time.Millisecond, func() error {
+		_, err := device.ReadRegister(0x30)
+		return err
+	})
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("注入延迟后应该超时，得到 %v", err)
+	}
+
+	// 支持 ctx 的包装器中止注入的延迟
+	start := time.Now()
+	err = WithTimeoutCtx(context.Background(), 20*time.Millisecond, func(ctx context.Context) error {
+		_, err := device.ReadRegisterCtx(ctx, 0x30)
+		return err
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("注入延迟后应该超时，得到 %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Errorf("超时后应中止注入的延迟，实际耗时 %v", elapsed)
+	}
+}
+
+func TestFaultConfigValidation(t *testing.T) {
+	invalid := []*FaultConfig{
+		{Rules: []FaultRule{{Kind: "explode"}}},
+		{Rules: []FaultRule{{Kind: FaultNACK, Op: "erase"}}},
+		{Rules: []FaultRule{{Kind: FaultNACK, Probability: 1.5}}},
+		{Rules: []FaultRule{{Kind: FaultNACK, Nth: []int{0}}}},
+		{Rules: []FaultRule{{Latency: "soon"}}},
+		{Rules: []FaultRule{{}}},
+	}
+	for i, fc := range invalid {
+		if err := fc.Validate(); err == nil {
+			t.Errorf("无效故障模型 %d 应该校验失败", i)
+		}
+	}
+
+	profile, err := ParseMockProfile([]byte(`
+devices:
+  - address: 0x48
+faults:
+  seed: 7
+  rules:
+    - {address: 0x48, op: write, kind: nack}
+`), "yaml")
+	if err != nil {
+		t.Fatalf("解析带故障模型的描述文件失败: %v", err)
+	}
+
+	dev := openTestMockDevice(t, NewMockBus(1, profile), 0x48)
+	if err := dev.WriteRegister(0x00, 0x01); err == nil {
+		t.Error("描述文件中的故障规则应该生效")
+	}
+}
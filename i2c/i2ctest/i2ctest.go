@@ -0,0 +1,67 @@
+// Package i2ctest 提供测试设备中间件和驱动代码的辅助工具
+package i2ctest
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"sensorcli/i2c"
+)
+
+// OpLog 记录经过中间件的操作，用于断言操作序列，并发安全
+type OpLog struct {
+	mu  sync.Mutex
+	ops []string
+}
+
+// Middleware 返回记录操作的中间件，每个操作在执行完成后以 i2c.Op.String() 的形式记录，
+// 失败的操作追加 " !"
+func (l *OpLog) Middleware() i2c.Middleware {
+	return func(next i2c.Handler) i2c.Handler {
+		return func(ctx context.Context, op *i2c.Op) ([]byte, error) {
+			data, err := next(ctx, op)
+			s := op.String()
+			if err != nil {
+				s += " !"
+			}
+			l.mu.Lock()
+			l.ops = append(l.ops, s)
+			l.mu.Unlock()
+			return data, err
+		}
+	}
+}
+
+// Ops 返回已记录的操作
+func (l *OpLog) Ops() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.ops...)
+}
+
+// Reset 清空记录
+func (l *OpLog) Reset() {
+	l.mu.Lock()
+	l.ops = nil
+	l.mu.Unlock()
+}
+
+// Expect 检查已记录的操作序列与 want 完全一致，然后清空记录
+func (l *OpLog) Expect(t testing.TB, want ...string) {
+	t.Helper()
+	got := l.Ops()
+	l.Reset()
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("操作序列不符\n实际:\n  %s\n期望:\n  %s", strings.Join(got, "\n  "), strings.Join(want, "\n  "))
+	}
+}
+
+// NewMockDevice 创建 1 号总线上指定地址的模拟设备，测试结束时关闭
+func NewMockDevice(t testing.TB, addr uint8) *i2c.MockDevice {
+	t.Helper()
+	dev := i2c.NewMockDevice(&i2c.DeviceConfig{Bus: 1, Address: addr, MockMode: true})
+	t.Cleanup(func() { dev.Close() })
+	return dev
+}
@@ -0,0 +1,28 @@
+package i2ctest
+
+import (
+	"testing"
+	"time"
+
+	"sensorcli/i2c"
+)
+
+func TestOpLog(t *testing.T) {
+	dev := NewMockDevice(t, 0x48)
+	var log OpLog
+	chained := i2c.Chain(dev, i2c.Cache(time.Minute), log.Middleware())
+
+	chained.WriteBytes(0x01, []byte{0x60, 0xA0})
+	chained.ReadBytes(0x01, 2)
+	chained.ReadBytes(0x01, 2)
+	chained.ReadRegister(0x02)
+	log.Expect(t, "write 0x01 [60 A0]", "read 0x01 x2", "read 0x02 x1")
+
+	if ops := log.Ops(); len(ops) != 0 {
+		t.Errorf("Expect 后应清空记录: %v", ops)
+	}
+
+	dev.Close()
+	chained.ReadRegister(0x03)
+	log.Expect(t, "read 0x03 x1 !")
+}
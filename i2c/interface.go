@@ -2,6 +2,7 @@ package i2c
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 )
@@ -30,13 +31,57 @@ type Device interface {
 	GetBus() int
 }
 
+// 消息标志位，与 Linux <linux/i2c.h> 中的 I2C_M_* 保持一致
+const (
+	// MsgRead 读消息
+	MsgRead uint16 = 0x0001
+
+	// MsgRecvLen 首字节为后续数据长度（SMBus 块读取）
+	MsgRecvLen uint16 = 0x0400
+)
+
+// Msg 单条I2C消息，多条消息组成一次带重复起始条件的组合传输
+type Msg struct {
+	Addr  uint16
+	Flags uint16
+	Buf   []byte
+}
+
+// Transferer 支持原始I2C组合传输的设备
+type Transferer interface {
+	// Transfer 执行一次组合传输，读消息的数据写回对应的 Buf
+	Transfer(msgs []Msg) error
+}
+
 // DeviceConfig 设备配置
 type DeviceConfig struct {
 	Bus      int
 	Address  uint8
 	Timeout  time.Duration
-	Retries  int
 	MockMode bool
+
+	// Retries 操作失败后的重试次数，未指定 Retry 时按默认重试策略重试；
+	// Linux 下同时设置适配器在仲裁失败时的重试次数
+	Retries int
+
+	// Retry 重试策略，为空时由 Retries 决定
+	Retry *RetryPolicy
+
+	// Middleware 打开的设备上依次经过的中间件，第一个在最外层，重试在所有中间件之内
+	Middleware []Middleware
+
+	// BusMiddleware 重试之内的中间件，第一个在最外层。每次尝试都经过这些中间件，
+	// 用于观察实际发生在总线上的事务 (跟踪、统计)
+	BusMiddleware []Middleware
+
+	// MockProfile 模拟总线描述文件路径，为空时使用内置描述
+	MockProfile string
+
+	// MockStateDir 模拟寄存器状态的持久化目录，为空时不持久化 (命令行工具使用 DefaultMockStateDir)
+	MockStateDir string
+
+	// Faults 模拟设备的故障模型，为空时使用描述文件中的定义
+	Faults *FaultConfig
 }
 
 // DefaultConfig 默认设备配置
@@ -65,19 +110,71 @@ func OpenWithConfig(config *DeviceConfig) (Device, error) {
 	}
 
 	// 验证参数
-	if config.Address < 0x03 || config.Address > 0x77 {
-		return nil, fmt.Errorf("无效的I2C地址: 0x%02X (有效范围: 0x03-0x77)", config.Address)
+	if err := checkAddress(config.Address); err != nil {
+		return nil, err
+	}
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
+	// 根据平台选择实现
+	dev, err := openPlatform(config)
+	if err != nil {
+		return nil, err
 	}
+	return config.chain(dev), nil
+}
 
+// checkAddress 检查是否为有效的 7 位设备地址 (不含保留地址)
+func checkAddress(addr uint8) error {
+	if addr < 0x03 || addr > 0x77 {
+		return fmt.Errorf("%w: 0x%02X (有效范围: 0x03-0x77)", ErrInvalidAddress, addr)
+	}
+	return nil
+}
+
+// validate 检查地址以外的配置
+func (config *DeviceConfig) validate() error {
 	if config.Bus < 0 {
-		return nil, fmt.Errorf("无效的总线号: %d", config.Bus)
+		return fmt.Errorf("无效的总线号: %d", config.Bus)
+	}
+	if policy := config.retryPolicy(); policy != nil {
+		return policy.Validate()
 	}
+	return nil
+}
 
-	// 根据平台选择实现
-	return openPlatform(config)
+// chain 按配置的中间件和重试策略包装设备: Middleware、重试、BusMiddleware 依次由外到内
+func (config *DeviceConfig) chain(dev Device) Device {
+	mws := config.Middleware
+	if policy := config.retryPolicy(); policy != nil && policy.MaxAttempts > 1 {
+		mws = append(mws[:len(mws):len(mws)], Retry(policy))
+	}
+	mws = append(mws[:len(mws):len(mws)], config.BusMiddleware...)
+	if len(mws) == 0 {
+		return dev
+	}
+	return Chain(dev, mws...)
+}
+
+// retryPolicy 返回生效的重试策略，不重试时为空
+func (config *DeviceConfig) retryPolicy() *RetryPolicy {
+	if config.Retry != nil {
+		return config.Retry
+	}
+	if config.Retries <= 0 {
+		return nil
+	}
+	policy := DefaultRetryPolicy()
+	policy.MaxAttempts = config.Retries + 1
+	return policy
 }
 
-// WithTimeout 带超时的操作包装器
+// WithTimeout 带超时的操作包装器，超时的错误包装 ErrTimeout
+//
+// fn 在另一个 goroutine 中执行，超时后不会被中止，可能在返回后继续占用设备。
+//
+// Deprecated: 使用 WithTimeoutCtx，把 ctx 传给 ContextDevice 的 Ctx 方法以便超时时中止传输
 func WithTimeout(ctx context.Context, timeout time.Duration, fn func() error) error {
 	if timeout <= 0 {
 		timeout = 1 * time.Second
@@ -95,26 +192,35 @@ func WithTimeout(ctx context.Context, timeout time.Duration, fn func() error) er
 	case err := <-done:
 		return err
 	case <-ctx.Done():
-		return fmt.Errorf("操作超时: %v", ctx.Err())
+		return fmt.Errorf("%w: %w", ErrTimeout, ctx.Err())
+	}
+}
+
+// WithTimeoutCtx 带超时的操作包装器
+//
+// fn 在当前 goroutine 中执行，应把 ctx 传给 ContextDevice 的 Ctx 方法，
+// 超时后由设备中止传输，fn 返回时设备不再被占用。超时的错误包装 ErrTimeout。
+func WithTimeoutCtx(ctx context.Context, timeout time.Duration, fn func(ctx context.Context) error) error {
+	if timeout <= 0 {
+		timeout = 1 * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err := fn(ctx)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) && !errors.Is(err, ErrTimeout) {
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
 	}
+	return err
 }
 
-// WithRetry 带重试的操作包装器
+// WithRetry 带重试的操作包装器，只重试 IsTransient 的错误，其他错误直接返回
+//
+// Deprecated: 使用 RetryPolicy.Do，或在 DeviceConfig.Retry 中设置重试策略
 func WithRetry(maxRetries int, fn func() error) error {
-	var lastErr error
-
-	for i := 0; i <= maxRetries; i++ {
-		if err := fn(); err == nil {
-			return nil
-		} else {
-			lastErr = err
-			if i < maxRetries {
-				// 指数退避
-				backoff := time.Duration(1<<uint(i)) * 10 * time.Millisecond
-				time.Sleep(backoff)
-			}
-		}
-	}
-
-	return fmt.Errorf("操作失败，已重试 %d 次: %v", maxRetries, lastErr)
+	policy := &RetryPolicy{MaxAttempts: maxRetries + 1, BaseDelay: 10 * time.Millisecond}
+	return policy.Do(context.Background(), func(context.Context) error {
+		return fn()
+	})
 }
@@ -0,0 +1,376 @@
+//go:build linux
+
+package i2c
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// i2c-dev ioctl 请求号，见 <linux/i2c-dev.h>
+const (
+	ioctlI2CRetries = 0x0701
+	ioctlI2CTimeout = 0x0702
+	ioctlI2CSlave   = 0x0703
+	ioctlI2CRdwr    = 0x0707
+)
+
+// i2cMsg 对应内核 struct i2c_msg
+type i2cMsg struct {
+	addr  uint16
+	flags uint16
+	len   uint16
+	buf   *byte
+}
+
+// i2cRdwrData 对应内核 struct i2c_rdwr_ioctl_data
+type i2cRdwrData struct {
+	msgs  *i2cMsg
+	nmsgs uint32
+}
+
+// ioctlFile 对 /dev/i2c-N 文件描述符的最小抽象，便于在没有内核适配器时测试
+type ioctlFile interface {
+	// IoctlInt 执行参数为整数的 ioctl 系统调用
+	IoctlInt(req, arg uintptr) error
+
+	// IoctlPtr 执行参数为指针的 ioctl 系统调用
+	IoctlPtr(req uintptr, arg unsafe.Pointer) error
+
+	// Close 关闭文件描述符
+	Close() error
+}
+
+// devFile 基于 os.File 的 ioctlFile 实现
+type devFile struct {
+	f *os.File
+}
+
+// IoctlInt 执行参数为整数的 ioctl 系统调用
+func (d *devFile) IoctlInt(req, arg uintptr) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, d.f.Fd(), req, arg)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// IoctlPtr 执行参数为指针的 ioctl 系统调用
+func (d *devFile) IoctlPtr(req uintptr, arg unsafe.Pointer) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, d.f.Fd(), req, uintptr(arg))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// Close 关闭文件描述符
+func (d *devFile) Close() error {
+	return d.f.Close()
+}
+
+// LinuxDevice 基于 Linux i2c-dev 的I2C设备实现
+type LinuxDevice struct {
+	config *DeviceConfig
+	file   ioctlFile
+}
+
+// openLinux 打开 /dev/i2c-N 并绑定设备地址
+func openLinux(config *DeviceConfig) (Device, error) {
+	a, err := openLinuxAdapter(config)
+	if err != nil {
+		return nil, opError(OpOpen, config.Bus, config.Address, -1, err)
+	}
+
+	dev, err := newLinuxDevice(a.file, config)
+	if err != nil {
+		a.close()
+		return nil, opError(OpOpen, config.Bus, config.Address, -1, err)
+	}
+	return dev, nil
+}
+
+// linuxAdapter 总线上所有设备共享的 /dev/i2c-N，设备的传输都使用 I2C_RDWR 并在消息中指定地址
+type linuxAdapter struct {
+	file ioctlFile
+}
+
+// openLinuxAdapter 打开 /dev/i2c-N
+func openLinuxAdapter(config *DeviceConfig) (*linuxAdapter, error) {
+	path := fmt.Sprintf("/dev/i2c-%d", config.Bus)
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = fmt.Errorf("%w: %w", ErrNoDevice, err)
+		}
+		return nil, fmt.Errorf("打开 %s 失败: %w", path, err)
+	}
+	return &linuxAdapter{file: &devFile{f: f}}, nil
+}
+
+func (a *linuxAdapter) open(config *DeviceConfig) (Device, error) {
+	dev, err := newLinuxDevice(sharedFile{a.file}, config)
+	if err != nil {
+		return nil, opError(OpOpen, config.Bus, config.Address, -1, err)
+	}
+	return dev, nil
+}
+
+func (a *linuxAdapter) close() error {
+	return a.file.Close()
+}
+
+// sharedFile 适配器中共享的文件，设备关闭时不关闭文件
+type sharedFile struct {
+	ioctlFile
+}
+
+func (sharedFile) Close() error {
+	return nil
+}
+
+// newLinuxDevice 在已打开的文件描述符上初始化设备
+func newLinuxDevice(file ioctlFile, config *DeviceConfig) (*LinuxDevice, error) {
+	if err := file.IoctlInt(ioctlI2CSlave, uintptr(config.Address)); err != nil {
+		return nil, fmt.Errorf("设置从设备地址 0x%02X 失败: %w", config.Address, errnoClass(err))
+	}
+
+	if config.Timeout > 0 {
+		if err := setAdapterTimeout(file, config.Timeout); err != nil {
+			return nil, err
+		}
+	}
+
+	if config.Retries > 0 {
+		if err := file.IoctlInt(ioctlI2CRetries, uintptr(config.Retries)); err != nil {
+			return nil, fmt.Errorf("设置总线重试次数失败: %v", err)
+		}
+	}
+
+	return &LinuxDevice{
+		config: config,
+		file:   file,
+	}, nil
+}
+
+// adapterTimeoutUnit 内核适配器超时的单位
+const adapterTimeoutUnit = 10 * time.Millisecond
+
+// setAdapterTimeout 设置适配器超时，按 adapterTimeoutUnit 向下取整，至少为一个单位
+func setAdapterTimeout(file ioctlFile, timeout time.Duration) error {
+	jiffies := uintptr(timeout / adapterTimeoutUnit)
+	if jiffies == 0 {
+		jiffies = 1
+	}
+	if err := file.IoctlInt(ioctlI2CTimeout, jiffies); err != nil {
+		return fmt.Errorf("设置总线超时失败: %v", err)
+	}
+	return nil
+}
+
+// errnoClass 把 i2c-dev 返回的错误码归类，参见内核 Documentation/i2c/fault-codes.rst
+func errnoClass(err error) error {
+	var errno syscall.Errno
+	if !errors.As(err, &errno) {
+		return err
+	}
+	switch errno {
+	case syscall.ENXIO, syscall.ENODEV, syscall.ENOENT:
+		return fmt.Errorf("%w: %w", ErrNoDevice, err)
+	case syscall.EREMOTEIO:
+		return fmt.Errorf("%w: %w", ErrNACK, err)
+	case syscall.ETIMEDOUT:
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
+	case syscall.EAGAIN, syscall.EBUSY:
+		return fmt.Errorf("%w: %w", ErrBusBusy, err)
+	}
+	return err
+}
+
+// Transfer 通过 I2C_RDWR 执行一次组合传输
+func (dev *LinuxDevice) Transfer(msgs []Msg) error {
+	return dev.TransferCtx(context.Background(), msgs)
+}
+
+// TransferCtx 通过 I2C_RDWR 执行一次组合传输
+//
+// 组合传输在内核中一次完成，无法从用户态中途取消: 传输前检查 ctx，
+// ctx 的截止时间早于配置的超时时临时缩短适配器超时，由内核在截止时间中止传输。
+func (dev *LinuxDevice) TransferCtx(ctx context.Context, msgs []Msg) error {
+	if dev.file == nil {
+		return ErrClosed
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && dev.config.Timeout > 0 {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return context.DeadlineExceeded
+		}
+		// 只在按内核单位取整后确实更短时修改 (剩余时间向上取整，最多晚于截止时间一个单位)，
+		// 避免每次尝试的超时等于配置的超时时每次传输都多两次 ioctl；总线上的设备共享适配器，
+		// 修改对所有设备生效
+		units := (remaining + adapterTimeoutUnit - 1) / adapterTimeoutUnit
+		if units < dev.config.Timeout/adapterTimeoutUnit {
+			if err := setAdapterTimeout(dev.file, remaining); err != nil {
+				return err
+			}
+			defer setAdapterTimeout(dev.file, dev.config.Timeout)
+		}
+	}
+
+	err := dev.transfer(msgs)
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// transfer 执行 I2C_RDWR
+func (dev *LinuxDevice) transfer(msgs []Msg) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	raw := make([]i2cMsg, len(msgs))
+	extra := make([]int, len(msgs))
+	for i, msg := range msgs {
+		if len(msg.Buf) > 0xFFFF {
+			return fmt.Errorf("消息过长: %d 字节", len(msg.Buf))
+		}
+		raw[i] = i2cMsg{addr: msg.Addr, flags: msg.Flags, len: uint16(len(msg.Buf))}
+		if len(msg.Buf) > 0 {
+			raw[i].buf = &msg.Buf[0]
+		}
+		if msg.Flags&MsgRecvLen != 0 {
+			// 内核要求 buf[0] 预置为长度字节之外的附加字节数（长度字节本身，以及可选的PEC）
+			if len(msg.Buf) == 0 || msg.Buf[0] < 1 {
+				return fmt.Errorf("块读取消息缺少长度前缀")
+			}
+			extra[i] = int(msg.Buf[0])
+		}
+	}
+
+	data := i2cRdwrData{
+		msgs:  &raw[0],
+		nmsgs: uint32(len(raw)),
+	}
+	if err := dev.file.IoctlPtr(ioctlI2CRdwr, unsafe.Pointer(&data)); err != nil {
+		return errnoClass(err)
+	}
+
+	// 块读取的实际长度由从设备返回的首字节决定
+	for i, msg := range msgs {
+		if msg.Flags&MsgRecvLen != 0 {
+			n := extra[i] + int(msg.Buf[0])
+			if n > len(msg.Buf) {
+				n = len(msg.Buf)
+			}
+			msgs[i].Buf = msg.Buf[:n]
+		}
+	}
+	return nil
+}
+
+// ReadRegister 读取寄存器值
+func (dev *LinuxDevice) ReadRegister(reg uint8) (uint8, error) {
+	return dev.ReadRegisterCtx(context.Background(), reg)
+}
+
+// WriteRegister 写入寄存器值
+func (dev *LinuxDevice) WriteRegister(reg, value uint8) error {
+	return dev.WriteRegisterCtx(context.Background(), reg, value)
+}
+
+// ReadBytes 读取多个字节
+func (dev *LinuxDevice) ReadBytes(reg uint8, count int) ([]byte, error) {
+	return dev.ReadBytesCtx(context.Background(), reg, count)
+}
+
+// WriteBytes 写入多个字节
+func (dev *LinuxDevice) WriteBytes(reg uint8, data []byte) error {
+	return dev.WriteBytesCtx(context.Background(), reg, data)
+}
+
+// ReadRegisterCtx 读取寄存器值
+func (dev *LinuxDevice) ReadRegisterCtx(ctx context.Context, reg uint8) (uint8, error) {
+	data, err := dev.ReadBytesCtx(ctx, reg, 1)
+	if err != nil {
+		return 0, err
+	}
+	return data[0], nil
+}
+
+// WriteRegisterCtx 写入寄存器值
+func (dev *LinuxDevice) WriteRegisterCtx(ctx context.Context, reg, value uint8) error {
+	return dev.WriteBytesCtx(ctx, reg, []byte{value})
+}
+
+// ReadBytesCtx 读取多个字节
+func (dev *LinuxDevice) ReadBytesCtx(ctx context.Context, reg uint8, count int) ([]byte, error) {
+	if count <= 0 || count > 0xFFFF {
+		return nil, fmt.Errorf("无效的读取字节数: %d", count)
+	}
+
+	data := make([]byte, count)
+	msgs := []Msg{
+		{Addr: uint16(dev.config.Address), Buf: []byte{reg}},
+		{Addr: uint16(dev.config.Address), Flags: MsgRead, Buf: data},
+	}
+
+	if err := dev.TransferCtx(ctx, msgs); err != nil {
+		return nil, opError(OpRead, dev.config.Bus, dev.config.Address, int(reg), err)
+	}
+	return data, nil
+}
+
+// WriteBytesCtx 写入多个字节
+func (dev *LinuxDevice) WriteBytesCtx(ctx context.Context, reg uint8, data []byte) error {
+	if len(data) == 0 {
+		return fmt.Errorf("写入数据为空")
+	}
+
+	if len(data) >= 0xFFFF {
+		return fmt.Errorf("写入数据过长: %d 字节", len(data))
+	}
+
+	buf := make([]byte, 0, len(data)+1)
+	buf = append(buf, reg)
+	buf = append(buf, data...)
+	msgs := []Msg{
+		{Addr: uint16(dev.config.Address), Buf: buf},
+	}
+
+	if err := dev.TransferCtx(ctx, msgs); err != nil {
+		return opError(OpWrite, dev.config.Bus, dev.config.Address, int(reg), err)
+	}
+	return nil
+}
+
+// Close 关闭设备
+func (dev *LinuxDevice) Close() error {
+	if dev.file == nil {
+		return nil
+	}
+
+	err := dev.file.Close()
+	dev.file = nil
+	return err
+}
+
+// GetAddress 获取设备地址
+func (dev *LinuxDevice) GetAddress() uint8 {
+	return dev.config.Address
+}
+
+// GetBus 获取总线号
+func (dev *LinuxDevice) GetBus() int {
+	return dev.config.Bus
+}
@@ -0,0 +1,235 @@
+//go:build linux
+
+package i2c
+
+import (
+	"context"
+	"errors"
+	"syscall"
+	"testing"
+	"time"
+	"unsafe"
+)
+
+// fakeIoctlFile 模拟内核 i2c-dev 适配器，内部维护一个带寄存器指针的从设备
+type fakeIoctlFile struct {
+	slave     uint16
+	timeout   uintptr
+	retries   uintptr
+	registers [256]byte
+	pointer   uint8
+	transfers int
+	closed    bool
+	failRdwr  error
+
+	// rdwrTimeout 最近一次传输时的适配器超时
+	rdwrTimeout uintptr
+
+	// timeoutSets 设置适配器超时的次数
+	timeoutSets int
+}
+
+func (f *fakeIoctlFile) IoctlInt(req, arg uintptr) error {
+	switch req {
+	case ioctlI2CSlave:
+		f.slave = uint16(arg)
+	case ioctlI2CTimeout:
+		f.timeout = arg
+		f.timeoutSets++
+	case ioctlI2CRetries:
+		f.retries = arg
+	default:
+		return syscall.EINVAL
+	}
+	return nil
+}
+
+func (f *fakeIoctlFile) IoctlPtr(req uintptr, arg unsafe.Pointer) error {
+	if req != ioctlI2CRdwr {
+		return syscall.EINVAL
+	}
+	if f.failRdwr != nil {
+		return f.failRdwr
+	}
+
+	f.transfers++
+	f.rdwrTimeout = f.timeout
+	data := (*i2cRdwrData)(arg)
+	msgs := unsafe.Slice(data.msgs, data.nmsgs)
+	for _, msg := range msgs {
+		if msg.addr != f.slave {
+			return syscall.ENXIO
+		}
+		buf := unsafe.Slice(msg.buf, msg.len)
+		if msg.flags&MsgRead != 0 {
+			for i := range buf {
+				buf[i] = f.registers[f.pointer]
+				f.pointer++
+			}
+			continue
+		}
+		if len(buf) == 0 {
+			continue
+		}
+		f.pointer = buf[0]
+		for _, b := range buf[1:] {
+			f.registers[f.pointer] = b
+			f.pointer++
+		}
+	}
+	return nil
+}
+
+func (f *fakeIoctlFile) Close() error {
+	f.closed = true
+	return nil
+}
+
+func newTestLinuxDevice(t *testing.T, file *fakeIoctlFile) *LinuxDevice {
+	t.Helper()
+
+	config := &DeviceConfig{
+		Bus:     1,
+		Address: 0x48,
+		Timeout: 250 * time.Millisecond,
+		Retries: 2,
+	}
+
+	dev, err := newLinuxDevice(file, config)
+	if err != nil {
+		t.Fatalf("初始化设备失败: %v", err)
+	}
+	return dev
+}
+
+func TestLinuxDeviceSetup(t *testing.T) {
+	file := &fakeIoctlFile{}
+	newTestLinuxDevice(t, file)
+
+	if file.slave != 0x48 {
+		t.Errorf("期望从设备地址 0x48，实际 0x%02X", file.slave)
+	}
+	if file.timeout != 25 {
+		t.Errorf("期望超时 25 (10ms 单位)，实际 %d", file.timeout)
+	}
+	if file.retries != 2 {
+		t.Errorf("期望重试 2 次，实际 %d", file.retries)
+	}
+}
+
+func TestLinuxDeviceReadWrite(t *testing.T) {
+	file := &fakeIoctlFile{}
+	dev := newTestLinuxDevice(t, file)
+
+	if err := dev.WriteBytes(0x20, []byte{0x11, 0x22, 0x33}); err != nil {
+		t.Fatalf("写入多字节失败: %v", err)
+	}
+	if file.registers[0x21] != 0x22 {
+		t.Errorf("期望寄存器 0x21 为 0x22，实际 0x%02X", file.registers[0x21])
+	}
+
+	data, err := dev.ReadBytes(0x20, 3)
+	if err != nil {
+		t.Fatalf("读取多字节失败: %v", err)
+	}
+	for i, expected := range []byte{0x11, 0x22, 0x33} {
+		if data[i] != expected {
+			t.Errorf("位置 %d: 期望 0x%02X，实际 0x%02X", i, expected, data[i])
+		}
+	}
+
+	if err := dev.WriteRegister(0x05, 0xA5); err != nil {
+		t.Fatalf("写入寄存器失败: %v", err)
+	}
+	value, err := dev.ReadRegister(0x05)
+	if err != nil {
+		t.Fatalf("读取寄存器失败: %v", err)
+	}
+	if value != 0xA5 {
+		t.Errorf("期望值 0xA5，实际 0x%02X", value)
+	}
+
+	// 每次读写都应是一次 I2C_RDWR 组合传输
+	if file.transfers != 4 {
+		t.Errorf("期望 4 次传输，实际 %d", file.transfers)
+	}
+}
+
+func TestLinuxDeviceErrors(t *testing.T) {
+	file := &fakeIoctlFile{failRdwr: syscall.EREMOTEIO}
+	dev := newTestLinuxDevice(t, file)
+
+	if _, err := dev.ReadRegister(0x00); !errors.Is(err, ErrNACK) || !errors.Is(err, syscall.EREMOTEIO) {
+		t.Errorf("EREMOTEIO 应归类为 ErrNACK，得到 %v", err)
+	}
+
+	file.failRdwr = syscall.ENXIO
+	if err := dev.WriteRegister(0x00, 0x01); !errors.Is(err, ErrNoDevice) {
+		t.Errorf("ENXIO 应归类为 ErrNoDevice，得到 %v", err)
+	}
+
+	if err := dev.WriteBytes(0x00, nil); err == nil {
+		t.Error("空数据写入应该失败")
+	}
+
+	if err := dev.Close(); err != nil {
+		t.Errorf("关闭设备失败: %v", err)
+	}
+	if !file.closed {
+		t.Error("文件描述符应该已关闭")
+	}
+
+	if _, err := dev.ReadBytes(0x00, 1); !errors.Is(err, ErrClosed) {
+		t.Errorf("关闭后读取应返回 ErrClosed，得到 %v", err)
+	}
+}
+
+func TestLinuxDeviceContext(t *testing.T) {
+	file := &fakeIoctlFile{}
+	dev := newTestLinuxDevice(t, file)
+
+	// 截止时间早于配置的超时时，传输期间缩短适配器超时，结束后恢复
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := dev.WriteRegisterCtx(ctx, 0x01, 0x42); err != nil {
+		t.Fatalf("写入寄存器失败: %v", err)
+	}
+	if file.rdwrTimeout == 0 || file.rdwrTimeout > 10 {
+		t.Errorf("期望传输时超时不超过 10 (10ms 单位)，实际 %d", file.rdwrTimeout)
+	}
+	if file.timeout != 25 {
+		t.Errorf("传输后应恢复超时 25，实际 %d", file.timeout)
+	}
+
+	// 截止时间晚于配置的超时时不修改
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if _, err := dev.ReadRegisterCtx(ctx, 0x01); err != nil {
+		t.Fatalf("读取寄存器失败: %v", err)
+	}
+	if file.rdwrTimeout != 25 {
+		t.Errorf("期望传输时超时 25，实际 %d", file.rdwrTimeout)
+	}
+
+	// 截止时间与配置的超时相同 (每次尝试的超时) 时，按 10ms 取整后没有变化，不发出 ioctl
+	sets := file.timeoutSets
+	ctx, cancel = context.WithTimeout(context.Background(), 250*time.Millisecond)
+	defer cancel()
+	if _, err := dev.ReadRegisterCtx(ctx, 0x01); err != nil {
+		t.Fatalf("读取寄存器失败: %v", err)
+	}
+	if file.timeoutSets != sets {
+		t.Errorf("超时取整后未变化时不应设置适配器超时，设置了 %d 次", file.timeoutSets-sets)
+	}
+
+	// 已取消的 ctx 不发起传输
+	transfers := file.transfers
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	if _, err := dev.ReadBytesCtx(ctx, 0x00, 4); !errors.Is(err, context.Canceled) {
+		t.Errorf("期望 context.Canceled，得到 %v", err)
+	}
+	if file.transfers != transfers {
+		t.Error("取消后不应发起传输")
+	}
+}
@@ -0,0 +1,31 @@
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package i2c
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// lockFileTimeout 等待锁文件的最长时间
+const lockFileTimeout = 10 * time.Second
+
+// lockFile 通过独占创建锁文件获取排他锁，返回释放函数
+func lockFile(path string) (func(), error) {
+	deadline := time.Now().Add(lockFileTimeout)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%w: 等待锁文件 %s 超时", ErrBusBusy, path)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
@@ -0,0 +1,42 @@
+package i2c
+
+import (
+	"context"
+	"time"
+
+	"sensorcli/logger"
+)
+
+// Logging 记录每次设备操作的总线、地址、寄存器、数据、耗时和错误的中间件
+func Logging() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, op *Op) ([]byte, error) {
+			start := time.Now()
+			data, err := next(ctx, op)
+			elapsed := time.Since(start)
+
+			log := logger.NewDeviceLogger(op.Bus, op.Addr)
+			switch op.Kind {
+			case OpRead:
+				log.LogRead(op.Reg, data, elapsed, err)
+			case OpWrite:
+				log.LogWrite(op.Reg, op.Data, elapsed, err)
+			default:
+				var read []byte
+				for _, msg := range op.Msgs {
+					if msg.Flags&MsgRead != 0 {
+						read = append(read, msg.Buf...)
+					}
+				}
+				log.LogTransfer(len(op.Msgs), op.written(), read, elapsed, err)
+			}
+			return data, err
+		}
+	}
+}
+
+// NewLoggingDevice 返回通过 logger 记录所有操作的设备，
+// 底层设备支持原始传输时返回的设备也支持
+func NewLoggingDevice(dev Device) Device {
+	return Chain(dev, Logging())
+}
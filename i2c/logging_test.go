@@ -0,0 +1,72 @@
+package i2c
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sensorcli/logger"
+)
+
+func TestLoggingDevice(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "device.log")
+	if err := logger.Setup(logger.Options{Level: logger.DEBUG, Format: logger.FormatJSON, File: logFile}); err != nil {
+		t.Fatal(err)
+	}
+	defer logger.Close()
+
+	device := NewLoggingDevice(NewMockDevice(&DeviceConfig{Bus: 2, Address: 0x48, MockMode: true}))
+	if err := device.WriteBytes(0x01, []byte{0x60, 0xA0}); err != nil {
+		t.Fatalf("写入失败: %v", err)
+	}
+	if _, err := device.ReadBytes(0x01, 2); err != nil {
+		t.Fatalf("读取失败: %v", err)
+	}
+
+	// 装饰后仍然支持原始传输，可以用于 SMBus
+	bus, err := NewSMBus(device)
+	if err != nil {
+		t.Fatalf("装饰后的设备应支持原始传输: %v", err)
+	}
+	if _, err := bus.ReadWordData(0x01); err != nil {
+		t.Fatalf("SMBus 读取失败: %v", err)
+	}
+
+	data, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("日志不是 JSON: %q", line)
+		}
+		records = append(records, record)
+	}
+
+	want := []map[string]any{
+		{"msg": "写入寄存器", "reg": "0x01", "value": "60 A0"},
+		{"msg": "读取寄存器", "reg": "0x01", "value": "60 A0"},
+		{"msg": "组合传输", "msgs": float64(2), "written": "01", "value": "60 A0"},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("期望 %d 条日志，实际 %d 条:\n%s", len(want), len(records), data)
+	}
+	for i, attrs := range want {
+		record := records[i]
+		if record["subsystem"] != "i2c" || record["bus"] != float64(2) || record["addr"] != "0x48" {
+			t.Errorf("日志 %d 缺少设备属性: %v", i, record)
+		}
+		if _, ok := record["latency"]; !ok {
+			t.Errorf("日志 %d 缺少耗时: %v", i, record)
+		}
+		for k, v := range attrs {
+			if record[k] != v {
+				t.Errorf("日志 %d 的 %s = %v，期望 %v", i, k, record[k], v)
+			}
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package i2c
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// OpStats 一类操作的统计
+type OpStats struct {
+	// Count 操作次数
+	Count int64
+
+	// Errors 失败次数
+	Errors int64
+
+	// Bytes 成功传输的字节数 (写出和读回)
+	Bytes int64
+
+	// Latency 累计耗时
+	Latency time.Duration
+}
+
+// Metrics 按操作类型 (OpRead, OpWrite, OpTransfer) 统计设备操作，并发安全，
+// 同一个 Metrics 可以用于多个设备
+type Metrics struct {
+	mu    sync.Mutex
+	stats map[string]OpStats
+}
+
+// NewMetrics 创建空的统计
+func NewMetrics() *Metrics {
+	return &Metrics{stats: make(map[string]OpStats)}
+}
+
+// Middleware 返回把每次操作计入统计的中间件
+func (m *Metrics) Middleware() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, op *Op) ([]byte, error) {
+			start := time.Now()
+			data, err := next(ctx, op)
+			elapsed := time.Since(start)
+
+			m.mu.Lock()
+			s := m.stats[op.Kind]
+			s.Count++
+			s.Latency += elapsed
+			if err != nil {
+				s.Errors++
+			} else {
+				s.Bytes += int64(len(data) + len(op.written()))
+				for _, msg := range op.Msgs {
+					if msg.Flags&MsgRead != 0 {
+						s.Bytes += int64(len(msg.Buf))
+					}
+				}
+			}
+			m.stats[op.Kind] = s
+			m.mu.Unlock()
+			return data, err
+		}
+	}
+}
+
+// Stats 返回各类操作的统计
+func (m *Metrics) Stats() map[string]OpStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats := make(map[string]OpStats, len(m.stats))
+	for kind, s := range m.stats {
+		stats[kind] = s
+	}
+	return stats
+}
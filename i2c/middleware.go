@@ -0,0 +1,201 @@
+package i2c
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Op 一次设备操作，中间件通过它观察或改变操作
+type Op struct {
+	// Kind 操作类型: OpRead, OpWrite, OpTransfer
+	Kind string
+
+	// Bus 和 Addr 执行操作的设备
+	Bus  int
+	Addr uint8
+
+	// Reg 读写的起始寄存器，组合传输时不使用
+	Reg uint8
+
+	// Count 读取的字节数
+	Count int
+
+	// Data 写入的数据
+	Data []byte
+
+	// Msgs 组合传输的消息，读消息的数据写回对应的 Buf
+	Msgs []Msg
+}
+
+// String 返回操作的简短描述，如 "read 0x01 x2"、"write 0x01 [60 A0]"、
+// "transfer W[01] R2"，用于日志和测试断言
+func (op *Op) String() string {
+	switch op.Kind {
+	case OpRead:
+		return fmt.Sprintf("read 0x%02X x%d", op.Reg, op.Count)
+	case OpWrite:
+		return fmt.Sprintf("write 0x%02X [% X]", op.Reg, op.Data)
+	}
+	var b strings.Builder
+	b.WriteString(op.Kind)
+	for _, msg := range op.Msgs {
+		if msg.Flags&MsgRead != 0 {
+			fmt.Fprintf(&b, " R%d", len(msg.Buf))
+		} else {
+			fmt.Fprintf(&b, " W[% X]", msg.Buf)
+		}
+	}
+	return b.String()
+}
+
+// written 返回操作写出的所有字节
+func (op *Op) written() []byte {
+	if op.Kind == OpWrite {
+		return op.Data
+	}
+	var data []byte
+	for _, msg := range op.Msgs {
+		if msg.Flags&MsgRead == 0 {
+			data = append(data, msg.Buf...)
+		}
+	}
+	return data
+}
+
+// Handler 执行一次操作，read 操作返回读取的数据，其他操作返回 nil
+type Handler func(ctx context.Context, op *Op) ([]byte, error)
+
+// Middleware 包装 Handler，在操作前后加入横切逻辑 (日志、重试、跟踪等)
+type Middleware func(next Handler) Handler
+
+// Chain 返回依次经过 mws 处理后再访问 dev 的设备，mws[0] 在最外层
+//
+// dev 支持原始传输时返回的设备也支持，组合传输以 OpTransfer 操作经过中间件。
+func Chain(dev Device, mws ...Middleware) Device {
+	cd := WithContext(dev)
+	h := baseHandler(cd)
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+
+	chain := &chainDevice{Device: dev, handler: h}
+	if _, ok := dev.(Transferer); ok {
+		return &chainTransferer{chainDevice: chain}
+	}
+	return chain
+}
+
+// baseHandler 在设备上执行操作的最内层 Handler
+func baseHandler(dev ContextDevice) Handler {
+	return func(ctx context.Context, op *Op) ([]byte, error) {
+		switch op.Kind {
+		case OpRead:
+			if op.Count == 1 {
+				value, err := dev.ReadRegisterCtx(ctx, op.Reg)
+				if err != nil {
+					return nil, err
+				}
+				return []byte{value}, nil
+			}
+			return dev.ReadBytesCtx(ctx, op.Reg, op.Count)
+		case OpWrite:
+			if len(op.Data) == 1 {
+				return nil, dev.WriteRegisterCtx(ctx, op.Reg, op.Data[0])
+			}
+			return nil, dev.WriteBytesCtx(ctx, op.Reg, op.Data)
+		case OpTransfer:
+			tr, ok := dev.(Transferer)
+			if !ok {
+				return nil, fmt.Errorf("设备不支持原始I2C传输")
+			}
+			return nil, transferCtx(ctx, tr, op.Msgs)
+		}
+		return nil, fmt.Errorf("未知的操作类型: %s", op.Kind)
+	}
+}
+
+// chainDevice 把设备方法转换为 Op 交给中间件链
+type chainDevice struct {
+	Device
+	handler Handler
+}
+
+// chainTransferer 同时支持原始组合传输，保证包装后仍可用于 SMBus
+type chainTransferer struct {
+	*chainDevice
+}
+
+func (d *chainDevice) op(kind string, reg uint8) *Op {
+	return &Op{Kind: kind, Bus: d.GetBus(), Addr: d.GetAddress(), Reg: reg}
+}
+
+func (d *chainDevice) ReadRegister(reg uint8) (uint8, error) {
+	return d.ReadRegisterCtx(context.Background(), reg)
+}
+
+func (d *chainDevice) WriteRegister(reg, value uint8) error {
+	return d.WriteRegisterCtx(context.Background(), reg, value)
+}
+
+func (d *chainDevice) ReadBytes(reg uint8, count int) ([]byte, error) {
+	return d.ReadBytesCtx(context.Background(), reg, count)
+}
+
+func (d *chainDevice) WriteBytes(reg uint8, data []byte) error {
+	return d.WriteBytesCtx(context.Background(), reg, data)
+}
+
+func (d *chainDevice) ReadRegisterCtx(ctx context.Context, reg uint8) (uint8, error) {
+	data, err := d.ReadBytesCtx(ctx, reg, 1)
+	if err != nil {
+		return 0, err
+	}
+	if len(data) != 1 {
+		return 0, fmt.Errorf("读取寄存器 0x%02X 返回 %d 字节", reg, len(data))
+	}
+	return data[0], nil
+}
+
+func (d *chainDevice) WriteRegisterCtx(ctx context.Context, reg, value uint8) error {
+	return d.WriteBytesCtx(ctx, reg, []byte{value})
+}
+
+func (d *chainDevice) ReadBytesCtx(ctx context.Context, reg uint8, count int) ([]byte, error) {
+	op := d.op(OpRead, reg)
+	op.Count = count
+	return d.handler(ctx, op)
+}
+
+func (d *chainDevice) WriteBytesCtx(ctx context.Context, reg uint8, data []byte) error {
+	op := d.op(OpWrite, reg)
+	op.Data = data
+	_, err := d.handler(ctx, op)
+	return err
+}
+
+// SetPEC 转发给底层设备，使包装后的模拟设备仍能模拟 SMBus PEC
+func (d *chainDevice) SetPEC(enabled bool) {
+	if s, ok := d.Device.(pecSetter); ok {
+		s.SetPEC(enabled)
+	}
+}
+
+// Do 底层设备支持 Exclusive 时独占总线执行 fn，否则直接执行
+func (d *chainDevice) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ex, ok := d.Device.(Exclusive); ok {
+		return ex.Do(ctx, fn)
+	}
+	return fn(ctx)
+}
+
+func (d *chainTransferer) Transfer(msgs []Msg) error {
+	return d.TransferCtx(context.Background(), msgs)
+}
+
+func (d *chainTransferer) TransferCtx(ctx context.Context, msgs []Msg) error {
+	op := d.op(OpTransfer, 0)
+	op.Msgs = msgs
+	_, err := d.handler(ctx, op)
+	return err
+}
@@ -0,0 +1,183 @@
+package i2c
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// tagMiddleware 在操作前后把 name 追加到 calls，用于检查中间件顺序
+func tagMiddleware(name string, calls *[]string) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, op *Op) ([]byte, error) {
+			*calls = append(*calls, name+">"+op.String())
+			data, err := next(ctx, op)
+			*calls = append(*calls, "<"+name)
+			return data, err
+		}
+	}
+}
+
+func TestChainOrder(t *testing.T) {
+	mock := NewMockDevice(&DeviceConfig{Bus: 1, Address: 0x48, MockMode: true})
+	var calls []string
+	dev := Chain(mock, tagMiddleware("a", &calls), tagMiddleware("b", &calls))
+
+	if err := dev.WriteBytes(0x01, []byte{0x60, 0xA0}); err != nil {
+		t.Fatal(err)
+	}
+	value, err := dev.ReadRegister(0x01)
+	if err != nil || value != 0x60 {
+		t.Fatalf("期望读到 0x60，实际 0x%02X, %v", value, err)
+	}
+
+	want := []string{
+		"a>write 0x01 [60 A0]", "b>write 0x01 [60 A0]", "<b", "<a",
+		"a>read 0x01 x1", "b>read 0x01 x1", "<b", "<a",
+	}
+	if len(calls) != len(want) {
+		t.Fatalf("调用顺序错误: %v", calls)
+	}
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Errorf("第 %d 步: 期望 %q，实际 %q", i, want[i], calls[i])
+		}
+	}
+}
+
+func TestChainTransferAndPEC(t *testing.T) {
+	mock := NewMockDevice(&DeviceConfig{Bus: 1, Address: 0x0B, MockMode: true})
+	var calls []string
+	dev := Chain(mock, tagMiddleware("a", &calls))
+
+	bus, err := NewSMBus(dev)
+	if err != nil {
+		t.Fatalf("包装后的设备应支持原始传输: %v", err)
+	}
+	// PEC 需要转发给模拟设备，否则模拟设备不会追加校验码
+	bus.SetPEC(true)
+	if err := bus.WriteWordData(0x08, 0xCAFE); err != nil {
+		t.Fatal(err)
+	}
+	word, err := bus.ReadWordData(0x08)
+	if err != nil || word != 0xCAFE {
+		t.Fatalf("带PEC读字数据: 期望 0xCAFE，实际 0x%04X, %v", word, err)
+	}
+	if len(calls) != 4 || calls[2] != "a>transfer W[08] R3" {
+		t.Errorf("组合传输应经过中间件: %v", calls)
+	}
+}
+
+func TestTimeoutMiddleware(t *testing.T) {
+	mock := newFaultyDevice(t, &FaultConfig{Rules: []FaultRule{{Reg: hexByte(0x10), Latency: "1s"}}})
+	dev := Chain(mock, Timeout(10*time.Millisecond))
+
+	start := time.Now()
+	if _, err := dev.ReadRegister(0x10); !errors.Is(err, ErrTimeout) {
+		t.Errorf("期望 ErrTimeout，得到 %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("超时后应立即返回，实际等待 %v", elapsed)
+	}
+	if _, err := dev.ReadRegister(0x11); err != nil {
+		t.Errorf("没有延迟的读取不应超时: %v", err)
+	}
+}
+
+func TestCacheMiddleware(t *testing.T) {
+	mock := NewMockDevice(&DeviceConfig{Bus: 1, Address: 0x48, MockMode: true})
+	mock.WriteBytes(0x10, []byte{0x01, 0x02})
+	var calls []string
+	dev := Chain(mock, Cache(time.Minute), tagMiddleware("dev", &calls))
+
+	for i := 0; i < 3; i++ {
+		data, err := dev.ReadBytes(0x10, 2)
+		if err != nil || data[0] != 0x01 {
+			t.Fatalf("读取失败: % X, %v", data, err)
+		}
+		// 修改返回的数据不影响缓存
+		data[0] = 0xFF
+	}
+	if len(calls) != 2 {
+		t.Errorf("相同的读取应使用缓存，设备操作: %v", calls)
+	}
+
+	// 不同长度不共用缓存，写入后清空缓存
+	dev.ReadBytes(0x10, 1)
+	dev.WriteRegister(0x10, 0x55)
+	calls = nil
+	if value, _ := dev.ReadRegister(0x10); value != 0x55 {
+		t.Errorf("写入后应读到新值 0x55，实际 0x%02X", value)
+	}
+	if len(calls) != 2 {
+		t.Errorf("写入后应重新读取设备，设备操作: %v", calls)
+	}
+}
+
+func TestCacheExpires(t *testing.T) {
+	mock := NewMockDevice(&DeviceConfig{Bus: 1, Address: 0x48, MockMode: true})
+	var calls []string
+	dev := Chain(mock, Cache(time.Millisecond), tagMiddleware("dev", &calls))
+
+	dev.ReadRegister(0x10)
+	time.Sleep(5 * time.Millisecond)
+	dev.ReadRegister(0x10)
+	if len(calls) != 4 {
+		t.Errorf("过期后应重新读取设备，设备操作: %v", calls)
+	}
+}
+
+func TestMetricsMiddleware(t *testing.T) {
+	mock := newFaultyDevice(t, &FaultConfig{Rules: []FaultRule{{Reg: hexByte(0x20), Kind: FaultNACK}}})
+	metrics := NewMetrics()
+	dev := Chain(mock, metrics.Middleware())
+
+	dev.WriteBytes(0x10, []byte{1, 2, 3})
+	dev.ReadBytes(0x10, 3)
+	dev.ReadRegister(0x11)
+	dev.ReadRegister(0x20)
+
+	stats := metrics.Stats()
+	if s := stats[OpWrite]; s.Count != 1 || s.Errors != 0 || s.Bytes != 3 {
+		t.Errorf("写入统计错误: %+v", s)
+	}
+	if s := stats[OpRead]; s.Count != 3 || s.Errors != 1 || s.Bytes != 4 {
+		t.Errorf("读取统计错误: %+v", s)
+	}
+	if _, ok := stats[OpTransfer]; ok {
+		t.Error("没有组合传输时不应有统计")
+	}
+}
+
+func TestBusMiddlewareInsideRetry(t *testing.T) {
+	var txs []Transaction
+	metrics := NewMetrics()
+	dev, err := OpenWithConfig(&DeviceConfig{
+		Bus:      1,
+		Address:  0x48,
+		MockMode: true,
+		Faults:   &FaultConfig{Rules: []FaultRule{{Reg: hexByte(0x00), Kind: FaultNACK, Nth: []int{1}}}},
+		Retry:    &RetryPolicy{MaxAttempts: 3, Clock: &fakeClock{}},
+		BusMiddleware: []Middleware{
+			metrics.Middleware(),
+			Tracing(tracerFunc(func(tx Transaction) { txs = append(txs, tx) })),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dev.Close()
+
+	if _, err := dev.ReadRegister(0x00); err != nil {
+		t.Fatalf("重试后应成功: %v", err)
+	}
+
+	// 跟踪和统计记录总线上的每次尝试，包括未应答的那一次
+	if len(txs) != 2 || !errors.Is(txs[0].Err, ErrNACK) || txs[1].Err != nil {
+		t.Fatalf("期望 NACK 和成功两个事务，实际 %d 个: %+v", len(txs), txs)
+	}
+	if s := metrics.Stats()[OpRead]; s.Count != 2 || s.Errors != 1 {
+		t.Errorf("统计应包含每次尝试: %+v", s)
+	}
+}
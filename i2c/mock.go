@@ -1,99 +1,227 @@
 package i2c
 
 import (
+	"context"
 	"fmt"
 	"sync"
 )
 
+// mockChip 模拟从设备的寄存器状态，同一地址的多个设备句柄共享
+type mockChip struct {
+	mu        sync.Mutex
+	registers map[uint8]uint8
+	specs     map[uint8]*RegisterSpec
+	pec       bool
+	pointer   uint8
+
+	// dirty 本进程修改过、尚未写回状态文件的寄存器
+	dirty map[uint8]bool
+}
+
+// newMockChip 创建空白的模拟从设备
+func newMockChip() *mockChip {
+	return &mockChip{
+		registers: make(map[uint8]uint8),
+		specs:     make(map[uint8]*RegisterSpec),
+		dirty:     make(map[uint8]bool),
+	}
+}
+
+// read 读取寄存器并应用读副作用（读清除、计数器），调用方需持有锁
+func (chip *mockChip) read(reg uint8) uint8 {
+	value := chip.registers[reg]
+
+	spec, ok := chip.specs[reg]
+	if !ok {
+		return value
+	}
+
+	if spec.Access == AccessWriteOnly {
+		return 0
+	}
+
+	next := value &^ uint8(spec.ClearOnRead)
+	next += uint8(spec.Counter)
+	if next != value {
+		chip.registers[reg] = next
+		chip.dirty[reg] = true
+	}
+
+	return value
+}
+
+// write 写入寄存器并应用写语义（只读、写1清零），调用方需持有锁
+func (chip *mockChip) write(reg, value uint8) {
+	spec, ok := chip.specs[reg]
+	chip.dirty[reg] = true
+	if !ok {
+		chip.registers[reg] = value
+		return
+	}
+
+	old := chip.registers[reg]
+	w1c := uint8(spec.W1C)
+
+	// 普通位按访问模式写入
+	next := old & w1c
+	if spec.Access == AccessReadOnly {
+		next |= old &^ w1c
+	} else {
+		next |= value &^ w1c
+	}
+
+	// 写1清零位
+	next &^= value & w1c
+
+	chip.registers[reg] = next
+}
+
 // MockDevice 模拟I2C设备实现
 type MockDevice struct {
-	config    *DeviceConfig
-	registers map[uint8]uint8
-	mu        sync.RWMutex
-	closed    bool
+	config  *DeviceConfig
+	chip    *mockChip
+	mu      sync.RWMutex
+	closed  bool
+	onClose func() error
+	faults  *faultInjector
 }
 
 // NewMockDevice 创建模拟I2C设备
 func NewMockDevice(config *DeviceConfig) *MockDevice {
+	return newMockDeviceOn(config, newMockChip())
+}
+
+// newMockDeviceOn 在已有的模拟从设备上创建设备句柄
+func newMockDeviceOn(config *DeviceConfig, chip *mockChip) *MockDevice {
 	return &MockDevice{
-		config:    config,
-		registers: make(map[uint8]uint8),
-		closed:    false,
+		config: config,
+		chip:   chip,
+		closed: false,
 	}
 }
 
-// ReadRegister 读取寄存器值
-func (dev *MockDevice) ReadRegister(reg uint8) (uint8, error) {
-	if dev.closed {
-		return 0, fmt.Errorf("设备已关闭")
+// SetFaults 设置故障模型，为 nil 时关闭故障注入
+func (dev *MockDevice) SetFaults(fc *FaultConfig) error {
+	var fi *faultInjector
+	if fc != nil {
+		var err error
+		if fi, err = newFaultInjector(fc); err != nil {
+			return err
+		}
 	}
 
+	dev.mu.Lock()
+	dev.faults = fi
+	dev.mu.Unlock()
+	return nil
+}
+
+// inject 按故障模型处理一次操作：施加延迟并返回注入结果，
+// 延迟期间 ctx 结束时中止操作并返回 ctx.Err()
+func (dev *MockDevice) inject(ctx context.Context, reg uint8, op string) faultOutcome {
+	if err := ctx.Err(); err != nil {
+		return faultOutcome{err: err}
+	}
 	dev.mu.RLock()
-	defer dev.mu.RUnlock()
+	fi := dev.faults
+	dev.mu.RUnlock()
+	if fi == nil {
+		return faultOutcome{}
+	}
 
-	value, exists := dev.registers[reg]
-	if !exists {
-		// 返回默认值0
-		return 0, nil
+	out := fi.check(dev.config.Address, reg, op)
+	if err := sleepCtx(ctx, out.latency); err != nil {
+		return faultOutcome{err: err}
 	}
+	return out
+}
 
-	return value, nil
+// ReadRegister 读取寄存器值
+func (dev *MockDevice) ReadRegister(reg uint8) (uint8, error) {
+	return dev.ReadRegisterCtx(context.Background(), reg)
 }
 
 // WriteRegister 写入寄存器值
 func (dev *MockDevice) WriteRegister(reg, value uint8) error {
-	if dev.closed {
-		return fmt.Errorf("设备已关闭")
-	}
-
-	dev.mu.Lock()
-	defer dev.mu.Unlock()
-
-	dev.registers[reg] = value
-	return nil
+	return dev.WriteRegisterCtx(context.Background(), reg, value)
 }
 
 // ReadBytes 读取多个字节
 func (dev *MockDevice) ReadBytes(reg uint8, count int) ([]byte, error) {
-	if dev.closed {
-		return nil, fmt.Errorf("设备已关闭")
+	return dev.ReadBytesCtx(context.Background(), reg, count)
+}
+
+// WriteBytes 写入多个字节
+func (dev *MockDevice) WriteBytes(reg uint8, data []byte) error {
+	return dev.WriteBytesCtx(context.Background(), reg, data)
+}
+
+// ReadRegisterCtx 读取寄存器值
+func (dev *MockDevice) ReadRegisterCtx(ctx context.Context, reg uint8) (uint8, error) {
+	data, err := dev.ReadBytesCtx(ctx, reg, 1)
+	if err != nil {
+		return 0, err
+	}
+	return data[0], nil
+}
+
+// WriteRegisterCtx 写入寄存器值
+func (dev *MockDevice) WriteRegisterCtx(ctx context.Context, reg, value uint8) error {
+	return dev.WriteBytesCtx(ctx, reg, []byte{value})
+}
+
+// ReadBytesCtx 读取多个字节，注入的延迟可以被 ctx 中止
+func (dev *MockDevice) ReadBytesCtx(ctx context.Context, reg uint8, count int) ([]byte, error) {
+	if dev.IsClosed() {
+		return nil, dev.opError(OpRead, int(reg), ErrClosed)
 	}
 
 	if count <= 0 {
 		return nil, fmt.Errorf("无效的读取字节数: %d", count)
 	}
 
-	dev.mu.RLock()
-	defer dev.mu.RUnlock()
+	out := dev.inject(ctx, reg, OpRead)
+	if out.err != nil {
+		return nil, dev.opError(OpRead, int(reg), out.err)
+	}
 
+	dev.chip.mu.Lock()
 	data := make([]byte, count)
 	for i := 0; i < count; i++ {
-		value, exists := dev.registers[reg+uint8(i)]
-		if !exists {
-			data[i] = 0
-		} else {
-			data[i] = value
-		}
+		data[i] = dev.chip.read(reg + uint8(i))
 	}
+	dev.chip.mu.Unlock()
 
+	out.corrupt(data)
 	return data, nil
 }
 
-// WriteBytes 写入多个字节
-func (dev *MockDevice) WriteBytes(reg uint8, data []byte) error {
-	if dev.closed {
-		return fmt.Errorf("设备已关闭")
+// WriteBytesCtx 写入多个字节，注入的延迟可以被 ctx 中止
+func (dev *MockDevice) WriteBytesCtx(ctx context.Context, reg uint8, data []byte) error {
+	if dev.IsClosed() {
+		return dev.opError(OpWrite, int(reg), ErrClosed)
 	}
 
 	if len(data) == 0 {
 		return fmt.Errorf("写入数据为空")
 	}
 
-	dev.mu.Lock()
-	defer dev.mu.Unlock()
+	out := dev.inject(ctx, reg, OpWrite)
+	if out.err != nil {
+		return dev.opError(OpWrite, int(reg), out.err)
+	}
+
+	// 线路上的数据损坏会被原样写入设备
+	if out.bitFlips > 0 {
+		data = append([]byte(nil), data...)
+		out.corrupt(data)
+	}
+
+	dev.chip.mu.Lock()
+	defer dev.chip.mu.Unlock()
 
 	for i, value := range data {
-		dev.registers[reg+uint8(i)] = value
+		dev.chip.write(reg+uint8(i), value)
 	}
 
 	return nil
@@ -104,11 +232,22 @@ func (dev *MockDevice) Close() error {
 	dev.mu.Lock()
 	defer dev.mu.Unlock()
 
+	if dev.closed {
+		return nil
+	}
 	dev.closed = true
-	dev.registers = nil
+
+	if dev.onClose != nil {
+		return dev.onClose()
+	}
 	return nil
 }
 
+// opError 为错误附加设备的总线和地址
+func (dev *MockDevice) opError(op string, reg int, err error) error {
+	return opError(op, dev.config.Bus, dev.config.Address, reg, err)
+}
+
 // GetAddress 获取设备地址
 func (dev *MockDevice) GetAddress() uint8 {
 	return dev.config.Address
@@ -119,13 +258,157 @@ func (dev *MockDevice) GetBus() int {
 	return dev.config.Bus
 }
 
+// SetPEC 设置是否模拟SMBus包错误校验
+func (dev *MockDevice) SetPEC(enabled bool) {
+	dev.chip.mu.Lock()
+	defer dev.chip.mu.Unlock()
+	dev.chip.pec = enabled
+}
+
+// Transfer 模拟一次组合传输
+//
+// 写消息的首字节设置寄存器指针，其余字节从指针处依次写入但不移动指针；
+// 读消息从指针处读取并使指针自增，因此过程调用会回显写入的数据。启用PEC时，写传输的
+// 最后一个字节按校验码验证，读传输的最后一个字节填充校验码。
+func (dev *MockDevice) Transfer(msgs []Msg) error {
+	return dev.TransferCtx(context.Background(), msgs)
+}
+
+// TransferCtx 模拟一次组合传输，注入的延迟可以被 ctx 中止
+func (dev *MockDevice) TransferCtx(ctx context.Context, msgs []Msg) error {
+	if dev.IsClosed() {
+		return dev.opError(OpTransfer, -1, ErrClosed)
+	}
+
+	chip := dev.chip
+
+	for _, msg := range msgs {
+		if msg.Addr != uint16(dev.config.Address) {
+			return opError(OpTransfer, dev.config.Bus, uint8(msg.Addr), -1, ErrNoDevice)
+		}
+	}
+
+	// 故障注入以首个写消息的命令字节（或当前寄存器指针）作为寄存器
+	hasRead := false
+	op, reg, regKnown := OpWrite, uint8(0), false
+	for _, msg := range msgs {
+		if msg.Flags&MsgRead != 0 {
+			hasRead = true
+			op = OpRead
+		} else if !regKnown && len(msg.Buf) > 0 {
+			reg, regKnown = msg.Buf[0], true
+		}
+	}
+	if !regKnown {
+		chip.mu.Lock()
+		reg = chip.pointer
+		chip.mu.Unlock()
+	}
+
+	out := dev.inject(ctx, reg, op)
+	if out.err != nil {
+		return dev.opError(op, int(reg), out.err)
+	}
+	if out.bitFlips > 0 {
+		defer func() {
+			for _, msg := range msgs {
+				if msg.Flags&MsgRead != 0 {
+					out.corrupt(msg.Buf)
+				}
+			}
+		}()
+	}
+
+	chip.mu.Lock()
+	defer chip.mu.Unlock()
+
+	// 纯写传输在执行前校验PEC
+	if chip.pec && !hasRead && len(msgs) > 0 {
+		last := msgs[len(msgs)-1]
+		if len(last.Buf) > 1 {
+			if pecOfMsgs(msgs, 1) != last.Buf[len(last.Buf)-1] {
+				return fmt.Errorf("PEC 校验失败")
+			}
+			msgs = append(msgs[:len(msgs)-1:len(msgs)-1], Msg{Addr: last.Addr, Flags: last.Flags, Buf: last.Buf[:len(last.Buf)-1]})
+		}
+	}
+
+	for i, msg := range msgs {
+		if msg.Flags&MsgRead == 0 {
+			if len(msg.Buf) == 0 {
+				continue
+			}
+			chip.pointer = msg.Buf[0]
+			for j, value := range msg.Buf[1:] {
+				chip.write(msg.Buf[0]+uint8(j), value)
+			}
+			continue
+		}
+
+		buf := msg.Buf
+		pecLen := 0
+		if msg.Flags&MsgRecvLen != 0 {
+			if len(buf) == 0 || buf[0] < 1 {
+				return fmt.Errorf("块读取消息缺少长度前缀")
+			}
+			extra := int(buf[0])
+			count := int(chip.registers[chip.pointer])
+			if count > len(buf)-extra {
+				count = len(buf) - extra
+			}
+			buf = buf[:extra+count]
+			msgs[i].Buf = buf
+			pecLen = extra - 1
+		} else if chip.pec && i == len(msgs)-1 && len(buf) > 0 {
+			pecLen = 1
+		}
+
+		for j := 0; j < len(buf)-pecLen; j++ {
+			buf[j] = chip.read(chip.pointer)
+			chip.pointer++
+		}
+
+		if pecLen > 0 {
+			crc := pecOfMsgs(msgs[:i+1], pecLen)
+			for j := len(buf) - pecLen; j < len(buf); j++ {
+				if chip.pec {
+					buf[j] = crc
+				} else {
+					buf[j] = 0xFF
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// pecOfMsgs 计算组合传输的PEC，最后一条消息末尾 skip 个字节不参与计算
+func pecOfMsgs(msgs []Msg, skip int) uint8 {
+	var crc uint8
+	for i, msg := range msgs {
+		rw := uint8(0)
+		if msg.Flags&MsgRead != 0 {
+			rw = 1
+		}
+		crc = crc8(crc, []byte{uint8(msg.Addr<<1) | rw})
+
+		buf := msg.Buf
+		if i == len(msgs)-1 {
+			buf = buf[:len(buf)-skip]
+		}
+		crc = crc8(crc, buf)
+	}
+	return crc
+}
+
 // GetRegisters 获取所有寄存器值（用于调试）
 func (dev *MockDevice) GetRegisters() map[uint8]uint8 {
-	dev.mu.RLock()
-	defer dev.mu.RUnlock()
+	dev.chip.mu.Lock()
+	defer dev.chip.mu.Unlock()
 
 	result := make(map[uint8]uint8)
-	for k, v := range dev.registers {
+	for k, v := range dev.chip.registers {
 		result[k] = v
 	}
 	return result
@@ -137,9 +420,3 @@ func (dev *MockDevice) IsClosed() bool {
 	defer dev.mu.RUnlock()
 	return dev.closed
 }
-
-// openPlatform 平台特定的打开函数
-func openPlatform(config *DeviceConfig) (Device, error) {
-	// 在Windows环境下使用模拟实现
-	return NewMockDevice(config), nil
-}
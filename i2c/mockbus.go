@@ -0,0 +1,292 @@
+package i2c
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 寄存器访问模式
+const (
+	AccessReadWrite = "rw"
+	AccessReadOnly  = "ro"
+	AccessWriteOnly = "wo"
+)
+
+//go:embed profiles/default.yaml
+var defaultProfileData []byte
+
+// HexByte 可从数字或 "0x48" 形式的字符串解析的字节
+type HexByte uint8
+
+// UnmarshalJSON 解析 JSON 数字或字符串
+func (h *HexByte) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	return h.parse(s)
+}
+
+// UnmarshalYAML 解析 YAML 标量
+func (h *HexByte) UnmarshalYAML(node *yaml.Node) error {
+	return h.parse(node.Value)
+}
+
+// parse 按 Go 整数字面量规则解析（支持 0x 前缀）
+func (h *HexByte) parse(s string) error {
+	v, err := strconv.ParseUint(strings.TrimSpace(s), 0, 8)
+	if err != nil {
+		return fmt.Errorf("无效的字节值 %q", s)
+	}
+	*h = HexByte(v)
+	return nil
+}
+
+// RegisterSpec 模拟寄存器的初始值和行为
+type RegisterSpec struct {
+	Reg         HexByte `json:"reg" yaml:"reg"`
+	Value       HexByte `json:"value" yaml:"value"`
+	Access      string  `json:"access,omitempty" yaml:"access,omitempty"`
+	W1C         HexByte `json:"w1c,omitempty" yaml:"w1c,omitempty"`                     // 写1清零的位
+	ClearOnRead HexByte `json:"clear_on_read,omitempty" yaml:"clear_on_read,omitempty"` // 读取后自动清零的位
+	Counter     int     `json:"counter,omitempty" yaml:"counter,omitempty"`             // 每次读取后的自增步长
+}
+
+// DeviceProfile 模拟总线上的一个从设备
+type DeviceProfile struct {
+	Address   HexByte        `json:"address" yaml:"address"`
+	Name      string         `json:"name,omitempty" yaml:"name,omitempty"`
+	Registers []RegisterSpec `json:"registers,omitempty" yaml:"registers,omitempty"`
+}
+
+// MockProfile 模拟总线描述文件
+type MockProfile struct {
+	Devices []DeviceProfile `json:"devices" yaml:"devices"`
+	Faults  *FaultConfig    `json:"faults,omitempty" yaml:"faults,omitempty"`
+}
+
+// LoadMockProfile 加载模拟总线描述文件，根据扩展名选择 YAML 或 JSON 格式
+func LoadMockProfile(path string) (*MockProfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取模拟总线描述文件失败: %v", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return ParseMockProfile(data, "json")
+	default:
+		return ParseMockProfile(data, "yaml")
+	}
+}
+
+// ParseMockProfile 解析模拟总线描述，format 为 "yaml" 或 "json"
+func ParseMockProfile(data []byte, format string) (*MockProfile, error) {
+	profile := &MockProfile{}
+
+	var err error
+	switch format {
+	case "json":
+		err = json.Unmarshal(data, profile)
+	case "yaml":
+		err = yaml.Unmarshal(data, profile)
+	default:
+		return nil, fmt.Errorf("不支持的描述文件格式: %s", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析模拟总线描述文件失败: %v", err)
+	}
+
+	if err := profile.Validate(); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// Validate 校验描述文件内容
+func (p *MockProfile) Validate() error {
+	seen := make(map[HexByte]bool)
+	for _, dev := range p.Devices {
+		if dev.Address < 0x03 || dev.Address > 0x77 {
+			return fmt.Errorf("%w: 0x%02X (有效范围: 0x03-0x77)", ErrInvalidAddress, uint8(dev.Address))
+		}
+		if seen[dev.Address] {
+			return fmt.Errorf("重复的设备地址: 0x%02X", uint8(dev.Address))
+		}
+		seen[dev.Address] = true
+
+		regs := make(map[HexByte]bool)
+		for _, reg := range dev.Registers {
+			if regs[reg.Reg] {
+				return fmt.Errorf("设备 0x%02X 重复定义寄存器 0x%02X", uint8(dev.Address), uint8(reg.Reg))
+			}
+			regs[reg.Reg] = true
+
+			switch reg.Access {
+			case "", AccessReadWrite, AccessReadOnly, AccessWriteOnly:
+			default:
+				return fmt.Errorf("设备 0x%02X 寄存器 0x%02X 的访问模式无效: %s",
+					uint8(dev.Address), uint8(reg.Reg), reg.Access)
+			}
+		}
+	}
+
+	if p.Faults != nil {
+		return p.Faults.Validate()
+	}
+	return nil
+}
+
+// DefaultMockProfile 内置的默认模拟总线描述
+func DefaultMockProfile() *MockProfile {
+	profile, err := ParseMockProfile(defaultProfileData, "yaml")
+	if err != nil {
+		panic(fmt.Sprintf("内置模拟总线描述无效: %v", err))
+	}
+	return profile
+}
+
+// MockBus 模拟I2C总线，只有描述文件中定义的地址会应答
+type MockBus struct {
+	bus      int
+	mu       sync.Mutex
+	chips    map[uint8]*mockChip
+	names    map[uint8]string
+	restored map[uint8]bool // 已从状态文件加载的地址，由 restoreMu 保护
+	faults   *faultInjector
+
+	// restoreMu 串行化状态文件的加载，并发打开的句柄等待加载完成
+	restoreMu sync.Mutex
+}
+
+// NewMockBus 根据描述文件创建模拟总线
+func NewMockBus(bus int, profile *MockProfile) *MockBus {
+	mb := &MockBus{
+		bus:      bus,
+		chips:    make(map[uint8]*mockChip),
+		names:    make(map[uint8]string),
+		restored: make(map[uint8]bool),
+	}
+
+	for _, dev := range profile.Devices {
+		chip := newMockChip()
+		for i := range dev.Registers {
+			spec := dev.Registers[i]
+			if spec.Access == "" {
+				spec.Access = AccessReadWrite
+			}
+			chip.registers[uint8(spec.Reg)] = uint8(spec.Value)
+			chip.specs[uint8(spec.Reg)] = &spec
+		}
+		mb.chips[uint8(dev.Address)] = chip
+		mb.names[uint8(dev.Address)] = dev.Name
+	}
+
+	// 描述文件已校验，故障模型不会出错；注入器在总线上的所有设备句柄间共享
+	if profile.Faults != nil {
+		mb.faults, _ = newFaultInjector(profile.Faults)
+	}
+
+	return mb
+}
+
+// Open 打开总线上的设备，地址未定义时返回无应答错误
+func (mb *MockBus) Open(config *DeviceConfig) (*MockDevice, error) {
+	mb.mu.Lock()
+	chip, ok := mb.chips[config.Address]
+	mb.mu.Unlock()
+
+	if !ok {
+		return nil, opError(OpOpen, mb.bus, config.Address, -1, ErrNoDevice)
+	}
+	dev := newMockDeviceOn(config, chip)
+	dev.faults = mb.faults
+	return dev, nil
+}
+
+// Addresses 返回总线上所有设备地址（升序）
+func (mb *MockBus) Addresses() []uint8 {
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+
+	addrs := make([]uint8, 0, len(mb.chips))
+	for addr := range mb.chips {
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool { return addrs[i] < addrs[j] })
+	return addrs
+}
+
+// Name 返回设备在描述文件中的名称
+func (mb *MockBus) Name(addr uint8) string {
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+	return mb.names[addr]
+}
+
+var (
+	mockBusesMu sync.Mutex
+	mockBuses   = make(map[string]*MockBus)
+)
+
+// mockBusFor 返回配置对应的模拟总线，同一进程内相同描述文件和总线号共享状态
+func mockBusFor(config *DeviceConfig) (*MockBus, error) {
+	key := fmt.Sprintf("%s#%d", config.MockProfile, config.Bus)
+
+	mockBusesMu.Lock()
+	defer mockBusesMu.Unlock()
+
+	if mb, ok := mockBuses[key]; ok {
+		return mb, nil
+	}
+
+	profile := DefaultMockProfile()
+	if config.MockProfile != "" {
+		var err error
+		profile, err = LoadMockProfile(config.MockProfile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	mb := NewMockBus(config.Bus, profile)
+	mockBuses[key] = mb
+	return mb, nil
+}
+
+// openMock 在模拟总线上打开设备
+func openMock(config *DeviceConfig) (Device, error) {
+	mb, err := mockBusFor(config)
+	if err != nil {
+		return nil, err
+	}
+
+	dev, err := mb.Open(config)
+	if err != nil {
+		return nil, err
+	}
+
+	// 设备配置中的故障模型优先于描述文件
+	if config.Faults != nil {
+		if err := dev.SetFaults(config.Faults); err != nil {
+			return nil, err
+		}
+	}
+
+	// 跨进程持久化：打开时加载，关闭时写回
+	if dir := config.MockStateDir; dir != "" {
+		if err := mb.restore(dir, config.Address); err != nil {
+			return nil, err
+		}
+		dev.onClose = func() error {
+			return mb.persist(dir, config.Address)
+		}
+	}
+	return dev, nil
+}
@@ -0,0 +1,136 @@
+package i2c
+
+import (
+	"testing"
+	"time"
+)
+
+const testProfileYAML = `
+devices:
+  - address: 0x48
+    name: sensor
+    registers:
+      - {reg: 0x00, value: 0x19, access: ro}
+      - {reg: 0x01, value: 0x60}
+      - {reg: 0x02, value: 0xF0, w1c: 0xF0}
+      - {reg: 0x03, value: 0x81, clear_on_read: 0x80}
+      - {reg: 0x04, value: 0x10, counter: 2}
+  - address: 0x68
+`
+
+const testProfileJSON = `{
+  "devices": [
+    {"address": "0x50", "registers": [{"reg": "0x10", "value": 171}]}
+  ]
+}`
+
+func newTestMockBus(t *testing.T) *MockBus {
+	t.Helper()
+
+	profile, err := ParseMockProfile([]byte(testProfileYAML), "yaml")
+	if err != nil {
+		t.Fatalf("解析描述文件失败: %v", err)
+	}
+	return NewMockBus(1, profile)
+}
+
+func openTestMockDevice(t *testing.T, mb *MockBus, addr uint8) *MockDevice {
+	t.Helper()
+
+	dev, err := mb.Open(&DeviceConfig{Bus: 1, Address: addr, Timeout: time.Second, MockMode: true})
+	if err != nil {
+		t.Fatalf("打开设备 0x%02X 失败: %v", addr, err)
+	}
+	return dev
+}
+
+func TestMockBusAddresses(t *testing.T) {
+	mb := newTestMockBus(t)
+
+	addrs := mb.Addresses()
+	if len(addrs) != 2 || addrs[0] != 0x48 || addrs[1] != 0x68 {
+		t.Errorf("期望地址 [0x48 0x68]，实际 %X", addrs)
+	}
+
+	if mb.Name(0x48) != "sensor" {
+		t.Errorf("期望名称 sensor，实际 %q", mb.Name(0x48))
+	}
+
+	if _, err := mb.Open(&DeviceConfig{Bus: 1, Address: 0x50}); err == nil {
+		t.Error("未定义的地址应该无应答")
+	}
+}
+
+func TestMockBusRegisterBehaviors(t *testing.T) {
+	mb := newTestMockBus(t)
+	dev := openTestMockDevice(t, mb, 0x48)
+
+	// 只读寄存器忽略写入
+	dev.WriteRegister(0x00, 0xFF)
+	if v, _ := dev.ReadRegister(0x00); v != 0x19 {
+		t.Errorf("只读寄存器: 期望 0x19，实际 0x%02X", v)
+	}
+
+	// 写1清零：写 0x30 清除 bit5:4，其余 w1c 位保持
+	dev.WriteRegister(0x02, 0x3A)
+	if v, _ := dev.ReadRegister(0x02); v != 0xCA {
+		t.Errorf("写1清零寄存器: 期望 0xCA，实际 0x%02X", v)
+	}
+
+	// 读清除状态位
+	if v, _ := dev.ReadRegister(0x03); v != 0x81 {
+		t.Errorf("读清除寄存器首次读取: 期望 0x81，实际 0x%02X", v)
+	}
+	if v, _ := dev.ReadRegister(0x03); v != 0x01 {
+		t.Errorf("读清除寄存器再次读取: 期望 0x01，实际 0x%02X", v)
+	}
+
+	// 计数器每次读取递增
+	data, _ := dev.ReadBytes(0x04, 1)
+	next, _ := dev.ReadRegister(0x04)
+	if data[0] != 0x10 || next != 0x12 {
+		t.Errorf("计数器寄存器: 期望 0x10, 0x12，实际 0x%02X, 0x%02X", data[0], next)
+	}
+
+	// 同一地址的不同句柄共享状态
+	dev.WriteRegister(0x01, 0x42)
+	other := openTestMockDevice(t, mb, 0x48)
+	if v, _ := other.ReadRegister(0x01); v != 0x42 {
+		t.Errorf("共享状态: 期望 0x42，实际 0x%02X", v)
+	}
+
+	// 关闭一个句柄不影响其他句柄
+	dev.Close()
+	if _, err := other.ReadRegister(0x01); err != nil {
+		t.Errorf("其他句柄读取失败: %v", err)
+	}
+}
+
+func TestParseMockProfile(t *testing.T) {
+	profile, err := ParseMockProfile([]byte(testProfileJSON), "json")
+	if err != nil {
+		t.Fatalf("解析 JSON 描述文件失败: %v", err)
+	}
+
+	mb := NewMockBus(1, profile)
+	dev := openTestMockDevice(t, mb, 0x50)
+	if v, _ := dev.ReadRegister(0x10); v != 0xAB {
+		t.Errorf("期望 0xAB，实际 0x%02X", v)
+	}
+
+	invalid := []string{
+		"devices: [{address: 0x02}]",
+		"devices: [{address: 0x48}, {address: 0x48}]",
+		"devices: [{address: 0x48, registers: [{reg: 0x00, access: rx}]}]",
+		"devices: [{address: 0x48, registers: [{reg: 0x100}]}]",
+	}
+	for _, data := range invalid {
+		if _, err := ParseMockProfile([]byte(data), "yaml"); err == nil {
+			t.Errorf("无效描述应该失败: %s", data)
+		}
+	}
+
+	if len(DefaultMockProfile().Devices) == 0 {
+		t.Error("内置描述文件不应为空")
+	}
+}
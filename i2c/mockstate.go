@@ -0,0 +1,295 @@
+package i2c
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+// MarshalJSON 输出为 "0x48" 形式的字符串
+func (h HexByte) MarshalJSON() ([]byte, error) {
+	return []byte(fmt.Sprintf(`"0x%02X"`, uint8(h))), nil
+}
+
+// MockState 模拟总线的持久化寄存器状态
+type MockState struct {
+	Bus     int                           `json:"bus"`
+	Devices map[string]map[string]HexByte `json:"devices"`
+}
+
+// NewMockState 创建空的模拟总线状态
+func NewMockState(bus int) *MockState {
+	return &MockState{
+		Bus:     bus,
+		Devices: make(map[string]map[string]HexByte),
+	}
+}
+
+// Registers 返回指定设备的寄存器值，设备不存在时返回 nil
+func (s *MockState) Registers(addr uint8) (map[uint8]uint8, error) {
+	regs, ok := s.Devices[fmt.Sprintf("0x%02X", addr)]
+	if !ok {
+		return nil, nil
+	}
+
+	result := make(map[uint8]uint8, len(regs))
+	for key, value := range regs {
+		reg, err := strconv.ParseUint(key, 0, 8)
+		if err != nil {
+			return nil, fmt.Errorf("无效的寄存器地址 %q", key)
+		}
+		result[uint8(reg)] = uint8(value)
+	}
+	return result, nil
+}
+
+// SetRegisters 设置指定设备的寄存器值
+func (s *MockState) SetRegisters(addr uint8, registers map[uint8]uint8) {
+	regs := make(map[string]HexByte, len(registers))
+	for reg, value := range registers {
+		regs[fmt.Sprintf("0x%02X", reg)] = HexByte(value)
+	}
+	s.Devices[fmt.Sprintf("0x%02X", addr)] = regs
+}
+
+// Addresses 返回状态中的设备地址（升序）
+func (s *MockState) Addresses() ([]uint8, error) {
+	addrs := make([]uint8, 0, len(s.Devices))
+	for key := range s.Devices {
+		addr, err := strconv.ParseUint(key, 0, 8)
+		if err != nil {
+			return nil, fmt.Errorf("无效的设备地址 %q", key)
+		}
+		addrs = append(addrs, uint8(addr))
+	}
+	sort.Slice(addrs, func(i, j int) bool { return addrs[i] < addrs[j] })
+	return addrs, nil
+}
+
+// Validate 校验状态内容
+func (s *MockState) Validate() error {
+	addrs, err := s.Addresses()
+	if err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		if addr < 0x03 || addr > 0x77 {
+			return fmt.Errorf("%w: 0x%02X (有效范围: 0x03-0x77)", ErrInvalidAddress, addr)
+		}
+		if _, err := s.Registers(addr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DefaultMockStateDir 默认的模拟状态目录 (~/.sensorcli/mock)
+func DefaultMockStateDir() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(homeDir, ".sensorcli", "mock")
+}
+
+// MockStatePath 返回总线状态文件路径
+func MockStatePath(dir string, bus int) string {
+	return filepath.Join(dir, fmt.Sprintf("bus%d.json", bus))
+}
+
+// LoadMockState 加载总线状态文件，文件不存在时返回空状态
+func LoadMockState(dir string, bus int) (*MockState, error) {
+	var state *MockState
+	err := withStateLock(dir, bus, func() error {
+		var err error
+		state, err = readMockState(dir, bus)
+		return err
+	})
+	return state, err
+}
+
+// SaveMockState 保存总线状态文件
+func SaveMockState(dir string, state *MockState) error {
+	if err := state.Validate(); err != nil {
+		return err
+	}
+	return withStateLock(dir, state.Bus, func() error {
+		return writeMockState(dir, state)
+	})
+}
+
+// ResetMockState 删除总线状态文件，下次打开时恢复为描述文件中的初始值
+func ResetMockState(dir string, bus int) error {
+	return withStateLock(dir, bus, func() error {
+		err := os.Remove(MockStatePath(dir, bus))
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("删除模拟状态文件失败: %v", err)
+		}
+		return nil
+	})
+}
+
+// withStateLock 持有总线状态文件锁执行 fn
+func withStateLock(dir string, bus int, fn func() error) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("创建模拟状态目录失败: %v", err)
+	}
+
+	unlock, err := lockFile(MockStatePath(dir, bus) + ".lock")
+	if err != nil {
+		return fmt.Errorf("锁定模拟状态文件失败: %w", err)
+	}
+	defer unlock()
+
+	return fn()
+}
+
+// readMockState 读取状态文件，调用方需持有锁
+func readMockState(dir string, bus int) (*MockState, error) {
+	data, err := os.ReadFile(MockStatePath(dir, bus))
+	if os.IsNotExist(err) {
+		return NewMockState(bus), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取模拟状态文件失败: %v", err)
+	}
+
+	state := NewMockState(bus)
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("解析模拟状态文件失败: %v", err)
+	}
+	if state.Devices == nil {
+		state.Devices = make(map[string]map[string]HexByte)
+	}
+	state.Bus = bus
+	return state, nil
+}
+
+// writeMockState 原子地写入状态文件，调用方需持有锁
+func writeMockState(dir string, state *MockState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化模拟状态失败: %v", err)
+	}
+
+	path := MockStatePath(dir, state.Bus)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("写入模拟状态文件失败: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("写入模拟状态文件失败: %v", err)
+	}
+	return nil
+}
+
+// ApplyState 用持久化状态覆盖总线上已定义设备的寄存器值
+func (mb *MockBus) ApplyState(state *MockState) error {
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+
+	for addr, chip := range mb.chips {
+		regs, err := state.Registers(addr)
+		if err != nil {
+			return err
+		}
+		if regs == nil {
+			continue
+		}
+
+		chip.mu.Lock()
+		for reg, value := range regs {
+			chip.registers[reg] = value
+		}
+		chip.mu.Unlock()
+	}
+	return nil
+}
+
+// Snapshot 导出总线上所有设备的当前寄存器值
+func (mb *MockBus) Snapshot() *MockState {
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+
+	state := NewMockState(mb.bus)
+	for addr, chip := range mb.chips {
+		chip.mu.Lock()
+		state.SetRegisters(addr, chip.registers)
+		chip.mu.Unlock()
+	}
+	return state
+}
+
+// restore 首次打开设备时从状态文件恢复寄存器值，同一进程内成功加载一次；
+// 加载失败时下次打开重新加载
+func (mb *MockBus) restore(dir string, addr uint8) error {
+	mb.restoreMu.Lock()
+	defer mb.restoreMu.Unlock()
+	if mb.restored[addr] {
+		return nil
+	}
+
+	mb.mu.Lock()
+	chip := mb.chips[addr]
+	mb.mu.Unlock()
+
+	state, err := LoadMockState(dir, mb.bus)
+	if err != nil {
+		return err
+	}
+	regs, err := state.Registers(addr)
+	if err != nil {
+		return err
+	}
+
+	chip.mu.Lock()
+	for reg, value := range regs {
+		chip.registers[reg] = value
+	}
+	chip.mu.Unlock()
+
+	mb.restored[addr] = true
+	return nil
+}
+
+// persist 将本进程修改过的寄存器写回状态文件，其余寄存器保留文件中的值，
+// 不覆盖其他进程同时写入的寄存器
+func (mb *MockBus) persist(dir string, addr uint8) error {
+	mb.mu.Lock()
+	chip := mb.chips[addr]
+	mb.mu.Unlock()
+
+	return withStateLock(dir, mb.bus, func() error {
+		chip.mu.Lock()
+		defer chip.mu.Unlock()
+		if len(chip.dirty) == 0 {
+			return nil
+		}
+
+		state, err := readMockState(dir, mb.bus)
+		if err != nil {
+			return err
+		}
+		regs, err := state.Registers(addr)
+		if err != nil {
+			return err
+		}
+		if regs == nil {
+			regs = make(map[uint8]uint8, len(chip.dirty))
+		}
+		for reg := range chip.dirty {
+			regs[reg] = chip.registers[reg]
+		}
+		state.SetRegisters(addr, regs)
+
+		if err := writeMockState(dir, state); err != nil {
+			return err
+		}
+		clear(chip.dirty)
+		return nil
+	})
+}
@@ -0,0 +1,167 @@
+package i2c
+
+import (
+	"os"
+	"sync"
+	"testing"
+)
+
+func TestMockStatePersistence(t *testing.T) {
+	dir := t.TempDir()
+
+	// 第一个“进程”写入并关闭
+	first := newTestMockBus(t)
+	if err := first.restore(dir, 0x48); err != nil {
+		t.Fatalf("加载状态失败: %v", err)
+	}
+	dev := openTestMockDevice(t, first, 0x48)
+	dev.onClose = func() error { return first.persist(dir, 0x48) }
+	dev.WriteRegister(0x01, 0x42)
+	if err := dev.Close(); err != nil {
+		t.Fatalf("关闭时写回状态失败: %v", err)
+	}
+
+	// 第二个“进程”应看到写入的值
+	second := newTestMockBus(t)
+	if err := second.restore(dir, 0x48); err != nil {
+		t.Fatalf("加载状态失败: %v", err)
+	}
+	other := openTestMockDevice(t, second, 0x48)
+	if v, _ := other.ReadRegister(0x01); v != 0x42 {
+		t.Errorf("期望持久化的值 0x42，实际 0x%02X", v)
+	}
+
+	// 重置后恢复描述文件中的初始值
+	if err := ResetMockState(dir, 1); err != nil {
+		t.Fatalf("重置状态失败: %v", err)
+	}
+	third := newTestMockBus(t)
+	if err := third.restore(dir, 0x48); err != nil {
+		t.Fatalf("加载状态失败: %v", err)
+	}
+	if v, _ := openTestMockDevice(t, third, 0x48).ReadRegister(0x01); v != 0x60 {
+		t.Errorf("期望初始值 0x60，实际 0x%02X", v)
+	}
+}
+
+func TestMockStateConcurrentPersist(t *testing.T) {
+	dir := t.TempDir()
+
+	// 多个总线实例并发写回不同设备，结果不应互相覆盖
+	var wg sync.WaitGroup
+	for _, addr := range []uint8{0x48, 0x68} {
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(addr uint8, value uint8) {
+				defer wg.Done()
+
+				mb := newTestMockBus(t)
+				dev, err := mb.Open(&DeviceConfig{Bus: 1, Address: addr})
+				if err != nil {
+					t.Errorf("打开设备失败: %v", err)
+					return
+				}
+				dev.WriteRegister(0x10, value)
+				if err := mb.persist(dir, addr); err != nil {
+					t.Errorf("写回状态失败: %v", err)
+				}
+			}(addr, uint8(i))
+		}
+	}
+	wg.Wait()
+
+	state, err := LoadMockState(dir, 1)
+	if err != nil {
+		t.Fatalf("加载状态失败: %v", err)
+	}
+	addrs, err := state.Addresses()
+	if err != nil {
+		t.Fatalf("解析状态失败: %v", err)
+	}
+	if len(addrs) != 2 {
+		t.Errorf("期望 2 个设备的状态，实际 %d", len(addrs))
+	}
+
+	// 导出与导入往返
+	state.SetRegisters(0x50, map[uint8]uint8{0x00: 0xAA})
+	if err := SaveMockState(dir, state); err != nil {
+		t.Fatalf("保存状态失败: %v", err)
+	}
+	loaded, err := LoadMockState(dir, 1)
+	if err != nil {
+		t.Fatalf("加载状态失败: %v", err)
+	}
+	regs, _ := loaded.Registers(0x50)
+	if regs[0x00] != 0xAA {
+		t.Errorf("期望 0xAA，实际 0x%02X", regs[0x00])
+	}
+
+	state.SetRegisters(0x02, map[uint8]uint8{})
+	if err := SaveMockState(dir, state); err == nil {
+		t.Error("无效地址的状态应该保存失败")
+	}
+}
+
+func TestMockStatePersistKeepsOtherWrites(t *testing.T) {
+	dir := t.TempDir()
+
+	// 两个“进程”同时打开同一设备，各自写不同的寄存器
+	first := newTestMockBus(t)
+	second := newTestMockBus(t)
+	for _, mb := range []*MockBus{first, second} {
+		if err := mb.restore(dir, 0x48); err != nil {
+			t.Fatalf("加载状态失败: %v", err)
+		}
+	}
+	a := openTestMockDevice(t, first, 0x48)
+	a.onClose = func() error { return first.persist(dir, 0x48) }
+	b := openTestMockDevice(t, second, 0x48)
+	b.onClose = func() error { return second.persist(dir, 0x48) }
+
+	a.ReadRegister(0x05)
+	a.WriteRegister(0x06, 0x55)
+	b.WriteRegister(0x05, 0xAA)
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// 后关闭的句柄只写回自己修改的寄存器
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := LoadMockState(dir, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	regs, _ := state.Registers(0x48)
+	if regs[0x05] != 0xAA || regs[0x06] != 0x55 {
+		t.Errorf("两个进程的写入都应保留: 0x05=0x%02X 0x06=0x%02X", regs[0x05], regs[0x06])
+	}
+	if _, ok := regs[0x01]; ok {
+		t.Errorf("未修改的寄存器不应写入状态文件: %v", regs)
+	}
+}
+
+func TestMockStateRestoreRetry(t *testing.T) {
+	dir := t.TempDir()
+	path := MockStatePath(dir, 1)
+	if err := os.WriteFile(path, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mb := newTestMockBus(t)
+	if err := mb.restore(dir, 0x48); err == nil {
+		t.Fatal("状态文件无效时加载应失败")
+	}
+
+	// 加载失败不标记为已加载，修复文件后再次打开重新加载
+	if err := os.WriteFile(path, []byte(`{"devices": {"0x48": {"0x01": "0x42"}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := mb.restore(dir, 0x48); err != nil {
+		t.Fatalf("加载状态失败: %v", err)
+	}
+	if v, _ := openTestMockDevice(t, mb, 0x48).ReadRegister(0x01); v != 0x42 {
+		t.Errorf("期望加载的值 0x42，实际 0x%02X", v)
+	}
+}
@@ -0,0 +1,24 @@
+//go:build linux
+
+package i2c
+
+// openPlatform 平台特定的打开函数
+func openPlatform(config *DeviceConfig) (Device, error) {
+	if config.MockMode {
+		return openMock(config)
+	}
+
+	// Linux 下通过 i2c-dev 访问真实硬件
+	return openLinux(config)
+}
+
+// openAdapter 打开平台特定的总线适配器
+func openAdapter(config *DeviceConfig) (adapter, error) {
+	if config.MockMode {
+		if _, err := mockBusFor(config); err != nil {
+			return nil, err
+		}
+		return mockAdapter{}, nil
+	}
+	return openLinuxAdapter(config)
+}
@@ -0,0 +1,26 @@
+//go:build !linux
+
+package i2c
+
+import "fmt"
+
+// openPlatform 平台特定的打开函数
+func openPlatform(config *DeviceConfig) (Device, error) {
+	if !config.MockMode {
+		return nil, fmt.Errorf("当前平台不支持真实I2C访问，请启用模拟模式")
+	}
+
+	// 非 Linux 平台仅支持模拟实现
+	return openMock(config)
+}
+
+// openAdapter 平台特定的总线适配器
+func openAdapter(config *DeviceConfig) (adapter, error) {
+	if !config.MockMode {
+		return nil, fmt.Errorf("当前平台不支持真实I2C访问，请启用模拟模式")
+	}
+	if _, err := mockBusFor(config); err != nil {
+		return nil, err
+	}
+	return mockAdapter{}, nil
+}
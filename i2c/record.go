@@ -0,0 +1,102 @@
+package i2c
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Recorder 把打开的设备上的所有请求和响应录制到 Cassette，供 Player 回放
+type Recorder struct {
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// NewRecorder 创建录制器
+func NewRecorder() *Recorder {
+	return &Recorder{cassette: Cassette{Version: CassetteVersion, Recorded: time.Now()}}
+}
+
+// Open 用 OpenWithConfig 打开设备并录制打开结果，成功时返回录制所有操作的设备
+//
+// 录制中间件位于 config.Middleware 之后 (最内层)，录制的是重试后的最终结果。
+func (r *Recorder) Open(config *DeviceConfig) (Device, error) {
+	c := *config
+	c.Middleware = append(c.Middleware[:len(c.Middleware):len(c.Middleware)], r.Middleware())
+	dev, err := OpenWithConfig(&c)
+	r.add(Interaction{Op: CassetteOpen, Bus: config.Bus, Addr: HexByte(config.Address)}, err)
+	return dev, err
+}
+
+// Wrap 返回录制所有操作的设备，底层设备支持原始传输时返回的设备也支持
+func (r *Recorder) Wrap(dev Device) Device {
+	return Chain(dev, r.Middleware())
+}
+
+// Middleware 返回录制所有操作的中间件
+func (r *Recorder) Middleware() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, op *Op) ([]byte, error) {
+			// 操作类型与录制文件中的操作名 (CassetteRead 等) 相同
+			in := Interaction{Op: op.Kind, Bus: op.Bus, Addr: HexByte(op.Addr)}
+			if op.Kind == OpTransfer {
+				// 请求在传输前记录，读消息的缓冲区会被响应覆盖
+				for _, msg := range op.Msgs {
+					cm := CassetteMsg{Addr: HexByte(msg.Addr), Flags: msg.Flags}
+					if msg.Flags&MsgRead != 0 {
+						cm.Len = len(msg.Buf)
+					} else {
+						cm.Data = append(HexBytes(nil), msg.Buf...)
+					}
+					in.Msgs = append(in.Msgs, cm)
+				}
+			} else {
+				reg := HexByte(op.Reg)
+				in.Reg = &reg
+			}
+
+			data, err := next(ctx, op)
+			switch op.Kind {
+			case OpRead:
+				in.Count = op.Count
+				in.Read = append(HexBytes(nil), data...)
+			case OpWrite:
+				in.Write = append(HexBytes(nil), op.Data...)
+			case OpTransfer:
+				if err == nil {
+					for i, msg := range op.Msgs {
+						if msg.Flags&MsgRead != 0 {
+							in.Msgs[i].Data = append(HexBytes(nil), msg.Buf...)
+						}
+					}
+				}
+			}
+			r.add(in, err)
+			return data, err
+		}
+	}
+}
+
+// Cassette 返回目前录制的内容
+func (r *Recorder) Cassette() *Cassette {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c := r.cassette
+	c.Interactions = append([]Interaction(nil), r.cassette.Interactions...)
+	return &c
+}
+
+// Save 保存录制文件
+func (r *Recorder) Save(path string) error {
+	return r.Cassette().Save(path)
+}
+
+func (r *Recorder) add(in Interaction, err error) {
+	if err != nil {
+		in.Error = err.Error()
+		in.ErrorKind = errorKind(err)
+	}
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, in)
+	r.mu.Unlock()
+}
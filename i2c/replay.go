@@ -0,0 +1,247 @@
+package i2c
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// 回放匹配模式
+const (
+	// ReplayStrict 请求必须与录制的顺序和内容完全一致，结束时所有录制的请求都必须已回放
+	ReplayStrict = "strict"
+
+	// ReplayLenient 按内容匹配尚未回放的录制请求，忽略顺序；
+	// 同一请求的录制响应用完后重复最后一次的响应
+	ReplayLenient = "lenient"
+)
+
+// Player 从 Cassette 回放设备请求的响应，不访问任何硬件
+//
+// 出现未录制的请求或顺序不一致时，该请求返回错误，同时记录第一次不匹配，
+// 由 Finish 返回，避免调用方忽略错误 (如 scan) 时不匹配被掩盖。
+type Player struct {
+	mu       sync.Mutex
+	cassette *Cassette
+	mode     string
+	next     int            // strict: 下一个期望的请求
+	used     []bool         // lenient: 已回放的请求
+	last     map[string]int // lenient: 每种请求最后一次回放的记录
+	err      error
+}
+
+// NewPlayer 按匹配模式 (strict, lenient) 创建回放器
+func NewPlayer(c *Cassette, mode string) (*Player, error) {
+	switch mode {
+	case "":
+		mode = ReplayStrict
+	case ReplayStrict, ReplayLenient:
+	default:
+		return nil, fmt.Errorf("无效的回放模式: %s (可选: %s, %s)", mode, ReplayStrict, ReplayLenient)
+	}
+	return &Player{
+		cassette: c,
+		mode:     mode,
+		used:     make([]bool, len(c.Interactions)),
+		last:     make(map[string]int),
+	}, nil
+}
+
+// Open 回放设备的打开结果，录制时打开失败的地址返回相同的错误
+func (p *Player) Open(config *DeviceConfig) (Device, error) {
+	rec, err := p.play(Interaction{Op: CassetteOpen, Bus: config.Bus, Addr: HexByte(config.Address)})
+	if err != nil {
+		return nil, err
+	}
+	if err := rec.err(); err != nil {
+		return nil, err
+	}
+	dev := Device(&replayDevice{player: p, bus: config.Bus, addr: config.Address})
+	// 回放录制的最终结果，不重试
+	if mws := append(config.Middleware[:len(config.Middleware):len(config.Middleware)], config.BusMiddleware...); len(mws) > 0 {
+		dev = Chain(dev, mws...)
+	}
+	return dev, nil
+}
+
+// Finish 返回回放过程中第一次不匹配；strict 模式下还检查是否有录制的请求未回放
+func (p *Player) Finish() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return p.err
+	}
+	if p.mode == ReplayStrict && p.next < len(p.cassette.Interactions) {
+		return fmt.Errorf("回放不匹配: 还有 %d 个录制的请求未执行，下一个为 %s",
+			len(p.cassette.Interactions)-p.next, p.cassette.Interactions[p.next].Request())
+	}
+	return nil
+}
+
+// play 查找与请求匹配的录制记录
+func (p *Player) play(req Interaction) (*Interaction, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	rec, err := p.match(req.Request())
+	if err != nil && p.err == nil {
+		p.err = err
+	}
+	return rec, err
+}
+
+func (p *Player) match(request string) (*Interaction, error) {
+	recs := p.cassette.Interactions
+	if p.mode == ReplayStrict {
+		if p.next >= len(recs) {
+			return nil, fmt.Errorf("回放不匹配: 第 %d 个请求未录制: %s", p.next+1, request)
+		}
+		rec := &recs[p.next]
+		if rec.Request() != request {
+			return nil, fmt.Errorf("回放不匹配: 第 %d 个请求与录制不一致\n  录制: %s\n  实际: %s", p.next+1, rec.Request(), request)
+		}
+		p.next++
+		return rec, nil
+	}
+
+	for i := range recs {
+		if !p.used[i] && recs[i].Request() == request {
+			p.used[i] = true
+			p.last[request] = i
+			return &recs[i], nil
+		}
+	}
+	if i, ok := p.last[request]; ok {
+		return &recs[i], nil
+	}
+	return nil, fmt.Errorf("回放不匹配: 请求未录制: %s", request)
+}
+
+// replayDevice 由 Player 提供响应的设备
+type replayDevice struct {
+	player *Player
+	bus    int
+	addr   uint8
+}
+
+func (d *replayDevice) request(op string, reg uint8) Interaction {
+	r := HexByte(reg)
+	return Interaction{Op: op, Bus: d.bus, Addr: HexByte(d.addr), Reg: &r}
+}
+
+func (d *replayDevice) read(ctx context.Context, reg uint8, count int) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	req := d.request(CassetteRead, reg)
+	req.Count = count
+	rec, err := d.player.play(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := rec.err(); err != nil {
+		return nil, err
+	}
+	return append([]byte(nil), rec.Read...), nil
+}
+
+func (d *replayDevice) write(ctx context.Context, reg uint8, data []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	req := d.request(CassetteWrite, reg)
+	req.Write = data
+	rec, err := d.player.play(req)
+	if err != nil {
+		return err
+	}
+	return rec.err()
+}
+
+func (d *replayDevice) ReadRegister(reg uint8) (uint8, error) {
+	return d.ReadRegisterCtx(context.Background(), reg)
+}
+
+func (d *replayDevice) WriteRegister(reg, value uint8) error {
+	return d.WriteRegisterCtx(context.Background(), reg, value)
+}
+
+func (d *replayDevice) ReadBytes(reg uint8, count int) ([]byte, error) {
+	return d.ReadBytesCtx(context.Background(), reg, count)
+}
+
+func (d *replayDevice) WriteBytes(reg uint8, data []byte) error {
+	return d.WriteBytesCtx(context.Background(), reg, data)
+}
+
+func (d *replayDevice) ReadRegisterCtx(ctx context.Context, reg uint8) (uint8, error) {
+	data, err := d.read(ctx, reg, 1)
+	if err != nil {
+		return 0, err
+	}
+	if len(data) != 1 {
+		return 0, fmt.Errorf("回放的响应长度 %d 与请求长度 1 不一致", len(data))
+	}
+	return data[0], nil
+}
+
+func (d *replayDevice) WriteRegisterCtx(ctx context.Context, reg, value uint8) error {
+	return d.write(ctx, reg, []byte{value})
+}
+
+func (d *replayDevice) ReadBytesCtx(ctx context.Context, reg uint8, count int) ([]byte, error) {
+	return d.read(ctx, reg, count)
+}
+
+func (d *replayDevice) WriteBytesCtx(ctx context.Context, reg uint8, data []byte) error {
+	return d.write(ctx, reg, data)
+}
+
+func (d *replayDevice) Transfer(msgs []Msg) error {
+	return d.TransferCtx(context.Background(), msgs)
+}
+
+// TransferCtx 回放组合传输，读消息的缓冲区替换为录制的响应
+func (d *replayDevice) TransferCtx(ctx context.Context, msgs []Msg) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	req := Interaction{Op: CassetteTransfer, Bus: d.bus, Addr: HexByte(d.addr)}
+	for _, msg := range msgs {
+		cm := CassetteMsg{Addr: HexByte(msg.Addr), Flags: msg.Flags}
+		if msg.Flags&MsgRead != 0 {
+			cm.Len = len(msg.Buf)
+		} else {
+			cm.Data = msg.Buf
+		}
+		req.Msgs = append(req.Msgs, cm)
+	}
+
+	rec, err := d.player.play(req)
+	if err != nil {
+		return err
+	}
+	if err := rec.err(); err != nil {
+		return err
+	}
+	for i, msg := range msgs {
+		if msg.Flags&MsgRead != 0 {
+			n := copy(msg.Buf, rec.Msgs[i].Data)
+			msgs[i].Buf = msg.Buf[:n]
+		}
+	}
+	return nil
+}
+
+// Close 回放设备没有需要释放的资源
+func (d *replayDevice) Close() error {
+	return nil
+}
+
+func (d *replayDevice) GetAddress() uint8 {
+	return d.addr
+}
+
+func (d *replayDevice) GetBus() int {
+	return d.bus
+}
@@ -0,0 +1,137 @@
+package i2c
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// recordSession 在模拟总线上录制一段会话并经由文件读回
+func recordSession(t *testing.T) *Cassette {
+	t.Helper()
+	rec := NewRecorder()
+	mock := NewMockDevice(&DeviceConfig{Bus: 1, Address: 0x48, MockMode: true})
+	if err := mock.SetFaults(&FaultConfig{Rules: []FaultRule{{Op: OpRead, Kind: FaultNACK, Nth: []int{2}}}}); err != nil {
+		t.Fatal(err)
+	}
+	dev := rec.Wrap(mock)
+
+	if err := dev.WriteBytes(0x01, []byte{0x60, 0xA0}); err != nil {
+		t.Fatalf("写入失败: %v", err)
+	}
+	if _, err := dev.ReadBytes(0x01, 2); err != nil {
+		t.Fatalf("读取失败: %v", err)
+	}
+	if _, err := dev.ReadRegister(0x02); err == nil {
+		t.Fatal("第二次读取应注入未应答")
+	}
+	bus, err := NewSMBus(dev)
+	if err != nil {
+		t.Fatalf("包装后的设备应支持原始传输: %v", err)
+	}
+	if _, err := bus.ReadWordData(0x01); err != nil {
+		t.Fatalf("SMBus 读取失败: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "session.json")
+	if err := rec.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	c, err := LoadCassette(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Interactions) != 4 {
+		t.Fatalf("录制了 %d 个请求，期望 4", len(c.Interactions))
+	}
+	return c
+}
+
+func TestReplayStrict(t *testing.T) {
+	p, err := NewPlayer(recordSession(t), ReplayStrict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dev := &replayDevice{player: p, bus: 1, addr: 0x48}
+
+	if err := dev.WriteBytes(0x01, []byte{0x60, 0xA0}); err != nil {
+		t.Fatalf("回放写入失败: %v", err)
+	}
+	data, err := dev.ReadBytes(0x01, 2)
+	if err != nil || !bytes.Equal(data, []byte{0x60, 0xA0}) {
+		t.Fatalf("回放读取 = % X, %v", data, err)
+	}
+	if _, err := dev.ReadRegister(0x02); err == nil || err.Error() != "总线 1 设备 0x48 读取寄存器 0x02 失败: 无应答 (NACK)" {
+		t.Fatalf("应回放录制的错误，得到 %v", err)
+	} else if !errors.Is(err, ErrNACK) {
+		t.Errorf("回放的错误应保留分类: %v", err)
+	}
+	bus, _ := NewSMBus(dev)
+	word, err := bus.ReadWordData(0x01)
+	if err != nil || word != 0xA060 {
+		t.Fatalf("回放 SMBus 读取 = 0x%04X, %v", word, err)
+	}
+	if err := p.Finish(); err != nil {
+		t.Errorf("完整回放不应出错: %v", err)
+	}
+}
+
+func TestReplayStrictMismatch(t *testing.T) {
+	c := recordSession(t)
+
+	// 顺序不一致
+	p, _ := NewPlayer(c, ReplayStrict)
+	dev := &replayDevice{player: p, bus: 1, addr: 0x48}
+	if _, err := dev.ReadBytes(0x01, 2); err == nil || !strings.Contains(err.Error(), "回放不匹配") {
+		t.Fatalf("顺序不一致应报错，得到 %v", err)
+	}
+	// 调用方忽略错误后继续，Finish 仍返回第一次不匹配
+	dev.WriteBytes(0x01, []byte{0x60, 0xA0})
+	if err := p.Finish(); err == nil || !strings.Contains(err.Error(), "第 1 个请求") {
+		t.Errorf("Finish 应返回第一次不匹配，得到 %v", err)
+	}
+
+	// 请求未全部执行
+	p, _ = NewPlayer(c, ReplayStrict)
+	dev = &replayDevice{player: p, bus: 1, addr: 0x48}
+	if err := dev.WriteBytes(0x01, []byte{0x60, 0xA0}); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Finish(); err == nil || !strings.Contains(err.Error(), "还有 3 个") {
+		t.Errorf("未执行的请求应报错，得到 %v", err)
+	}
+}
+
+func TestReplayLenient(t *testing.T) {
+	p, err := NewPlayer(recordSession(t), ReplayLenient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dev := &replayDevice{player: p, bus: 1, addr: 0x48}
+
+	// 忽略顺序，同一请求重复时使用最后一次的响应
+	for i := 0; i < 3; i++ {
+		data, err := dev.ReadBytes(0x01, 2)
+		if err != nil || !bytes.Equal(data, []byte{0x60, 0xA0}) {
+			t.Fatalf("第 %d 次回放读取 = % X, %v", i+1, data, err)
+		}
+	}
+	if err := p.Finish(); err != nil {
+		t.Errorf("lenient 模式不要求执行所有请求: %v", err)
+	}
+
+	if err := dev.WriteBytes(0x01, []byte{0xFF}); err == nil || !strings.Contains(err.Error(), "未录制") {
+		t.Fatalf("未录制的请求应报错，得到 %v", err)
+	}
+	if err := p.Finish(); err == nil {
+		t.Error("Finish 应返回未录制的请求")
+	}
+}
+
+func TestNewPlayerRejectsMode(t *testing.T) {
+	if _, err := NewPlayer(&Cassette{}, "loose"); err == nil {
+		t.Error("应拒绝未知的回放模式")
+	}
+}
@@ -0,0 +1,237 @@
+package i2c
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"sensorcli/logger"
+)
+
+// Clock 重试等待使用的时钟，测试中可替换为不真正等待的实现
+type Clock interface {
+	// Sleep 等待 d 或直到 ctx 结束
+	Sleep(ctx context.Context, d time.Duration) error
+}
+
+// realClock 系统时钟
+type realClock struct{}
+
+func (realClock) Sleep(ctx context.Context, d time.Duration) error {
+	return sleepCtx(ctx, d)
+}
+
+// RetryPolicy 失败操作的重试策略
+//
+// 第 n 次重试前等待 BaseDelay*2^(n-1)，不超过 MaxDelay，再按 Jitter 随机缩短，
+// 避免多个进程同时重试。
+type RetryPolicy struct {
+	// MaxAttempts 最多尝试的次数 (包括第一次)，小于等于 1 时不重试
+	MaxAttempts int
+
+	// BaseDelay 第一次重试前的等待时间
+	BaseDelay time.Duration
+
+	// MaxDelay 等待时间上限，0 表示不限制
+	MaxDelay time.Duration
+
+	// Jitter 等待时间随机缩短的最大比例 (0-1)
+	Jitter float64
+
+	// Retryable 判断错误是否值得重试，为空时使用 IsTransient
+	Retryable func(err error) bool
+
+	// AttemptTimeout 每次尝试的超时，超时的尝试返回 ErrTimeout 并参与重试，0 表示不限制
+	AttemptTimeout time.Duration
+
+	// Clock 为空时使用系统时钟
+	Clock Clock
+
+	// Stats 不为空时累计重试统计，可在多个设备间共享
+	Stats *RetryStats
+}
+
+// DefaultRetryPolicy 默认重试策略: 最多重试 3 次，从 10ms 开始指数退避
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts: 4,
+		BaseDelay:   10 * time.Millisecond,
+		MaxDelay:    time.Second,
+		Jitter:      0.2,
+	}
+}
+
+// Validate 检查策略参数
+func (p *RetryPolicy) Validate() error {
+	if p.BaseDelay < 0 || p.MaxDelay < 0 || p.AttemptTimeout < 0 {
+		return fmt.Errorf("重试等待时间和超时不能为负数")
+	}
+	if p.Jitter < 0 || p.Jitter > 1 {
+		return fmt.Errorf("重试抖动比例必须在 0-1 之间: %g", p.Jitter)
+	}
+	return nil
+}
+
+// Delay 返回第 retry 次重试 (从 1 开始) 前的等待时间
+func (p *RetryPolicy) Delay(retry int) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < retry && (p.MaxDelay <= 0 || d < p.MaxDelay); i++ {
+		d *= 2
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if p.Jitter > 0 {
+		d -= time.Duration(float64(d) * p.Jitter * rand.Float64())
+	}
+	return d
+}
+
+// Do 按策略执行 fn，直到成功、遇到不可重试的错误、次数用完或 ctx 结束
+func (p *RetryPolicy) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return p.do(ctx, fn, nil)
+}
+
+// do 执行 fn，每次重试前调用 onRetry
+func (p *RetryPolicy) do(ctx context.Context, fn func(ctx context.Context) error, onRetry func(retry int, delay time.Duration, err error)) error {
+	clock := p.Clock
+	if clock == nil {
+		clock = realClock{}
+	}
+	retryable := p.Retryable
+	if retryable == nil {
+		retryable = IsTransient
+	}
+
+	var err error
+	retries := 0
+	for attempt := 1; ; attempt++ {
+		err = p.attempt(ctx, fn)
+		if err == nil || attempt >= p.MaxAttempts || !retryable(err) || ctx.Err() != nil {
+			break
+		}
+
+		retries++
+		delay := p.Delay(retries)
+		if onRetry != nil {
+			onRetry(retries, delay, err)
+		}
+		if serr := clock.Sleep(ctx, delay); serr != nil {
+			break
+		}
+	}
+
+	p.Stats.add(retries, err)
+	if err != nil && retries > 0 {
+		return fmt.Errorf("操作失败，已重试 %d 次: %w", retries, err)
+	}
+	return err
+}
+
+// attempt 执行一次尝试，超过 AttemptTimeout 时返回 ErrTimeout
+func (p *RetryPolicy) attempt(ctx context.Context, fn func(ctx context.Context) error) error {
+	if p.AttemptTimeout <= 0 {
+		return fn(ctx)
+	}
+
+	actx, cancel := context.WithTimeout(ctx, p.AttemptTimeout)
+	defer cancel()
+	err := fn(actx)
+	if err != nil && ctx.Err() == nil && errors.Is(actx.Err(), context.DeadlineExceeded) && !errors.Is(err, ErrTimeout) {
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
+	}
+	return err
+}
+
+// RetryStats 重试统计，并发安全
+type RetryStats struct {
+	operations atomic.Int64
+	retried    atomic.Int64
+	retries    atomic.Int64
+	failures   atomic.Int64
+}
+
+// RetryCounts RetryStats 的一份快照
+type RetryCounts struct {
+	// Operations 执行的操作数
+	Operations int64
+
+	// Retried 至少重试过一次的操作数
+	Retried int64
+
+	// Retries 重试的总次数
+	Retries int64
+
+	// Failures 最终失败的操作数
+	Failures int64
+}
+
+// Counts 返回当前的统计
+func (s *RetryStats) Counts() RetryCounts {
+	return RetryCounts{
+		Operations: s.operations.Load(),
+		Retried:    s.retried.Load(),
+		Retries:    s.retries.Load(),
+		Failures:   s.failures.Load(),
+	}
+}
+
+func (s *RetryStats) add(retries int, err error) {
+	if s == nil {
+		return
+	}
+	s.operations.Add(1)
+	if retries > 0 {
+		s.retried.Add(1)
+		s.retries.Add(int64(retries))
+	}
+	if err != nil {
+		s.failures.Add(1)
+	}
+}
+
+// Retry 按 policy 重试失败操作的中间件，每次重试记录到日志
+func Retry(policy *RetryPolicy) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, op *Op) (data []byte, err error) {
+			// 块读取会截短读消息的缓冲区并覆盖长度前缀，每次尝试前恢复
+			bufs := make([][]byte, len(op.Msgs))
+			prefix := make([]byte, len(op.Msgs))
+			for i, msg := range op.Msgs {
+				bufs[i] = msg.Buf
+				if msg.Flags&MsgRecvLen != 0 && len(msg.Buf) > 0 {
+					prefix[i] = msg.Buf[0]
+				}
+			}
+
+			reg := int(op.Reg)
+			if op.Kind == OpTransfer {
+				reg = -1
+			}
+			log := logger.NewDeviceLogger(op.Bus, op.Addr)
+
+			err = policy.do(ctx, func(ctx context.Context) error {
+				for i := range op.Msgs {
+					op.Msgs[i].Buf = bufs[i]
+					if op.Msgs[i].Flags&MsgRecvLen != 0 && len(bufs[i]) > 0 {
+						bufs[i][0] = prefix[i]
+					}
+				}
+				data, err = next(ctx, op)
+				return err
+			}, func(retry int, delay time.Duration, err error) {
+				log.LogRetry(op.Kind, reg, retry, delay, err)
+			})
+			return data, err
+		}
+	}
+}
+
+// NewRetryingDevice 返回按 policy 重试失败操作的设备，每次重试记录到日志，
+// 底层设备支持原始传输时返回的设备也支持
+func NewRetryingDevice(dev Device, policy *RetryPolicy) Device {
+	return Chain(dev, Retry(policy))
+}
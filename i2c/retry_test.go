@@ -0,0 +1,167 @@
+package i2c
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeClock 记录等待时间但不真正等待
+type fakeClock struct {
+	sleeps []time.Duration
+	cancel context.CancelFunc // 不为空时在第一次等待时取消
+}
+
+func (c *fakeClock) Sleep(ctx context.Context, d time.Duration) error {
+	c.sleeps = append(c.sleeps, d)
+	if c.cancel != nil {
+		c.cancel()
+	}
+	return ctx.Err()
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	p := &RetryPolicy{BaseDelay: 10 * time.Millisecond, MaxDelay: 50 * time.Millisecond}
+	want := []time.Duration{10, 20, 40, 50, 50}
+	for i, w := range want {
+		if d := p.Delay(i + 1); d != w*time.Millisecond {
+			t.Errorf("第 %d 次重试: 期望等待 %v，实际 %v", i+1, w*time.Millisecond, d)
+		}
+	}
+
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if d := p.Delay(2); d < 10*time.Millisecond || d > 20*time.Millisecond {
+			t.Fatalf("抖动后的等待时间 %v 超出 [10ms, 20ms]", d)
+		}
+	}
+}
+
+func TestRetryPolicyDo(t *testing.T) {
+	clock := &fakeClock{}
+	stats := &RetryStats{}
+	p := &RetryPolicy{MaxAttempts: 4, BaseDelay: time.Millisecond, Clock: clock, Stats: stats}
+
+	// 前两次 NACK，第三次成功
+	calls := 0
+	err := p.Do(context.Background(), func(context.Context) error {
+		calls++
+		if calls <= 2 {
+			return ErrNACK
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Fatalf("期望第 3 次成功，调用 %d 次，%v", calls, err)
+	}
+	if len(clock.sleeps) != 2 || clock.sleeps[0] != time.Millisecond || clock.sleeps[1] != 2*time.Millisecond {
+		t.Errorf("等待时间错误: %v", clock.sleeps)
+	}
+
+	// 次数用完后返回最后一次错误
+	calls = 0
+	err = p.Do(context.Background(), func(context.Context) error {
+		calls++
+		return ErrBusBusy
+	})
+	if calls != 4 || !errors.Is(err, ErrBusBusy) {
+		t.Errorf("期望尝试 4 次后失败，调用 %d 次，%v", calls, err)
+	}
+
+	// 不可重试的错误立即返回
+	calls = 0
+	err = p.Do(context.Background(), func(context.Context) error {
+		calls++
+		return ErrNoDevice
+	})
+	if calls != 1 || !errors.Is(err, ErrNoDevice) {
+		t.Errorf("不可重试的错误不应重试，调用 %d 次，%v", calls, err)
+	}
+
+	c := stats.Counts()
+	if c != (RetryCounts{Operations: 3, Retried: 2, Retries: 5, Failures: 2}) {
+		t.Errorf("统计错误: %+v", c)
+	}
+}
+
+func TestRetryPolicyRetryableAndCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clock := &fakeClock{cancel: cancel}
+	errOther := errors.New("其他错误")
+	p := &RetryPolicy{
+		MaxAttempts: 5,
+		Retryable:   func(err error) bool { return err == errOther },
+		Clock:       clock,
+	}
+
+	// 等待期间取消，不再尝试
+	calls := 0
+	err := p.Do(ctx, func(context.Context) error {
+		calls++
+		return errOther
+	})
+	if calls != 1 || len(clock.sleeps) != 1 || !errors.Is(err, errOther) {
+		t.Errorf("取消后不应继续重试: 调用 %d 次，等待 %d 次，%v", calls, len(clock.sleeps), err)
+	}
+}
+
+func TestRetryPolicyAttemptTimeout(t *testing.T) {
+	clock := &fakeClock{}
+	p := &RetryPolicy{MaxAttempts: 2, AttemptTimeout: 5 * time.Millisecond, Clock: clock}
+
+	// 第一次尝试超时后重试
+	calls := 0
+	err := p.Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		if calls == 1 {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return nil
+	})
+	if err != nil || calls != 2 {
+		t.Errorf("超时的尝试应重试: 调用 %d 次，%v", calls, err)
+	}
+}
+
+func TestRetryingDevice(t *testing.T) {
+	mock := NewMockDevice(&DeviceConfig{Bus: 1, Address: 0x48, MockMode: true})
+	mock.WriteBytes(0x10, []byte{0xAB, 0xCD})
+	mock.SetFaults(&FaultConfig{Rules: []FaultRule{{Reg: hexByte(0x10), Kind: FaultNACK, Nth: []int{1, 2}}}})
+
+	clock := &fakeClock{}
+	stats := &RetryStats{}
+	dev := NewRetryingDevice(mock, &RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, Clock: clock, Stats: stats})
+
+	data, err := dev.ReadBytes(0x10, 2)
+	if err != nil || data[0] != 0xAB || data[1] != 0xCD {
+		t.Fatalf("重试后应读到 ABCD，实际 % X, %v", data, err)
+	}
+	if c := stats.Counts(); c.Retries != 2 || c.Failures != 0 {
+		t.Errorf("统计错误: %+v", c)
+	}
+
+	// 装饰后仍可用于 SMBus
+	if _, err := NewSMBus(dev); err != nil {
+		t.Errorf("包装后的设备应支持原始传输: %v", err)
+	}
+}
+
+func TestOpenWithConfigRetries(t *testing.T) {
+	config := &DeviceConfig{Bus: 1, Address: 0x48, MockMode: true, Retries: 2}
+	dev, err := OpenWithConfig(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dev.Close()
+	if _, ok := dev.(*chainTransferer); !ok {
+		t.Errorf("Retries 大于 0 时应按默认策略重试，得到 %T", dev)
+	}
+
+	config.Retry = &RetryPolicy{Jitter: 2}
+	if _, err := OpenWithConfig(config); err == nil {
+		t.Error("无效的重试策略应报错")
+	}
+}
@@ -0,0 +1,59 @@
+package i2c
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+)
+
+// ReadModifyWrite 对从 reg 开始的 n 个字节执行读-改-写，并回读校验写入结果
+//
+// modify 接收当前值并返回新值（长度必须为 n）。新值与当前值相同时不写入。
+// 回读结果与写入值不一致时返回错误，此时返回的 after 为实际回读值。
+func ReadModifyWrite(dev Device, reg uint8, n int, modify func(old []byte) []byte) (before, after []byte, err error) {
+	return ReadModifyWriteCtx(context.Background(), WithContext(dev), reg, n, modify)
+}
+
+// ReadModifyWriteCtx 与 ReadModifyWrite 相同，ctx 取消时中止尚未完成的读写
+//
+// dev 支持 Exclusive (共享总线的句柄) 时整个读-改-写独占总线，其他句柄的写入不会插在读和写之间。
+func ReadModifyWriteCtx(ctx context.Context, dev ContextDevice, reg uint8, n int, modify func(old []byte) []byte) (before, after []byte, err error) {
+	ex, ok := dev.(Exclusive)
+	if !ok {
+		return readModifyWrite(ctx, dev, reg, n, modify)
+	}
+	err = ex.Do(ctx, func(ctx context.Context) error {
+		before, after, err = readModifyWrite(ctx, dev, reg, n, modify)
+		return err
+	})
+	return before, after, err
+}
+
+// readModifyWrite 执行读-改-写，调用方负责独占总线
+func readModifyWrite(ctx context.Context, dev ContextDevice, reg uint8, n int, modify func(old []byte) []byte) (before, after []byte, err error) {
+	before, err = dev.ReadBytesCtx(ctx, reg, n)
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取寄存器 0x%02X 失败: %w", reg, err)
+	}
+
+	want := modify(append([]byte(nil), before...))
+	if len(want) != n {
+		return before, nil, fmt.Errorf("修改后的数据长度错误: 期望 %d，实际 %d", n, len(want))
+	}
+
+	if !bytes.Equal(want, before) {
+		if err := dev.WriteBytesCtx(ctx, reg, want); err != nil {
+			return before, nil, fmt.Errorf("写入寄存器 0x%02X 失败: %w", reg, err)
+		}
+	}
+
+	after, err = dev.ReadBytesCtx(ctx, reg, n)
+	if err != nil {
+		return before, nil, fmt.Errorf("回读寄存器 0x%02X 失败: %w", reg, err)
+	}
+
+	if !bytes.Equal(after, want) {
+		return before, after, fmt.Errorf("寄存器 0x%02X 回读校验失败: 期望 % X，实际 % X", reg, want, after)
+	}
+	return before, after, nil
+}
@@ -0,0 +1,44 @@
+package i2c
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestReadModifyWrite(t *testing.T) {
+	device := NewMockDevice(&DeviceConfig{Bus: 1, Address: 0x48, MockMode: true})
+	device.WriteBytes(0x01, []byte{0x60, 0xA0})
+
+	// 清除 bit6:5，保留其他位
+	before, after, err := ReadModifyWrite(device, 0x01, 2, func(old []byte) []byte {
+		old[0] &^= 0x60
+		old[0] |= 0x20
+		return old
+	})
+	if err != nil {
+		t.Fatalf("读-改-写失败: %v", err)
+	}
+	if !bytes.Equal(before, []byte{0x60, 0xA0}) || !bytes.Equal(after, []byte{0x20, 0xA0}) {
+		t.Errorf("期望 60A0 -> 20A0，实际 %X -> %X", before, after)
+	}
+}
+
+func TestReadModifyWriteVerify(t *testing.T) {
+	mb := newTestMockBus(t)
+	device := openTestMockDevice(t, mb, 0x48)
+
+	// 只读寄存器忽略写入，回读校验应失败
+	_, after, err := ReadModifyWrite(device, 0x00, 1, func(old []byte) []byte {
+		return []byte{old[0] ^ 0xFF}
+	})
+	if err == nil {
+		t.Fatal("只读寄存器的回读校验应该失败")
+	}
+	if len(after) != 1 || after[0] != 0x19 {
+		t.Errorf("期望回读值 0x19，实际 %X", after)
+	}
+
+	if _, _, err := ReadModifyWrite(device, 0x01, 1, func(old []byte) []byte { return nil }); err == nil {
+		t.Error("修改后长度错误应该失败")
+	}
+}
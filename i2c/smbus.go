@@ -0,0 +1,250 @@
+package i2c
+
+import (
+	"context"
+	"fmt"
+)
+
+// SMBusBlockMax SMBus 块传输的最大数据长度
+const SMBusBlockMax = 32
+
+// pecSetter 可以模拟PEC的设备（如模拟设备）
+type pecSetter interface {
+	SetPEC(enabled bool)
+}
+
+// SMBus 基于原始I2C组合传输实现的SMBus协议命令集
+type SMBus struct {
+	dev  Device
+	tr   Transferer
+	addr uint16
+	pec  bool
+	ctx  context.Context
+}
+
+// NewSMBus 在设备之上创建SMBus访问器，设备必须支持原始传输
+func NewSMBus(dev Device) (*SMBus, error) {
+	tr, ok := dev.(Transferer)
+	if !ok {
+		return nil, fmt.Errorf("设备不支持原始I2C传输，无法执行SMBus命令")
+	}
+
+	return &SMBus{
+		dev:  dev,
+		tr:   tr,
+		addr: uint16(dev.GetAddress()),
+		ctx:  context.Background(),
+	}, nil
+}
+
+// WithContext 返回使用 ctx 执行所有命令的副本，ctx 取消时中止尚未完成的传输
+func (bus *SMBus) WithContext(ctx context.Context) *SMBus {
+	b := *bus
+	b.ctx = ctx
+	return &b
+}
+
+// SetPEC 启用或关闭包错误校验（CRC-8），校验码在软件中计算和验证
+func (bus *SMBus) SetPEC(enabled bool) {
+	bus.pec = enabled
+	if s, ok := bus.dev.(pecSetter); ok {
+		s.SetPEC(enabled)
+	}
+}
+
+// PEC 是否启用包错误校验
+func (bus *SMBus) PEC() bool {
+	return bus.pec
+}
+
+// QuickCommand 快速命令，仅发送地址和读写位
+func (bus *SMBus) QuickCommand(read bool) error {
+	msg := Msg{Addr: bus.addr}
+	if read {
+		msg.Flags = MsgRead
+	}
+	return transferCtx(bus.ctx, bus.tr, []Msg{msg})
+}
+
+// SendByte 发送字节
+func (bus *SMBus) SendByte(value uint8) error {
+	return bus.write([]byte{value})
+}
+
+// ReceiveByte 接收字节
+func (bus *SMBus) ReceiveByte() (uint8, error) {
+	data, err := bus.read(nil, 1)
+	if err != nil {
+		return 0, err
+	}
+	return data[0], nil
+}
+
+// ReadByteData 读字节数据
+func (bus *SMBus) ReadByteData(cmd uint8) (uint8, error) {
+	data, err := bus.read([]byte{cmd}, 1)
+	if err != nil {
+		return 0, err
+	}
+	return data[0], nil
+}
+
+// WriteByteData 写字节数据
+func (bus *SMBus) WriteByteData(cmd, value uint8) error {
+	return bus.write([]byte{cmd, value})
+}
+
+// ReadWordData 读字数据（低字节在前）
+func (bus *SMBus) ReadWordData(cmd uint8) (uint16, error) {
+	data, err := bus.read([]byte{cmd}, 2)
+	if err != nil {
+		return 0, err
+	}
+	return uint16(data[0]) | uint16(data[1])<<8, nil
+}
+
+// WriteWordData 写字数据（低字节在前）
+func (bus *SMBus) WriteWordData(cmd uint8, value uint16) error {
+	return bus.write([]byte{cmd, uint8(value), uint8(value >> 8)})
+}
+
+// ReadBlockData 块读取，返回从设备给出的数据（不含长度字节）
+func (bus *SMBus) ReadBlockData(cmd uint8) ([]byte, error) {
+	return bus.readBlock([]byte{cmd})
+}
+
+// WriteBlockData 块写入
+func (bus *SMBus) WriteBlockData(cmd uint8, data []byte) error {
+	if len(data) == 0 || len(data) > SMBusBlockMax {
+		return fmt.Errorf("无效的块长度: %d (有效范围: 1-%d)", len(data), SMBusBlockMax)
+	}
+
+	buf := make([]byte, 0, len(data)+2)
+	buf = append(buf, cmd, uint8(len(data)))
+	buf = append(buf, data...)
+	return bus.write(buf)
+}
+
+// ProcessCall 过程调用，写入一个字并读回一个字
+func (bus *SMBus) ProcessCall(cmd uint8, value uint16) (uint16, error) {
+	data, err := bus.read([]byte{cmd, uint8(value), uint8(value >> 8)}, 2)
+	if err != nil {
+		return 0, err
+	}
+	return uint16(data[0]) | uint16(data[1])<<8, nil
+}
+
+// BlockProcessCall 块过程调用，写入一个数据块并读回一个数据块
+func (bus *SMBus) BlockProcessCall(cmd uint8, data []byte) ([]byte, error) {
+	if len(data) == 0 || len(data) > SMBusBlockMax {
+		return nil, fmt.Errorf("无效的块长度: %d (有效范围: 1-%d)", len(data), SMBusBlockMax)
+	}
+
+	buf := make([]byte, 0, len(data)+2)
+	buf = append(buf, cmd, uint8(len(data)))
+	buf = append(buf, data...)
+	return bus.readBlock(buf)
+}
+
+// write 执行一次纯写传输，启用PEC时追加校验码
+func (bus *SMBus) write(buf []byte) error {
+	if bus.pec {
+		crc := crc8(0, []byte{uint8(bus.addr << 1)})
+		buf = append(buf, crc8(crc, buf))
+	}
+
+	if err := transferCtx(bus.ctx, bus.tr, []Msg{{Addr: bus.addr, Buf: buf}}); err != nil {
+		return fmt.Errorf("SMBus 写入设备 0x%02X 失败: %w", bus.addr, err)
+	}
+	return nil
+}
+
+// read 先写入 wr（可为空）再读取 count 个字节，启用PEC时校验末尾的校验码
+func (bus *SMBus) read(wr []byte, count int) ([]byte, error) {
+	pecLen := 0
+	if bus.pec {
+		pecLen = 1
+	}
+
+	msgs := make([]Msg, 0, 2)
+	if len(wr) > 0 {
+		msgs = append(msgs, Msg{Addr: bus.addr, Buf: wr})
+	}
+	msgs = append(msgs, Msg{Addr: bus.addr, Flags: MsgRead, Buf: make([]byte, count+pecLen)})
+
+	if err := transferCtx(bus.ctx, bus.tr, msgs); err != nil {
+		return nil, fmt.Errorf("SMBus 读取设备 0x%02X 失败: %w", bus.addr, err)
+	}
+
+	rd := msgs[len(msgs)-1].Buf
+	if bus.pec {
+		if err := bus.checkPEC(msgs); err != nil {
+			return nil, err
+		}
+	}
+	return rd[:count], nil
+}
+
+// readBlock 先写入 wr 再执行块读取
+func (bus *SMBus) readBlock(wr []byte) ([]byte, error) {
+	pecLen := 0
+	if bus.pec {
+		pecLen = 1
+	}
+
+	buf := make([]byte, 1+SMBusBlockMax+pecLen)
+	buf[0] = uint8(1 + pecLen)
+	msgs := []Msg{
+		{Addr: bus.addr, Buf: wr},
+		{Addr: bus.addr, Flags: MsgRead | MsgRecvLen, Buf: buf},
+	}
+
+	if err := transferCtx(bus.ctx, bus.tr, msgs); err != nil {
+		return nil, fmt.Errorf("SMBus 块读取设备 0x%02X 失败: %w", bus.addr, err)
+	}
+
+	rd := msgs[1].Buf
+	if len(rd) < 1+pecLen {
+		return nil, fmt.Errorf("SMBus 块读取返回数据过短")
+	}
+
+	count := int(rd[0])
+	if count > SMBusBlockMax || len(rd) != 1+count+pecLen {
+		return nil, fmt.Errorf("SMBus 块读取返回无效长度: %d", count)
+	}
+
+	if bus.pec {
+		if err := bus.checkPEC(msgs); err != nil {
+			return nil, err
+		}
+	}
+
+	data := make([]byte, count)
+	copy(data, rd[1:1+count])
+	return data, nil
+}
+
+// checkPEC 校验读传输末尾的PEC字节
+func (bus *SMBus) checkPEC(msgs []Msg) error {
+	last := msgs[len(msgs)-1].Buf
+	expected := pecOfMsgs(msgs, 1)
+	if got := last[len(last)-1]; got != expected {
+		return fmt.Errorf("PEC 校验失败: 期望 0x%02X，实际 0x%02X", expected, got)
+	}
+	return nil
+}
+
+// crc8 计算SMBus PEC使用的 CRC-8（多项式 x^8+x^2+x+1）
+func crc8(crc uint8, data []byte) uint8 {
+	for _, b := range data {
+		crc ^= b
+		for i := 0; i < 8; i++ {
+			if crc&0x80 != 0 {
+				crc = crc<<1 ^ 0x07
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
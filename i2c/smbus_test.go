@@ -0,0 +1,148 @@
+package i2c
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func newTestSMBus(t *testing.T) (*SMBus, *MockDevice) {
+	t.Helper()
+
+	device := NewMockDevice(&DeviceConfig{
+		Bus:      1,
+		Address:  0x0B,
+		Timeout:  1 * time.Second,
+		MockMode: true,
+	})
+
+	bus, err := NewSMBus(device)
+	if err != nil {
+		t.Fatalf("创建SMBus失败: %v", err)
+	}
+	return bus, device
+}
+
+func TestCRC8(t *testing.T) {
+	// CRC-8 (多项式 0x07) 的标准校验值
+	if crc := crc8(0, []byte("123456789")); crc != 0xF4 {
+		t.Errorf("期望 CRC 0xF4，实际 0x%02X", crc)
+	}
+}
+
+func TestSMBusByteAndWord(t *testing.T) {
+	bus, _ := newTestSMBus(t)
+
+	if err := bus.WriteByteData(0x10, 0x5A); err != nil {
+		t.Fatalf("写字节数据失败: %v", err)
+	}
+	value, err := bus.ReadByteData(0x10)
+	if err != nil {
+		t.Fatalf("读字节数据失败: %v", err)
+	}
+	if value != 0x5A {
+		t.Errorf("期望 0x5A，实际 0x%02X", value)
+	}
+
+	if err := bus.WriteWordData(0x20, 0x1234); err != nil {
+		t.Fatalf("写字数据失败: %v", err)
+	}
+	word, err := bus.ReadWordData(0x20)
+	if err != nil {
+		t.Fatalf("读字数据失败: %v", err)
+	}
+	if word != 0x1234 {
+		t.Errorf("期望 0x1234，实际 0x%04X", word)
+	}
+
+	// 发送字节设置寄存器指针，接收字节从指针处读取
+	if err := bus.SendByte(0x10); err != nil {
+		t.Fatalf("发送字节失败: %v", err)
+	}
+	value, err = bus.ReceiveByte()
+	if err != nil {
+		t.Fatalf("接收字节失败: %v", err)
+	}
+	if value != 0x5A {
+		t.Errorf("期望 0x5A，实际 0x%02X", value)
+	}
+
+	if err := bus.QuickCommand(false); err != nil {
+		t.Errorf("快速命令失败: %v", err)
+	}
+}
+
+func TestSMBusBlockAndProcessCall(t *testing.T) {
+	bus, _ := newTestSMBus(t)
+
+	block := []byte{0x01, 0x02, 0x03, 0x04, 0x05}
+	if err := bus.WriteBlockData(0x40, block); err != nil {
+		t.Fatalf("块写入失败: %v", err)
+	}
+	data, err := bus.ReadBlockData(0x40)
+	if err != nil {
+		t.Fatalf("块读取失败: %v", err)
+	}
+	if !bytes.Equal(data, block) {
+		t.Errorf("期望 %X，实际 %X", block, data)
+	}
+
+	// 模拟设备的过程调用回显写入的数据
+	word, err := bus.ProcessCall(0x50, 0xBEEF)
+	if err != nil {
+		t.Fatalf("过程调用失败: %v", err)
+	}
+	if word != 0xBEEF {
+		t.Errorf("期望 0xBEEF，实际 0x%04X", word)
+	}
+
+	data, err = bus.BlockProcessCall(0x60, []byte{0xAA, 0xBB})
+	if err != nil {
+		t.Fatalf("块过程调用失败: %v", err)
+	}
+	if !bytes.Equal(data, []byte{0xAA, 0xBB}) {
+		t.Errorf("期望 AABB，实际 %X", data)
+	}
+
+	if err := bus.WriteBlockData(0x40, make([]byte, SMBusBlockMax+1)); err == nil {
+		t.Error("超长块写入应该失败")
+	}
+}
+
+func TestSMBusPEC(t *testing.T) {
+	bus, device := newTestSMBus(t)
+	bus.SetPEC(true)
+
+	if err := bus.WriteWordData(0x08, 0xCAFE); err != nil {
+		t.Fatalf("带PEC写字数据失败: %v", err)
+	}
+	// PEC 字节不应写入寄存器
+	if regs := device.GetRegisters(); regs[0x0A] != 0 {
+		t.Errorf("PEC 字节不应写入寄存器，实际 0x%02X", regs[0x0A])
+	}
+
+	word, err := bus.ReadWordData(0x08)
+	if err != nil {
+		t.Fatalf("带PEC读字数据失败: %v", err)
+	}
+	if word != 0xCAFE {
+		t.Errorf("期望 0xCAFE，实际 0x%04X", word)
+	}
+
+	if err := bus.WriteBlockData(0x30, []byte{0x10, 0x20}); err != nil {
+		t.Fatalf("带PEC块写入失败: %v", err)
+	}
+	data, err := bus.ReadBlockData(0x30)
+	if err != nil {
+		t.Fatalf("带PEC块读取失败: %v", err)
+	}
+	if !bytes.Equal(data, []byte{0x10, 0x20}) {
+		t.Errorf("期望 1020，实际 %X", data)
+	}
+
+	// 设备不产生PEC时应检测到校验失败
+	device.SetPEC(false)
+	if _, err := bus.ReadByteData(0x08); err == nil {
+		t.Error("PEC 不匹配时读取应该失败")
+	}
+}
@@ -0,0 +1,68 @@
+package i2c
+
+import (
+	"context"
+	"time"
+)
+
+// Transaction 一次总线事务: 从起始条件到停止条件之间的所有消息
+type Transaction struct {
+	Bus   int
+	Start time.Time
+
+	// Elapsed 事务耗时
+	Elapsed time.Duration
+
+	// Msgs 按总线上的顺序排列，读消息的 Buf 为读回的数据，
+	// 第二条及之后的消息以重复起始条件开始
+	Msgs []Msg
+
+	// Err 事务失败 (如地址或数据未应答) 时的错误
+	Err error
+}
+
+// Tracer 接收设备上完成的每个事务
+type Tracer interface {
+	Trace(tx Transaction)
+}
+
+// Tracing 把每次设备操作按总线上的消息记录为事务的中间件，
+// 寄存器读取表示为 写寄存器地址 + 重复起始后的读消息
+func Tracing(tracer Tracer) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, op *Op) ([]byte, error) {
+			start := time.Now()
+			data, err := next(ctx, op)
+			tx := Transaction{Bus: op.Bus, Start: start, Elapsed: time.Since(start), Err: err}
+
+			addr := uint16(op.Addr)
+			switch op.Kind {
+			case OpRead:
+				tx.Msgs = []Msg{
+					{Addr: addr, Buf: []byte{op.Reg}},
+					{Addr: addr, Flags: MsgRead, Buf: append([]byte(nil), data...)},
+				}
+			case OpWrite:
+				tx.Msgs = []Msg{{Addr: addr, Buf: append([]byte{op.Reg}, op.Data...)}}
+			default:
+				// 复制消息，调用方之后可能复用缓冲区
+				tx.Msgs = make([]Msg, len(op.Msgs))
+				for i, msg := range op.Msgs {
+					buf := msg.Buf
+					if msg.Flags&MsgRead != 0 && err != nil {
+						buf = nil
+					}
+					tx.Msgs[i] = Msg{Addr: msg.Addr, Flags: msg.Flags, Buf: append([]byte(nil), buf...)}
+				}
+			}
+			tracer.Trace(tx)
+			return data, err
+		}
+	}
+}
+
+// NewTracingDevice 返回把所有操作交给 tracer 记录的设备，
+// 底层设备支持原始传输时返回的设备也支持
+func NewTracingDevice(dev Device, tracer Tracer) Device {
+	return Chain(dev, Tracing(tracer))
+}
@@ -1,9 +1,13 @@
 package logger
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"os"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -24,85 +28,238 @@ var levelNames = map[Level]string{
 	ERROR: "ERROR",
 }
 
-// Logger 日志记录器
-type Logger struct {
-	level  Level
-	logger *log.Logger
+// ParseLevel 解析日志级别名称 (debug, info, warn, error)，不区分大小写
+func ParseLevel(name string) (Level, error) {
+	for level, levelName := range levelNames {
+		if strings.EqualFold(name, levelName) {
+			return level, nil
+		}
+	}
+	return INFO, fmt.Errorf("无效的日志级别: %s", name)
 }
 
-var defaultLogger *Logger
+// ParseSubsystemLevels 解析 "i2c=debug,scan=warn" 形式的子系统日志级别
+func ParseSubsystemLevels(s string) (map[string]Level, error) {
+	levels := make(map[string]Level)
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, levelName, ok := strings.Cut(item, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("无效的子系统日志级别: %q (格式为 子系统=级别)", item)
+		}
+		level, err := ParseLevel(strings.TrimSpace(levelName))
+		if err != nil {
+			return nil, err
+		}
+		levels[name] = level
+	}
+	return levels, nil
+}
 
-// Init 初始化默认日志记录器
-func Init(level Level, logFile string) error {
-	var output *os.File
-	var err error
+// slogLevel 转换为 slog 的级别
+func (l Level) slogLevel() slog.Level {
+	switch l {
+	case DEBUG:
+		return slog.LevelDebug
+	case WARN:
+		return slog.LevelWarn
+	case ERROR:
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// 日志格式
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Options 日志输出设置
+type Options struct {
+	// Level 默认日志级别
+	Level Level
+
+	// Subsystems 按子系统覆盖日志级别，如 {"i2c": DEBUG}
+	Subsystems map[string]Level
+
+	// Format 输出格式 (text, json)，默认 text
+	Format string
+
+	// File 日志文件路径，为空时写入标准错误，不影响标准输出中的数据
+	File string
 
-	if logFile != "" {
-		output, err = os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	// MaxSize 单个日志文件的最大字节数，超过后轮转，0 表示不轮转
+	MaxSize int64
+
+	// MaxFiles 轮转时保留的旧文件数
+	MaxFiles int
+}
+
+var (
+	mu      sync.RWMutex
+	handler slog.Handler = newHandler(os.Stderr, FormatText)
+	output  io.Closer
+	levels  = &levelSet{def: slog.LevelInfo}
+)
+
+// Setup 按设置重新配置日志输出，之前打开的日志文件会被关闭
+func Setup(opts Options) error {
+	switch opts.Format {
+	case "", FormatText, FormatJSON:
+	default:
+		return fmt.Errorf("无效的日志格式: %s", opts.Format)
+	}
+
+	var w io.Writer = os.Stderr
+	var closer io.Closer
+	if opts.File != "" {
+		f, err := OpenRotatingFile(opts.File, opts.MaxSize, opts.MaxFiles)
 		if err != nil {
-			return fmt.Errorf("打开日志文件失败: %v", err)
+			return err
 		}
-	} else {
-		output = os.Stdout
+		w, closer = f, f
 	}
 
-	defaultLogger = &Logger{
-		level:  level,
-		logger: log.New(output, "", log.LstdFlags),
+	mu.Lock()
+	if output != nil {
+		output.Close()
 	}
+	handler, output = newHandler(w, opts.Format), closer
+	mu.Unlock()
 
+	levels.set(opts.Level, opts.Subsystems)
 	return nil
 }
 
-// SetLevel 设置日志级别
+// Init 初始化默认日志记录器，logFile 为空时写入标准错误
+func Init(level Level, logFile string) error {
+	return Setup(Options{Level: level, File: logFile})
+}
+
+// Close 关闭日志文件
+func Close() error {
+	mu.Lock()
+	defer mu.Unlock()
+	if output == nil {
+		return nil
+	}
+	err := output.Close()
+	handler, output = newHandler(os.Stderr, FormatText), nil
+	return err
+}
+
+// SetLevel 设置默认日志级别，子系统级别不变
 func SetLevel(level Level) {
-	if defaultLogger != nil {
-		defaultLogger.level = level
+	levels.setDefault(level)
+}
+
+// newHandler 创建不做级别过滤的处理器，级别由 For 返回的记录器按子系统判断
+func newHandler(w io.Writer, format string) slog.Handler {
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
+	if format == FormatJSON {
+		return slog.NewJSONHandler(w, opts)
 	}
+	return slog.NewTextHandler(w, opts)
 }
 
-// log 内部日志记录方法
-func (l *Logger) log(level Level, format string, args ...interface{}) {
-	if level < l.level {
-		return
+// For 返回指定子系统的结构化记录器，每条记录带有 subsystem 属性
+func For(subsystem string) *slog.Logger {
+	mu.RLock()
+	h := handler
+	mu.RUnlock()
+	return slog.New(&subsystemHandler{
+		Handler:   h.WithAttrs([]slog.Attr{slog.String("subsystem", subsystem)}),
+		subsystem: subsystem,
+	})
+}
+
+// subsystemHandler 按子系统的日志级别过滤记录
+type subsystemHandler struct {
+	slog.Handler
+	subsystem string
+}
+
+func (h *subsystemHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= levels.level(h.subsystem)
+}
+
+func (h *subsystemHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &subsystemHandler{Handler: h.Handler.WithAttrs(attrs), subsystem: h.subsystem}
+}
+
+func (h *subsystemHandler) WithGroup(name string) slog.Handler {
+	return &subsystemHandler{Handler: h.Handler.WithGroup(name), subsystem: h.subsystem}
+}
+
+// levelSet 默认日志级别和各子系统的日志级别
+type levelSet struct {
+	mu   sync.RWMutex
+	def  slog.Level
+	subs map[string]slog.Level
+}
+
+func (s *levelSet) level(subsystem string) slog.Level {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if l, ok := s.subs[subsystem]; ok {
+		return l
 	}
+	return s.def
+}
 
-	timestamp := time.Now().Format("2006-01-02 15:04:05")
-	message := fmt.Sprintf(format, args...)
-	logMessage := fmt.Sprintf("[%s] %s: %s", timestamp, levelNames[level], message)
+func (s *levelSet) set(def Level, subsystems map[string]Level) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.def = def.slogLevel()
+	s.subs = make(map[string]slog.Level, len(subsystems))
+	for name, l := range subsystems {
+		s.subs[name] = l.slogLevel()
+	}
+}
 
-	l.logger.Println(logMessage)
+func (s *levelSet) setDefault(def Level) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.def = def.slogLevel()
 }
 
+// app 是 Debug/Info/Warn/Error 使用的子系统
+const app = "app"
+
 // Debug 调试日志
 func Debug(format string, args ...interface{}) {
-	if defaultLogger != nil {
-		defaultLogger.log(DEBUG, format, args...)
-	}
+	logf(slog.LevelDebug, format, args...)
 }
 
 // Info 信息日志
 func Info(format string, args ...interface{}) {
-	if defaultLogger != nil {
-		defaultLogger.log(INFO, format, args...)
-	}
+	logf(slog.LevelInfo, format, args...)
 }
 
 // Warn 警告日志
 func Warn(format string, args ...interface{}) {
-	if defaultLogger != nil {
-		defaultLogger.log(WARN, format, args...)
-	}
+	logf(slog.LevelWarn, format, args...)
 }
 
 // Error 错误日志
 func Error(format string, args ...interface{}) {
-	if defaultLogger != nil {
-		defaultLogger.log(ERROR, format, args...)
+	logf(slog.LevelError, format, args...)
+}
+
+func logf(level slog.Level, format string, args ...interface{}) {
+	l := For(app)
+	if l.Enabled(context.Background(), level) {
+		l.Log(context.Background(), level, fmt.Sprintf(format, args...))
 	}
 }
 
-// DeviceLogger 设备操作日志记录器
+// DeviceLogger 设备操作日志记录器，记录到 i2c 子系统
 type DeviceLogger struct {
 	deviceAddr uint8
 	bus        int
@@ -116,29 +273,79 @@ func NewDeviceLogger(bus int, addr uint8) *DeviceLogger {
 	}
 }
 
-// LogRead 记录读取操作
-func (dl *DeviceLogger) LogRead(reg uint8, value uint8, err error) {
-	if err != nil {
-		Error("设备 0x%02X (总线 %d) 读取寄存器 0x%02X 失败: %v", dl.deviceAddr, dl.bus, reg, err)
-	} else {
-		Debug("设备 0x%02X (总线 %d) 读取寄存器 0x%02X: 0x%02X", dl.deviceAddr, dl.bus, reg, value)
+// LogRead 记录读取操作，失败时为警告级别
+func (dl *DeviceLogger) LogRead(reg uint8, data []byte, elapsed time.Duration, err error) {
+	dl.logOp("读取寄存器", reg, data, elapsed, err)
+}
+
+// LogWrite 记录写入操作，失败时为警告级别
+func (dl *DeviceLogger) LogWrite(reg uint8, data []byte, elapsed time.Duration, err error) {
+	dl.logOp("写入寄存器", reg, data, elapsed, err)
+}
+
+// LogTransfer 记录一次原始组合传输，written 和 read 分别为写出和读回的数据
+func (dl *DeviceLogger) LogTransfer(msgs int, written, read []byte, elapsed time.Duration, err error) {
+	dl.log(err, "组合传输",
+		slog.Int("msgs", msgs),
+		slog.String("written", hexBytes(written)),
+		slog.String("value", hexBytes(read)),
+		slog.Duration("latency", elapsed))
+}
+
+// LogRetry 记录一次重试，op 为 read、write 或 transfer，reg 为 -1 表示组合传输
+func (dl *DeviceLogger) LogRetry(op string, reg, retry int, delay time.Duration, err error) {
+	attrs := []slog.Attr{
+		slog.Int("bus", dl.bus),
+		slog.String("addr", fmt.Sprintf("0x%02X", dl.deviceAddr)),
+		slog.String("op", op),
 	}
+	if reg >= 0 {
+		attrs = append(attrs, slog.String("reg", fmt.Sprintf("0x%02X", reg)))
+	}
+	attrs = append(attrs,
+		slog.Int("retry", retry),
+		slog.Duration("delay", delay),
+		slog.String("error", err.Error()))
+	For("i2c").LogAttrs(context.Background(), slog.LevelInfo, "重试操作", attrs...)
+}
+
+func (dl *DeviceLogger) logOp(msg string, reg uint8, data []byte, elapsed time.Duration, err error) {
+	dl.log(err, msg,
+		slog.String("reg", fmt.Sprintf("0x%02X", reg)),
+		slog.String("value", hexBytes(data)),
+		slog.Duration("latency", elapsed))
 }
 
-// LogWrite 记录写入操作
-func (dl *DeviceLogger) LogWrite(reg uint8, value uint8, err error) {
+func (dl *DeviceLogger) log(err error, msg string, attrs ...slog.Attr) {
+	level := slog.LevelDebug
 	if err != nil {
-		Error("设备 0x%02X (总线 %d) 写入寄存器 0x%02X 失败: %v", dl.deviceAddr, dl.bus, reg, err)
-	} else {
-		Debug("设备 0x%02X (总线 %d) 写入寄存器 0x%02X: 0x%02X", dl.deviceAddr, dl.bus, reg, value)
+		level = slog.LevelWarn
+		msg += "失败"
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+
+	l := For("i2c")
+	if !l.Enabled(context.Background(), level) {
+		return
 	}
+	attrs = append([]slog.Attr{
+		slog.Int("bus", dl.bus),
+		slog.String("addr", fmt.Sprintf("0x%02X", dl.deviceAddr)),
+	}, attrs...)
+	l.LogAttrs(context.Background(), level, msg, attrs...)
 }
 
 // LogScan 记录扫描操作
 func (dl *DeviceLogger) LogScan(found bool) {
+	level, msg := slog.LevelDebug, "扫描地址无设备"
 	if found {
-		Info("扫描发现设备: 0x%02X (总线 %d)", dl.deviceAddr, dl.bus)
-	} else {
-		Debug("扫描地址 0x%02X (总线 %d): 无设备", dl.deviceAddr, dl.bus)
+		level, msg = slog.LevelInfo, "扫描发现设备"
 	}
+	For("scan").LogAttrs(context.Background(), level, msg,
+		slog.Int("bus", dl.bus),
+		slog.String("addr", fmt.Sprintf("0x%02X", dl.deviceAddr)))
+}
+
+func hexBytes(data []byte) string {
+	return fmt.Sprintf("% X", data)
 }
@@ -0,0 +1,123 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func readLog(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestSubsystemLevels(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sensorcli.log")
+	err := Setup(Options{
+		Level:      WARN,
+		Subsystems: map[string]Level{"i2c": DEBUG},
+		File:       path,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Close()
+
+	Info("应被过滤 %d", 1)
+	Warn("保留 %d", 2)
+	NewDeviceLogger(1, 0x48).LogRead(0x00, []byte{0x19}, 0, nil)
+	NewDeviceLogger(1, 0x48).LogScan(true)
+
+	log := readLog(t, path)
+	if strings.Contains(log, "应被过滤") {
+		t.Errorf("INFO 日志应被过滤:\n%s", log)
+	}
+	for _, want := range []string{
+		`msg="保留 2" subsystem=app`,
+		`msg=读取寄存器 subsystem=i2c bus=1 addr=0x48 reg=0x00 value=19`,
+	} {
+		if !strings.Contains(log, want) {
+			t.Errorf("日志中缺少 %q:\n%s", want, log)
+		}
+	}
+	if strings.Contains(log, "扫描发现设备") {
+		t.Errorf("scan 子系统使用默认级别 WARN，INFO 应被过滤:\n%s", log)
+	}
+}
+
+func TestSetupInvalidFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sensorcli.log")
+	if err := Setup(Options{Level: INFO, File: path, Format: "xml"}); err == nil {
+		t.Fatal("无效的日志格式应报错")
+	}
+	// 格式无效时不应打开日志文件
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("格式无效时不应创建日志文件: %v", err)
+	}
+}
+
+func TestParseSubsystemLevels(t *testing.T) {
+	levels, err := ParseSubsystemLevels("i2c=debug, scan=WARN")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if levels["i2c"] != DEBUG || levels["scan"] != WARN || len(levels) != 2 {
+		t.Errorf("levels = %v", levels)
+	}
+	for _, bad := range []string{"i2c", "i2c=loud", "=debug"} {
+		if _, err := ParseSubsystemLevels(bad); err == nil {
+			t.Errorf("%q 应当报错", bad)
+		}
+	}
+}
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rotate.log")
+	rf, err := OpenRotatingFile(path, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rf.Close()
+
+	for _, line := range []string{"aaaaaaaa\n", "bbbbbbbb\n", "cccccccc\n", "dddddddd\n"} {
+		if _, err := rf.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 只保留两个旧文件，最早的 aaa 被删除
+	for name, want := range map[string]string{
+		path:        "dddddddd\n",
+		path + ".1": "cccccccc\n",
+		path + ".2": "bbbbbbbb\n",
+	} {
+		if got := readLog(t, name); got != want {
+			t.Errorf("%s = %q，期望 %q", filepath.Base(name), got, want)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("超出保留数量的文件应被删除")
+	}
+}
+
+func TestRotatingFileAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "append.log")
+	if err := os.WriteFile(path, []byte("old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rf, err := OpenRotatingFile(path, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rf.Write([]byte("new\n"))
+	rf.Close()
+
+	if got := readLog(t, path); got != "old\nnew\n" {
+		t.Errorf("不轮转时应追加写入: %q", got)
+	}
+}
@@ -0,0 +1,99 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// RotatingFile 按大小轮转的日志文件
+//
+// 写入后超过 MaxSize 时，当前文件改名为 name.1，原有的 name.1 改为 name.2，
+// 依此类推，只保留 MaxFiles 个旧文件。
+type RotatingFile struct {
+	mu       sync.Mutex
+	path     string
+	maxSize  int64
+	maxFiles int
+	file     *os.File
+	size     int64
+}
+
+// OpenRotatingFile 以追加方式打开日志文件，maxSize 为 0 时不轮转
+func OpenRotatingFile(path string, maxSize int64, maxFiles int) (*RotatingFile, error) {
+	rf := &RotatingFile{path: path, maxSize: maxSize, maxFiles: maxFiles}
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+	return rf, nil
+}
+
+func (rf *RotatingFile) open() error {
+	f, err := os.OpenFile(rf.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		return fmt.Errorf("打开日志文件失败: %v", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("打开日志文件失败: %v", err)
+	}
+	rf.file, rf.size = f, info.Size()
+	return nil
+}
+
+// Write 写入一条日志，超过大小限制时先轮转，单条日志不会被拆到两个文件中
+func (rf *RotatingFile) Write(p []byte) (int, error) {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if rf.file == nil {
+		return 0, fmt.Errorf("日志文件已关闭")
+	}
+	if rf.maxSize > 0 && rf.size > 0 && rf.size+int64(len(p)) > rf.maxSize {
+		if err := rf.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := rf.file.Write(p)
+	rf.size += int64(n)
+	return n, err
+}
+
+// rotate 依次后移旧文件，超出保留数量的文件被删除
+func (rf *RotatingFile) rotate() error {
+	if err := rf.file.Close(); err != nil {
+		return fmt.Errorf("关闭日志文件失败: %v", err)
+	}
+	rf.file = nil
+
+	if rf.maxFiles <= 0 {
+		os.Remove(rf.path)
+	} else {
+		os.Remove(rf.backup(rf.maxFiles))
+		for i := rf.maxFiles - 1; i >= 1; i-- {
+			os.Rename(rf.backup(i), rf.backup(i+1))
+		}
+		if err := os.Rename(rf.path, rf.backup(1)); err != nil {
+			return fmt.Errorf("轮转日志文件失败: %v", err)
+		}
+	}
+	return rf.open()
+}
+
+func (rf *RotatingFile) backup(n int) string {
+	return fmt.Sprintf("%s.%d", rf.path, n)
+}
+
+// Close 关闭日志文件
+func (rf *RotatingFile) Close() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	if rf.file == nil {
+		return nil
+	}
+	err := rf.file.Close()
+	rf.file = nil
+	return err
+}
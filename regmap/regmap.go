@@ -0,0 +1,335 @@
+package regmap
+
+import (
+	"embed"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed maps/*.yaml
+var builtinMaps embed.FS
+
+// 寄存器访问模式
+const (
+	AccessReadWrite = "rw"
+	AccessReadOnly  = "ro"
+	AccessWriteOnly = "wo"
+)
+
+// Field 寄存器中的位域
+type Field struct {
+	Name        string         `yaml:"name"`
+	Bits        string         `yaml:"bits"` // "7:5" 或 "0"
+	Description string         `yaml:"description,omitempty"`
+	Enum        map[int]string `yaml:"enum,omitempty"`
+
+	msb int
+	lsb int
+}
+
+// Register 寄存器定义
+type Register struct {
+	Name        string  `yaml:"name"`
+	Address     uint8   `yaml:"address"`
+	Width       int     `yaml:"width,omitempty"`  // 位宽，8/16/24/32，默认 8
+	Endian      string  `yaml:"endian,omitempty"` // 多字节寄存器的字节序，big 或 little，默认 big
+	Access      string  `yaml:"access,omitempty"` // rw, ro, wo，默认 rw
+	Description string  `yaml:"description,omitempty"`
+	Fields      []Field `yaml:"fields,omitempty"`
+}
+
+// Map 设备寄存器映射
+type Map struct {
+	Name        string     `yaml:"name"`
+	Description string     `yaml:"description,omitempty"`
+	Registers   []Register `yaml:"registers"`
+
+	byAddr map[uint8]*Register
+}
+
+// FieldValue 解码后的位域
+type FieldValue struct {
+	Name    string `json:"name"`
+	Bits    string `json:"bits"`
+	Value   uint64 `json:"value"`
+	Meaning string `json:"meaning,omitempty"`
+}
+
+// Decoded 解码后的寄存器
+type Decoded struct {
+	Register string       `json:"register"`
+	Address  uint8        `json:"address"`
+	Value    uint64       `json:"value"`
+	Fields   []FieldValue `json:"fields,omitempty"`
+}
+
+// ParseBits 解析位范围，支持 "7:5"、"5:7" 和单个位 "3"，返回 msb 和 lsb
+func ParseBits(bits string) (int, int, error) {
+	parts := strings.Split(strings.TrimSpace(bits), ":")
+	if len(parts) > 2 {
+		return 0, 0, fmt.Errorf("无效的位范围 %q", bits)
+	}
+
+	values := make([]int, len(parts))
+	for i, part := range parts {
+		v, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || v < 0 || v > 63 {
+			return 0, 0, fmt.Errorf("无效的位范围 %q", bits)
+		}
+		values[i] = v
+	}
+
+	if len(values) == 1 {
+		return values[0], values[0], nil
+	}
+	if values[0] < values[1] {
+		return values[1], values[0], nil
+	}
+	return values[0], values[1], nil
+}
+
+// Mask 返回位范围对应的掩码
+func Mask(msb, lsb int) uint64 {
+	return (uint64(1)<<(msb-lsb+1) - 1) << lsb
+}
+
+// Parse 解析 YAML 格式的寄存器映射
+func Parse(data []byte) (*Map, error) {
+	m := &Map{}
+	if err := yaml.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("解析寄存器映射失败: %v", err)
+	}
+
+	if err := m.init(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Load 从文件加载寄存器映射
+func Load(path string) (*Map, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取寄存器映射失败: %v", err)
+	}
+	return Parse(data)
+}
+
+// Builtin 加载内置的寄存器映射
+func Builtin(name string) (*Map, error) {
+	data, err := builtinMaps.ReadFile(path.Join("maps", strings.ToLower(name)+".yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("未找到内置寄存器映射: %s", name)
+	}
+	return Parse(data)
+}
+
+// BuiltinNames 返回所有内置寄存器映射的名称
+func BuiltinNames() []string {
+	entries, _ := builtinMaps.ReadDir("maps")
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".yaml"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lookup 按内置名称或文件路径加载寄存器映射
+func Lookup(nameOrPath string) (*Map, error) {
+	if _, err := os.Stat(nameOrPath); err == nil {
+		return Load(nameOrPath)
+	}
+	return Builtin(nameOrPath)
+}
+
+// init 填充默认值、校验定义并建立索引
+func (m *Map) init() error {
+	m.byAddr = make(map[uint8]*Register)
+
+	for i := range m.Registers {
+		reg := &m.Registers[i]
+		if reg.Width == 0 {
+			reg.Width = 8
+		}
+		if reg.Endian == "" {
+			reg.Endian = "big"
+		}
+		if reg.Access == "" {
+			reg.Access = AccessReadWrite
+		}
+
+		if reg.Width != 8 && reg.Width != 16 && reg.Width != 24 && reg.Width != 32 {
+			return fmt.Errorf("寄存器 %s: 不支持的位宽 %d", reg.Name, reg.Width)
+		}
+		if reg.Endian != "big" && reg.Endian != "little" {
+			return fmt.Errorf("寄存器 %s: 无效的字节序 %q", reg.Name, reg.Endian)
+		}
+		switch reg.Access {
+		case AccessReadWrite, AccessReadOnly, AccessWriteOnly:
+		default:
+			return fmt.Errorf("寄存器 %s: 无效的访问模式 %q", reg.Name, reg.Access)
+		}
+		if int(reg.Address)+reg.Bytes() > 0x100 {
+			return fmt.Errorf("寄存器 %s: 超出地址范围", reg.Name)
+		}
+		if _, ok := m.byAddr[reg.Address]; ok {
+			return fmt.Errorf("重复的寄存器地址: 0x%02X", reg.Address)
+		}
+		m.byAddr[reg.Address] = reg
+
+		var used uint64
+		for j := range reg.Fields {
+			field := &reg.Fields[j]
+			msb, lsb, err := ParseBits(field.Bits)
+			if err != nil {
+				return fmt.Errorf("寄存器 %s 位域 %s: %v", reg.Name, field.Name, err)
+			}
+			if msb >= reg.Width {
+				return fmt.Errorf("寄存器 %s 位域 %s: 超出寄存器位宽", reg.Name, field.Name)
+			}
+			mask := Mask(msb, lsb)
+			if used&mask != 0 {
+				return fmt.Errorf("寄存器 %s 位域 %s: 与其他位域重叠", reg.Name, field.Name)
+			}
+			used |= mask
+			field.msb, field.lsb = msb, lsb
+		}
+	}
+	return nil
+}
+
+// Bytes 寄存器占用的字节数
+func (r *Register) Bytes() int {
+	return r.Width / 8
+}
+
+// Value 按字节序组合寄存器值
+func (r *Register) Value(data []byte) uint64 {
+	var value uint64
+	for i := 0; i < r.Bytes(); i++ {
+		b := data[i]
+		if r.Endian == "little" {
+			b = data[r.Bytes()-1-i]
+		}
+		value = value<<8 | uint64(b)
+	}
+	return value
+}
+
+// Encode 按字节序将寄存器值拆分为字节
+func (r *Register) Encode(value uint64) []byte {
+	data := make([]byte, r.Bytes())
+	for i := range data {
+		b := byte(value >> (8 * (len(data) - 1 - i)))
+		if r.Endian == "little" {
+			data[len(data)-1-i] = b
+		} else {
+			data[i] = b
+		}
+	}
+	return data
+}
+
+// Field 按名称查找位域（不区分大小写）
+func (r *Register) Field(name string) (*Field, bool) {
+	for i := range r.Fields {
+		if strings.EqualFold(r.Fields[i].Name, name) {
+			return &r.Fields[i], true
+		}
+	}
+	return nil, false
+}
+
+// Range 返回位域的 msb 和 lsb
+func (f *Field) Range() (int, int) {
+	return f.msb, f.lsb
+}
+
+// Extract 从寄存器值中提取位域值
+func (f *Field) Extract(value uint64) uint64 {
+	return (value & Mask(f.msb, f.lsb)) >> f.lsb
+}
+
+// Register 按地址查找寄存器
+func (m *Map) Register(addr uint8) (*Register, bool) {
+	reg, ok := m.byAddr[addr]
+	return reg, ok
+}
+
+// RegisterByName 按名称查找寄存器（不区分大小写）
+func (m *Map) RegisterByName(name string) (*Register, bool) {
+	for i := range m.Registers {
+		if strings.EqualFold(m.Registers[i].Name, name) {
+			return &m.Registers[i], true
+		}
+	}
+	return nil, false
+}
+
+// Containing 返回占用该字节地址的寄存器，多字节寄存器的任一字节都可以匹配
+func (m *Map) Containing(addr uint8) *Register {
+	for i := range m.Registers {
+		reg := &m.Registers[i]
+		if addr >= reg.Address && int(addr) < int(reg.Address)+reg.Bytes() {
+			return reg
+		}
+	}
+	return nil
+}
+
+// Decode 解码单个寄存器，data 至少包含寄存器宽度的字节数
+func (r *Register) Decode(data []byte) Decoded {
+	value := r.Value(data)
+	decoded := Decoded{
+		Register: r.Name,
+		Address:  r.Address,
+		Value:    value,
+	}
+
+	for i := range r.Fields {
+		field := &r.Fields[i]
+		fv := FieldValue{
+			Name:  field.Name,
+			Bits:  field.Bits,
+			Value: field.Extract(value),
+		}
+		if meaning, ok := field.Enum[int(fv.Value)]; ok {
+			fv.Meaning = meaning
+		}
+		decoded.Fields = append(decoded.Fields, fv)
+	}
+	return decoded
+}
+
+// DecodeBytes 解码从 start 开始连续读取的数据中完整包含的所有寄存器
+func (m *Map) DecodeBytes(start uint8, data []byte) []Decoded {
+	var result []Decoded
+	for i := range m.Registers {
+		reg := &m.Registers[i]
+		offset := int(reg.Address) - int(start)
+		if offset < 0 || offset+reg.Bytes() > len(data) {
+			continue
+		}
+		result = append(result, reg.Decode(data[offset:offset+reg.Bytes()]))
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Address < result[j].Address })
+	return result
+}
+
+// String 以 "NAME[7:5] = 3 (含义)" 形式格式化位域
+func (fv FieldValue) String() string {
+	s := fmt.Sprintf("%s[%s] = %d", fv.Name, fv.Bits, fv.Value)
+	if fv.Meaning != "" {
+		s += fmt.Sprintf(" (%s)", fv.Meaning)
+	}
+	return s
+}
@@ -0,0 +1,134 @@
+package regmap
+
+import "testing"
+
+func TestBuiltinMaps(t *testing.T) {
+	names := BuiltinNames()
+	if len(names) == 0 {
+		t.Fatal("应该存在内置寄存器映射")
+	}
+
+	for _, name := range names {
+		if _, err := Builtin(name); err != nil {
+			t.Errorf("内置寄存器映射 %s 无效: %v", name, err)
+		}
+	}
+
+	if _, err := Builtin("no-such-part"); err == nil {
+		t.Error("不存在的内置映射应该失败")
+	}
+}
+
+func TestDecode(t *testing.T) {
+	m, err := Builtin("tmp102")
+	if err != nil {
+		t.Fatalf("加载映射失败: %v", err)
+	}
+
+	decoded := m.DecodeBytes(0x01, []byte{0x60})
+	if len(decoded) != 1 || decoded[0].Register != "CONFIG" {
+		t.Fatalf("期望解码 CONFIG 寄存器，实际 %+v", decoded)
+	}
+
+	fields := make(map[string]FieldValue)
+	for _, fv := range decoded[0].Fields {
+		fields[fv.Name] = fv
+	}
+	if fv := fields["RESOLUTION"]; fv.Value != 3 || fv.Meaning != "12-bit" {
+		t.Errorf("期望 RESOLUTION=3 (12-bit)，实际 %s", fv)
+	}
+	if fv := fields["SHUTDOWN"]; fv.Value != 0 || fv.Meaning != "continuous" {
+		t.Errorf("期望 SHUTDOWN=0 (continuous)，实际 %s", fv)
+	}
+
+	// 只解码完整包含在数据中的寄存器
+	if got := len(m.DecodeBytes(0x00, []byte{0x19, 0x60, 0x4B})); got != 3 {
+		t.Errorf("期望解码 3 个寄存器，实际 %d", got)
+	}
+}
+
+func TestMultiByteRegister(t *testing.T) {
+	m, err := Parse([]byte(`
+name: test
+registers:
+  - name: BIG
+    address: 0x10
+    width: 16
+    fields:
+      - {name: HIGH, bits: "15:8"}
+      - {name: LOW, bits: "7:0"}
+  - {name: LITTLE, address: 0x12, width: 16, endian: little}
+`))
+	if err != nil {
+		t.Fatalf("解析映射失败: %v", err)
+	}
+
+	decoded := m.DecodeBytes(0x10, []byte{0x12, 0x34, 0x56, 0x78})
+	if len(decoded) != 2 {
+		t.Fatalf("期望 2 个寄存器，实际 %d", len(decoded))
+	}
+	if decoded[0].Value != 0x1234 || decoded[0].Fields[0].Value != 0x12 {
+		t.Errorf("大端寄存器解码错误: %+v", decoded[0])
+	}
+	if decoded[1].Value != 0x7856 {
+		t.Errorf("小端寄存器期望 0x7856，实际 0x%04X", decoded[1].Value)
+	}
+
+	// 编码是解码的逆操作
+	big, _ := m.Register(0x10)
+	little, _ := m.Register(0x12)
+	if got := big.Encode(0x1234); got[0] != 0x12 || got[1] != 0x34 {
+		t.Errorf("大端编码错误: %X", got)
+	}
+	if got := little.Encode(0x7856); got[0] != 0x56 || got[1] != 0x78 {
+		t.Errorf("小端编码错误: %X", got)
+	}
+
+	// 数据不完整时不解码
+	if got := len(m.DecodeBytes(0x10, []byte{0x12})); got != 0 {
+		t.Errorf("不完整的寄存器不应解码，实际 %d 个", got)
+	}
+}
+
+func TestParseBits(t *testing.T) {
+	cases := []struct {
+		bits     string
+		msb, lsb int
+	}{
+		{"7:5", 7, 5},
+		{"5:7", 7, 5},
+		{"3", 3, 3},
+	}
+	for _, c := range cases {
+		msb, lsb, err := ParseBits(c.bits)
+		if err != nil || msb != c.msb || lsb != c.lsb {
+			t.Errorf("ParseBits(%q) = %d, %d, %v", c.bits, msb, lsb, err)
+		}
+	}
+
+	for _, bits := range []string{"", "a", "1:2:3", "-1", "64"} {
+		if _, _, err := ParseBits(bits); err == nil {
+			t.Errorf("ParseBits(%q) 应该失败", bits)
+		}
+	}
+
+	if Mask(5, 4) != 0x30 {
+		t.Errorf("期望掩码 0x30，实际 0x%X", Mask(5, 4))
+	}
+}
+
+func TestInvalidMaps(t *testing.T) {
+	invalid := []string{
+		"registers: [{name: A, address: 0x00}, {name: B, address: 0x00}]",
+		"registers: [{name: A, address: 0x00, width: 12}]",
+		"registers: [{name: A, address: 0x00, access: rx}]",
+		"registers: [{name: A, address: 0x00, fields: [{name: F, bits: '8'}]}]",
+		"registers: [{name: A, address: 0x00, fields: [{name: F, bits: '3:0'}, {name: G, bits: '4:3'}]}]",
+		"registers: [{name: A, address: 0xFF, width: 16}]",
+	}
+	for _, data := range invalid {
+		if _, err := Parse([]byte(data)); err == nil {
+			t.Errorf("无效映射应该失败: %s", data)
+		}
+	}
+}
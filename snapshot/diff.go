@@ -0,0 +1,117 @@
+package snapshot
+
+import (
+	"sensorcli/regmap"
+)
+
+// 差异类型
+const (
+	Changed = "changed"
+	Added   = "added"   // 只存在于新快照
+	Removed = "removed" // 只存在于旧快照
+)
+
+// FieldChange 发生变化的位域
+type FieldChange struct {
+	Register   string `json:"register"`
+	Name       string `json:"name"`
+	Bits       string `json:"bits"`
+	Old        uint64 `json:"old"`
+	New        uint64 `json:"new"`
+	OldMeaning string `json:"old_meaning,omitempty"`
+	NewMeaning string `json:"new_meaning,omitempty"`
+}
+
+// Change 一个寄存器字节的差异
+type Change struct {
+	Addr   uint8         `json:"-"`
+	Kind   string        `json:"kind"`
+	Old    *uint8        `json:"old,omitempty"`
+	New    *uint8        `json:"new,omitempty"`
+	Fields []FieldChange `json:"fields,omitempty"`
+}
+
+// Diff 按地址对齐两个快照并返回所有差异，结果按地址升序排列
+func Diff(a, b *Snapshot) []Change {
+	var changes []Change
+
+	i, j := 0, 0
+	for i < len(a.Registers) || j < len(b.Registers) {
+		switch {
+		case j >= len(b.Registers) || i < len(a.Registers) && a.Registers[i].Addr < b.Registers[j].Addr:
+			r := a.Registers[i]
+			changes = append(changes, Change{Addr: r.Addr, Kind: Removed, Old: &r.Value})
+			i++
+		case i >= len(a.Registers) || b.Registers[j].Addr < a.Registers[i].Addr:
+			r := b.Registers[j]
+			changes = append(changes, Change{Addr: r.Addr, Kind: Added, New: &r.Value})
+			j++
+		default:
+			ra, rb := a.Registers[i], b.Registers[j]
+			if ra.Value != rb.Value {
+				changes = append(changes, Change{Addr: ra.Addr, Kind: Changed, Old: &ra.Value, New: &rb.Value})
+			}
+			i++
+			j++
+		}
+	}
+	return changes
+}
+
+// DecodeChanges 使用寄存器映射找出发生变化的位域
+//
+// 只有两个快照都完整包含某个寄存器的所有字节时才解码该寄存器，
+// 多字节寄存器的位域变化记录在其中第一个发生变化的字节上。
+func DecodeChanges(m *regmap.Map, a, b *Snapshot, changes []Change) {
+	decoded := make(map[string]bool)
+	for i := range changes {
+		c := &changes[i]
+		if c.Kind != Changed {
+			continue
+		}
+
+		reg := m.Containing(c.Addr)
+		if reg == nil || decoded[reg.Name] {
+			continue
+		}
+		oldData, okA := a.Bytes(reg.Address, reg.Bytes())
+		newData, okB := b.Bytes(reg.Address, reg.Bytes())
+		if !okA || !okB {
+			continue
+		}
+		decoded[reg.Name] = true
+
+		oldDec, newDec := reg.Decode(oldData), reg.Decode(newData)
+		for k, fv := range oldDec.Fields {
+			nv := newDec.Fields[k]
+			if fv.Value == nv.Value {
+				continue
+			}
+			c.Fields = append(c.Fields, FieldChange{
+				Register:   reg.Name,
+				Name:       fv.Name,
+				Bits:       fv.Bits,
+				Old:        fv.Value,
+				New:        nv.Value,
+				OldMeaning: fv.Meaning,
+				NewMeaning: nv.Meaning,
+			})
+		}
+	}
+}
+
+// Bytes 返回从 addr 开始的 n 个连续寄存器，快照未完整包含时返回 false
+func (s *Snapshot) Bytes(addr uint8, n int) ([]byte, bool) {
+	data := make([]byte, n)
+	for i := range data {
+		if int(addr)+i > 0xFF {
+			return nil, false
+		}
+		v, ok := s.Value(addr + uint8(i))
+		if !ok {
+			return nil, false
+		}
+		data[i] = v
+	}
+	return data, true
+}
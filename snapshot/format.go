@@ -0,0 +1,118 @@
+package snapshot
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+func init() {
+	RegisterFormatter("json", FormatterFunc(formatJSON))
+	RegisterFormatter("csv", FormatterFunc(formatCSV))
+	RegisterFormatter("hex", FormatterFunc(formatHex))
+}
+
+func formatJSON(w io.Writer, s *Snapshot) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("JSON编码失败: %v", err)
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+// formatCSV 每个寄存器一行，提供寄存器映射时增加 Fields 列
+func formatCSV(w io.Writer, s *Snapshot) error {
+	fields := decodedFields(s)
+
+	cw := csv.NewWriter(w)
+	header := []string{"Register", "Value", "Decimal"}
+	if s.Decoded != nil {
+		header = append(header, "Fields")
+	}
+	cw.Write(header)
+
+	for _, r := range s.Registers {
+		record := []string{fmt.Sprintf("0x%02X", r.Addr), fmt.Sprintf("0x%02X", r.Value), fmt.Sprint(r.Value)}
+		if s.Decoded != nil {
+			record = append(record, strings.Join(fields[r.Addr], ";"))
+		}
+		cw.Write(record)
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// formatHex 以 hexdump -C 的布局输出，每行 16 个寄存器，行首为寄存器地址，
+// 行尾为 ASCII 形式；不在快照中的寄存器留空。
+func formatHex(w io.Writer, s *Snapshot) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# 设备地址: 0x%02X\n", s.DeviceAddr)
+	fmt.Fprintf(&b, "# 起始寄存器: 0x%02X\n", s.StartReg)
+	fmt.Fprintf(&b, "# 时间戳: %s\n", s.Timestamp)
+
+	var present [256]bool
+	var values [256]byte
+	for _, r := range s.Registers {
+		present[r.Addr] = true
+		values[r.Addr] = r.Value
+	}
+
+	for row := 0; row < 256; row += 16 {
+		empty := true
+		for i := row; i < row+16; i++ {
+			empty = empty && !present[i]
+		}
+		if empty {
+			continue
+		}
+
+		var ascii strings.Builder
+		fmt.Fprintf(&b, "%02X ", row)
+		for i := row; i < row+16; i++ {
+			if i == row+8 {
+				b.WriteByte(' ')
+			}
+			if !present[i] {
+				b.WriteString("   ")
+				ascii.WriteByte(' ')
+				continue
+			}
+			fmt.Fprintf(&b, " %02X", values[i])
+			if values[i] >= 0x20 && values[i] < 0x7F {
+				ascii.WriteByte(values[i])
+			} else {
+				ascii.WriteByte('.')
+			}
+		}
+		fmt.Fprintf(&b, "  |%s|\n", ascii.String())
+	}
+
+	for _, d := range s.Decoded {
+		fmt.Fprintf(&b, "# %s (0x%02X) = 0x%X\n", d.Register, d.Address, d.Value)
+		for _, fv := range d.Fields {
+			fmt.Fprintf(&b, "#   %s\n", fv)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// decodedFields 按寄存器起始地址整理解码后的位域描述
+func decodedFields(s *Snapshot) map[uint8][]string {
+	fields := make(map[uint8][]string)
+	for _, d := range s.Decoded {
+		if len(d.Fields) == 0 {
+			fields[d.Address] = append(fields[d.Address], fmt.Sprintf("%s = 0x%X", d.Register, d.Value))
+			continue
+		}
+		for _, fv := range d.Fields {
+			fields[d.Address] = append(fields[d.Address], fmt.Sprintf("%s.%s", d.Register, fv))
+		}
+	}
+	return fields
+}
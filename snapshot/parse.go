@@ -0,0 +1,172 @@
+package snapshot
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Load 从文件加载快照
+func Load(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取快照失败: %v", err)
+	}
+
+	s, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return s, nil
+}
+
+// Parse 解析 dump 命令输出的 JSON、CSV 或 hex 格式，格式根据内容自动识别。
+// 兼容旧版以 "data" 映射保存寄存器的 JSON 格式。
+func Parse(data []byte) (*Snapshot, error) {
+	trimmed := bytes.TrimSpace(data)
+
+	var (
+		s   *Snapshot
+		err error
+	)
+	switch {
+	case len(trimmed) == 0:
+		return nil, fmt.Errorf("快照内容为空")
+	case trimmed[0] == '{':
+		s, err = parseJSON(trimmed)
+	case bytes.HasPrefix(trimmed, []byte("Register,")):
+		s, err = parseCSV(trimmed)
+	default:
+		s, err = parseHex(trimmed)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.Sort()
+	return s, nil
+}
+
+func parseJSON(data []byte) (*Snapshot, error) {
+	var raw struct {
+		Snapshot
+		Data map[string]uint8 `json:"data"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("解析 JSON 快照失败: %v", err)
+	}
+
+	s := raw.Snapshot
+	for reg, value := range raw.Data {
+		addr, err := strconv.ParseUint(reg, 0, 8)
+		if err != nil {
+			return nil, fmt.Errorf("无效的寄存器地址 %q", reg)
+		}
+		s.Registers = append(s.Registers, Register{Addr: uint8(addr), Value: value})
+	}
+	return &s, nil
+}
+
+func parseCSV(data []byte) (*Snapshot, error) {
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("解析 CSV 快照失败: %v", err)
+	}
+
+	s := &Snapshot{}
+	for i, record := range records[1:] {
+		if len(record) < 2 {
+			return nil, fmt.Errorf("CSV 第 %d 行: 列数不足", i+2)
+		}
+		r, err := parseRegister(record[0], record[1])
+		if err != nil {
+			return nil, fmt.Errorf("CSV 第 %d 行: %v", i+2, err)
+		}
+		s.Registers = append(s.Registers, r)
+	}
+	if len(s.Registers) > 0 {
+		s.StartReg = s.Registers[0].Addr
+	}
+	return s, nil
+}
+
+// parseHex 解析 formatHex 输出的布局
+func parseHex(data []byte) (*Snapshot, error) {
+	s := &Snapshot{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimRight(scanner.Text(), " \r")
+		if text == "" {
+			continue
+		}
+
+		if strings.HasPrefix(text, "#") {
+			key, value, ok := strings.Cut(strings.TrimSpace(strings.TrimPrefix(text, "#")), ": ")
+			if !ok {
+				continue
+			}
+			switch key {
+			case "设备地址":
+				if v, err := strconv.ParseUint(value, 0, 8); err == nil {
+					s.DeviceAddr = uint8(v)
+				}
+			case "起始寄存器":
+				if v, err := strconv.ParseUint(value, 0, 8); err == nil {
+					s.StartReg = uint8(v)
+				}
+			case "时间戳":
+				s.Timestamp = value
+			}
+			continue
+		}
+
+		if i := strings.Index(text, "  |"); i >= 0 {
+			text = text[:i]
+		}
+		row, err := strconv.ParseUint(text[:min(2, len(text))], 16, 8)
+		if err != nil || len(text) < 3 || row%16 != 0 {
+			return nil, fmt.Errorf("无法识别的快照格式 (第 %d 行)", line)
+		}
+
+		// 第 i 列位于 4+3*i 处，第 8 列之前有额外的空格
+		for col := 0; col < 16; col++ {
+			pos := 4 + 3*col
+			if col >= 8 {
+				pos++
+			}
+			if pos+2 > len(text) {
+				break
+			}
+			cell := text[pos : pos+2]
+			if cell == "  " {
+				continue
+			}
+			value, err := strconv.ParseUint(cell, 16, 8)
+			if err != nil {
+				return nil, fmt.Errorf("第 %d 行: 无效的数据 %q", line, cell)
+			}
+			s.Registers = append(s.Registers, Register{Addr: uint8(int(row) + col), Value: uint8(value)})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func parseRegister(reg, value string) (Register, error) {
+	addr, err := strconv.ParseUint(strings.TrimSpace(reg), 0, 8)
+	if err != nil {
+		return Register{}, fmt.Errorf("无效的寄存器地址 %q", reg)
+	}
+	v, err := strconv.ParseUint(strings.TrimSpace(value), 0, 8)
+	if err != nil {
+		return Register{}, fmt.Errorf("无效的寄存器值 %q", value)
+	}
+	return Register{Addr: uint8(addr), Value: uint8(v)}, nil
+}
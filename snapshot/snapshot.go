@@ -0,0 +1,162 @@
+// Package snapshot 定义寄存器快照及其导出格式
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"sensorcli/regmap"
+)
+
+// Register 快照中的一个寄存器字节
+type Register struct {
+	Addr  uint8
+	Value uint8
+}
+
+// jsonRegister Register 的 JSON 形式，地址以 "0x00" 表示
+type jsonRegister struct {
+	Reg   string `json:"reg"`
+	Value uint8  `json:"value"`
+}
+
+// MarshalJSON 以 {"reg": "0x00", "value": 25} 形式编码
+func (r Register) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonRegister{Reg: fmt.Sprintf("0x%02X", r.Addr), Value: r.Value})
+}
+
+// UnmarshalJSON 解析 {"reg": "0x00", "value": 25}
+func (r *Register) UnmarshalJSON(data []byte) error {
+	var jr jsonRegister
+	if err := json.Unmarshal(data, &jr); err != nil {
+		return err
+	}
+	addr, err := strconv.ParseUint(jr.Reg, 0, 8)
+	if err != nil {
+		return fmt.Errorf("无效的寄存器地址 %q", jr.Reg)
+	}
+	r.Addr, r.Value = uint8(addr), jr.Value
+	return nil
+}
+
+// Snapshot 设备寄存器快照，寄存器按地址升序排列
+type Snapshot struct {
+	DeviceAddr uint8            `json:"device_addr"`
+	Bus        int              `json:"bus"`
+	StartReg   uint8            `json:"start_register"`
+	Timestamp  string           `json:"timestamp"`
+	Registers  []Register       `json:"registers"`
+	Decoded    []regmap.Decoded `json:"decoded,omitempty"`
+}
+
+// New 由从 start 开始连续读取的数据创建快照
+func New(bus int, addr, start uint8, data []byte) *Snapshot {
+	s := &Snapshot{
+		DeviceAddr: addr,
+		Bus:        bus,
+		StartReg:   start,
+		Timestamp:  time.Now().Format(time.RFC3339),
+		Registers:  make([]Register, len(data)),
+	}
+	for i, value := range data {
+		s.Registers[i] = Register{Addr: start + uint8(i), Value: value}
+	}
+	return s
+}
+
+// Sort 按寄存器地址升序排列，相同地址保留最后一个值
+func (s *Snapshot) Sort() {
+	sort.SliceStable(s.Registers, func(i, j int) bool {
+		return s.Registers[i].Addr < s.Registers[j].Addr
+	})
+
+	regs := s.Registers[:0]
+	for _, r := range s.Registers {
+		if n := len(regs); n > 0 && regs[n-1].Addr == r.Addr {
+			regs[n-1] = r
+			continue
+		}
+		regs = append(regs, r)
+	}
+	s.Registers = regs
+}
+
+// Value 返回寄存器的值
+func (s *Snapshot) Value(addr uint8) (uint8, bool) {
+	i := sort.Search(len(s.Registers), func(i int) bool { return s.Registers[i].Addr >= addr })
+	if i < len(s.Registers) && s.Registers[i].Addr == addr {
+		return s.Registers[i].Value, true
+	}
+	return 0, false
+}
+
+// Decode 使用寄存器映射解码快照中完整包含的寄存器
+func (s *Snapshot) Decode(m *regmap.Map) {
+	s.Decoded = nil
+	for _, run := range s.Runs() {
+		s.Decoded = append(s.Decoded, m.DecodeBytes(run.Start, run.Data)...)
+	}
+}
+
+// Run 一段地址连续的寄存器
+type Run struct {
+	Start uint8
+	Data  []byte
+}
+
+// Runs 将寄存器按地址连续性分段
+func (s *Snapshot) Runs() []Run {
+	var runs []Run
+	for i, r := range s.Registers {
+		if i > 0 && int(r.Addr) == int(s.Registers[i-1].Addr)+1 {
+			last := &runs[len(runs)-1]
+			last.Data = append(last.Data, r.Value)
+			continue
+		}
+		runs = append(runs, Run{Start: r.Addr, Data: []byte{r.Value}})
+	}
+	return runs
+}
+
+// Formatter 快照导出格式
+type Formatter interface {
+	Format(w io.Writer, s *Snapshot) error
+}
+
+// FormatterFunc 将函数适配为 Formatter
+type FormatterFunc func(w io.Writer, s *Snapshot) error
+
+// Format 实现 Formatter
+func (f FormatterFunc) Format(w io.Writer, s *Snapshot) error {
+	return f(w, s)
+}
+
+var formatters = make(map[string]Formatter)
+
+// RegisterFormatter 注册导出格式，同名格式会被替换
+func RegisterFormatter(name string, f Formatter) {
+	formatters[name] = f
+}
+
+// LookupFormatter 按名称查找导出格式
+func LookupFormatter(name string) (Formatter, error) {
+	f, ok := formatters[name]
+	if !ok {
+		return nil, fmt.Errorf("不支持的输出格式: %s (可用格式: %v)", name, Formats())
+	}
+	return f, nil
+}
+
+// Formats 返回所有已注册格式的名称
+func Formats() []string {
+	names := make([]string, 0, len(formatters))
+	for name := range formatters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,199 @@
+package snapshot
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"sensorcli/regmap"
+)
+
+func format(t *testing.T, name string, s *Snapshot) string {
+	t.Helper()
+
+	f, err := LookupFormatter(name)
+	if err != nil {
+		t.Fatalf("查找格式 %s 失败: %v", name, err)
+	}
+	var buf bytes.Buffer
+	if err := f.Format(&buf, s); err != nil {
+		t.Fatalf("%s 格式输出失败: %v", name, err)
+	}
+	return buf.String()
+}
+
+func TestCSVIsOrdered(t *testing.T) {
+	data := make([]byte, 32)
+	for i := range data {
+		data[i] = byte(i * 3)
+	}
+	s := New(1, 0x48, 0x10, data)
+
+	// 多次输出结果一致且按地址排列
+	first := format(t, "csv", s)
+	for i := 0; i < 5; i++ {
+		if got := format(t, "csv", s); got != first {
+			t.Fatal("CSV 输出不稳定")
+		}
+	}
+
+	lines := strings.Split(strings.TrimSpace(first), "\n")
+	if len(lines) != 33 || lines[0] != "Register,Value,Decimal" {
+		t.Fatalf("CSV 表头或行数错误:\n%s", first)
+	}
+	if lines[1] != "0x10,0x00,0" || lines[32] != "0x2F,0x5D,93" {
+		t.Errorf("CSV 行顺序错误: %q ... %q", lines[1], lines[32])
+	}
+}
+
+func TestHexLayout(t *testing.T) {
+	s := New(1, 0x48, 0x0E, []byte{0x41, 0x42, 0x00, 0x7F})
+	s.Timestamp = "2026-01-01T00:00:00Z"
+
+	want := `# 设备地址: 0x48
+# 起始寄存器: 0x0E
+# 时间戳: 2026-01-01T00:00:00Z
+00                                             41 42  |              AB|
+10  00 7F                                             |..              |
+`
+	if got := format(t, "hex", s); got != want {
+		t.Errorf("hex 输出错误:\n%s\n期望:\n%s", got, want)
+	}
+}
+
+func TestDecodedCSV(t *testing.T) {
+	m, err := regmap.Builtin("tmp102")
+	if err != nil {
+		t.Fatalf("加载映射失败: %v", err)
+	}
+
+	s := New(1, 0x48, 0x00, []byte{0x19, 0x60})
+	s.Decode(m)
+
+	lines := strings.Split(strings.TrimSpace(format(t, "csv", s)), "\n")
+	if lines[0] != "Register,Value,Decimal,Fields" || !strings.Contains(lines[2], "CONFIG.RESOLUTION[6:5] = 3 (12-bit)") {
+		t.Errorf("解码后的 CSV 错误: %v", lines)
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	s := New(1, 0x48, 0xFE, []byte{0x01, 0x02})
+
+	var decoded Snapshot
+	if err := json.Unmarshal([]byte(format(t, "json", s)), &decoded); err != nil {
+		t.Fatalf("解析 JSON 失败: %v", err)
+	}
+	if len(decoded.Registers) != 2 || decoded.Registers[1] != (Register{Addr: 0xFF, Value: 0x02}) {
+		t.Errorf("JSON 往返结果错误: %+v", decoded.Registers)
+	}
+}
+
+func TestSortAndRuns(t *testing.T) {
+	s := &Snapshot{Registers: []Register{
+		{Addr: 0x05, Value: 5}, {Addr: 0x01, Value: 1}, {Addr: 0x02, Value: 2}, {Addr: 0x01, Value: 9},
+	}}
+	s.Sort()
+
+	if v, ok := s.Value(0x01); !ok || v != 9 || len(s.Registers) != 3 {
+		t.Errorf("重复地址应保留最后一个值: %+v", s.Registers)
+	}
+
+	runs := s.Runs()
+	if len(runs) != 2 || runs[0].Start != 0x01 || len(runs[0].Data) != 2 || runs[1].Start != 0x05 {
+		t.Errorf("分段错误: %+v", runs)
+	}
+}
+
+func TestRegisterFormatter(t *testing.T) {
+	RegisterFormatter("count", FormatterFunc(func(w io.Writer, s *Snapshot) error {
+		_, err := io.WriteString(w, strings.Repeat("#", len(s.Registers)))
+		return err
+	}))
+	defer delete(formatters, "count")
+
+	if got := format(t, "count", New(1, 0x48, 0x00, []byte{1, 2, 3})); got != "###" {
+		t.Errorf("自定义格式输出错误: %q", got)
+	}
+	if _, err := LookupFormatter("xml"); err == nil {
+		t.Error("未注册的格式应该失败")
+	}
+}
+
+func TestParseRoundTrip(t *testing.T) {
+	data := make([]byte, 20)
+	for i := range data {
+		data[i] = byte(0xA0 + i)
+	}
+	s := New(1, 0x48, 0x0C, data)
+
+	for _, name := range []string{"json", "csv", "hex"} {
+		parsed, err := Parse([]byte(format(t, name, s)))
+		if err != nil {
+			t.Errorf("解析 %s 格式失败: %v", name, err)
+			continue
+		}
+		if len(parsed.Registers) != len(s.Registers) {
+			t.Errorf("%s 格式: 期望 %d 个寄存器，实际 %d", name, len(s.Registers), len(parsed.Registers))
+			continue
+		}
+		for i, r := range parsed.Registers {
+			if r != s.Registers[i] {
+				t.Errorf("%s 格式: 第 %d 个寄存器 %+v，期望 %+v", name, i, r, s.Registers[i])
+				break
+			}
+		}
+		if name != "csv" && (parsed.DeviceAddr != 0x48 || parsed.StartReg != 0x0C) {
+			t.Errorf("%s 格式: 设备信息错误 %+v", name, parsed)
+		}
+	}
+}
+
+func TestParseLegacyJSON(t *testing.T) {
+	s, err := Parse([]byte(`{"device_addr": 72, "start_register": 0, "data": {"0x02": 75, "0x00": 25, "0x01": 96}}`))
+	if err != nil {
+		t.Fatalf("解析旧版 JSON 失败: %v", err)
+	}
+	if len(s.Registers) != 3 || s.Registers[0] != (Register{Addr: 0x00, Value: 25}) || s.Registers[2].Addr != 0x02 {
+		t.Errorf("旧版 JSON 解析结果错误: %+v", s.Registers)
+	}
+
+	for _, bad := range []string{"", "{", "Register,Value\nzz,1", "hello world"} {
+		if _, err := Parse([]byte(bad)); err == nil {
+			t.Errorf("解析 %q 应该失败", bad)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	a := New(1, 0x48, 0x00, []byte{0x19, 0x60, 0x4B, 0x50})
+	b := New(1, 0x48, 0x01, []byte{0x40, 0x4B, 0x50, 0x00})
+
+	changes := Diff(a, b)
+	if len(changes) != 3 {
+		t.Fatalf("期望 3 处差异，实际 %+v", changes)
+	}
+	if c := changes[0]; c.Addr != 0x00 || c.Kind != Removed || *c.Old != 0x19 || c.New != nil {
+		t.Errorf("0x00 应为删除: %+v", c)
+	}
+	if c := changes[1]; c.Addr != 0x01 || c.Kind != Changed || *c.Old != 0x60 || *c.New != 0x40 {
+		t.Errorf("0x01 应为修改: %+v", c)
+	}
+	if c := changes[2]; c.Addr != 0x04 || c.Kind != Added || *c.New != 0x00 {
+		t.Errorf("0x04 应为新增: %+v", c)
+	}
+	if len(Diff(a, a)) != 0 {
+		t.Error("相同快照不应有差异")
+	}
+
+	m, err := regmap.Builtin("tmp102")
+	if err != nil {
+		t.Fatalf("加载映射失败: %v", err)
+	}
+	DecodeChanges(m, a, b, changes)
+	fields := changes[1].Fields
+	if len(fields) != 1 || fields[0].Name != "RESOLUTION" || fields[0].Old != 3 || fields[0].New != 2 || fields[0].NewMeaning != "11-bit" {
+		t.Errorf("位域差异错误: %+v", fields)
+	}
+}
@@ -0,0 +1,65 @@
+package trace
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Summary 跟踪文件的统计
+type Summary struct {
+	Packets      int
+	Transactions int
+	NACKs        int // 失败的事务数
+	Duration     time.Duration
+}
+
+// WriteText 以文本形式逐条输出数据包，返回统计信息
+//
+// 每行依次为序号、相对第一个数据包的时间、与上一事务的间隔、总线、
+// 起始条件 (S 为起始，Sr 为重复起始)、地址、读写方向和数据。
+func WriteText(w io.Writer, rd *Reader) (Summary, error) {
+	var sum Summary
+	var first, last time.Time
+
+	fmt.Fprintf(w, "%5s  %-11s %-11s %-4s %-3s %-5s %-2s %s\n", "#", "时间(s)", "间隔", "总线", "起始", "地址", "方向", "数据")
+	for {
+		p, err := rd.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return sum, err
+		}
+
+		sum.Packets++
+		if sum.Packets == 1 {
+			first, last = p.Time, p.Time
+		}
+		start, gap := "Sr", ""
+		if !p.Restart() {
+			start = "S"
+			gap = fmt.Sprintf("+%.6f", p.Time.Sub(last).Seconds())
+			last = p.Time
+			sum.Transactions++
+			if p.NACK() {
+				sum.NACKs++
+			}
+		}
+		dir := "W"
+		if p.Read() {
+			dir = "R"
+		}
+
+		line := fmt.Sprintf("%5d  %-11.6f %-11s %-4d %-3s 0x%02X  %-2s %s",
+			sum.Packets, p.Time.Sub(first).Seconds(), gap, p.Bus, start, p.Addr, dir, fmt.Sprintf("% X", p.Data))
+		if p.NACK() {
+			line += "  NACK"
+		}
+		fmt.Fprintln(w, strings.TrimRight(line, " "))
+	}
+
+	sum.Duration = last.Sub(first)
+	return sum, nil
+}
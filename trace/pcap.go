@@ -0,0 +1,251 @@
+// Package trace 把 I2C 总线事务导出为 pcap 文件，供 Wireshark 打开
+//
+// 文件使用 Linux I2C 链路类型 (LINKTYPE_I2C_LINUX, 209)。每条 I2C 消息是一个数据包，
+// 包头为 1 字节总线号和 4 字节大端标志位，随后是地址字节 (7 位地址 << 1 | R/W) 和数据。
+package trace
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"sensorcli/i2c"
+)
+
+// LinkTypeI2CLinux pcap 中 Linux I2C 的链路类型
+const LinkTypeI2CLinux = 209
+
+// 数据包标志位，低 16 位与 Linux 的 I2C_M_* 一致，高 16 位为 sensorcli 的扩展
+const (
+	// FlagRead 读消息 (I2C_M_RD)
+	FlagRead uint32 = 0x0001
+
+	// FlagNACK 所在事务失败，设备未应答或传输出错
+	FlagNACK uint32 = 0x00010000
+
+	// FlagRestart 消息以重复起始条件开始，否则以起始条件开始
+	FlagRestart uint32 = 0x00020000
+)
+
+const (
+	magicMicro = 0xa1b2c3d4
+	magicNano  = 0xa1b23c4d
+	snapLen    = 65535
+	headerLen  = 5 // 总线号 + 标志位
+)
+
+// Packet 一条 I2C 消息
+type Packet struct {
+	Time  time.Time
+	Bus   int
+	Flags uint32
+	Addr  uint8 // 7 位地址
+	Data  []byte
+}
+
+// Read 是否为读消息
+func (p Packet) Read() bool {
+	return p.Flags&FlagRead != 0
+}
+
+// NACK 所在事务是否失败
+func (p Packet) NACK() bool {
+	return p.Flags&FlagNACK != 0
+}
+
+// Restart 是否以重复起始条件开始
+func (p Packet) Restart() bool {
+	return p.Flags&FlagRestart != 0
+}
+
+// Writer 把事务写成 pcap 数据包，实现 i2c.Tracer
+type Writer struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+	err    error
+	count  int
+}
+
+// NewWriter 写入 pcap 文件头并返回 Writer
+func NewWriter(w io.Writer) (*Writer, error) {
+	hdr := make([]byte, 24)
+	binary.LittleEndian.PutUint32(hdr[0:], magicMicro)
+	binary.LittleEndian.PutUint16(hdr[4:], 2)
+	binary.LittleEndian.PutUint16(hdr[6:], 4)
+	binary.LittleEndian.PutUint32(hdr[16:], snapLen)
+	binary.LittleEndian.PutUint32(hdr[20:], LinkTypeI2CLinux)
+	if _, err := w.Write(hdr); err != nil {
+		return nil, fmt.Errorf("写入 pcap 文件头失败: %v", err)
+	}
+	return &Writer{w: w}, nil
+}
+
+// Create 创建 pcap 文件，Close 时关闭文件
+func Create(path string) (*Writer, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("创建跟踪文件失败: %v", err)
+	}
+	w, err := NewWriter(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	w.closer = f
+	return w, nil
+}
+
+// Trace 把事务中的每条消息写成一个数据包，写入错误在 Close 时返回
+func (w *Writer) Trace(tx i2c.Transaction) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return
+	}
+
+	for i, msg := range tx.Msgs {
+		p := Packet{
+			Time: tx.Start,
+			Bus:  tx.Bus,
+			Addr: uint8(msg.Addr),
+			Data: msg.Buf,
+		}
+		if msg.Flags&i2c.MsgRead != 0 {
+			p.Flags |= FlagRead
+		}
+		if i > 0 {
+			p.Flags |= FlagRestart
+		}
+		if tx.Err != nil {
+			p.Flags |= FlagNACK
+		}
+		if err := w.writePacket(p); err != nil {
+			w.err = fmt.Errorf("写入跟踪文件失败: %v", err)
+			return
+		}
+	}
+}
+
+func (w *Writer) writePacket(p Packet) error {
+	n := headerLen + 1 + len(p.Data)
+	buf := make([]byte, 16+n)
+	binary.LittleEndian.PutUint32(buf[0:], uint32(p.Time.Unix()))
+	binary.LittleEndian.PutUint32(buf[4:], uint32(p.Time.Nanosecond()/1000))
+	binary.LittleEndian.PutUint32(buf[8:], uint32(n))
+	binary.LittleEndian.PutUint32(buf[12:], uint32(n))
+
+	buf[16] = uint8(p.Bus)
+	binary.BigEndian.PutUint32(buf[17:], p.Flags)
+	buf[21] = p.Addr << 1
+	if p.Read() {
+		buf[21] |= 1
+	}
+	copy(buf[22:], p.Data)
+
+	if _, err := w.w.Write(buf); err != nil {
+		return err
+	}
+	w.count++
+	return nil
+}
+
+// Count 已写入的数据包数
+func (w *Writer) Count() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.count
+}
+
+// Close 关闭文件，返回写入过程中的第一个错误
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	err := w.err
+	if w.closer != nil {
+		if cerr := w.closer.Close(); err == nil && cerr != nil {
+			err = fmt.Errorf("关闭跟踪文件失败: %v", cerr)
+		}
+		w.closer = nil
+	}
+	return err
+}
+
+// Reader 读取 Linux I2C 链路类型的 pcap 文件
+type Reader struct {
+	r     io.Reader
+	order binary.ByteOrder
+	nano  bool
+}
+
+// NewReader 读取并校验 pcap 文件头
+func NewReader(r io.Reader) (*Reader, error) {
+	hdr := make([]byte, 24)
+	if _, err := io.ReadFull(r, hdr); err != nil {
+		return nil, fmt.Errorf("读取 pcap 文件头失败: %v", err)
+	}
+
+	rd := &Reader{r: r}
+	switch {
+	case binary.LittleEndian.Uint32(hdr) == magicMicro:
+		rd.order = binary.LittleEndian
+	case binary.BigEndian.Uint32(hdr) == magicMicro:
+		rd.order = binary.BigEndian
+	case binary.LittleEndian.Uint32(hdr) == magicNano:
+		rd.order, rd.nano = binary.LittleEndian, true
+	case binary.BigEndian.Uint32(hdr) == magicNano:
+		rd.order, rd.nano = binary.BigEndian, true
+	default:
+		return nil, fmt.Errorf("不是 pcap 文件 (pcapng 格式请先用 editcap -F pcap 转换)")
+	}
+
+	if link := rd.order.Uint32(hdr[20:]) & 0x0FFFFFFF; link != LinkTypeI2CLinux {
+		return nil, fmt.Errorf("不支持的链路类型 %d，需要 Linux I2C (%d)", link, LinkTypeI2CLinux)
+	}
+	return rd, nil
+}
+
+// Next 读取下一个数据包，没有更多数据包时返回 io.EOF
+func (rd *Reader) Next() (Packet, error) {
+	rec := make([]byte, 16)
+	if _, err := io.ReadFull(rd.r, rec); err != nil {
+		if err == io.EOF {
+			return Packet{}, io.EOF
+		}
+		return Packet{}, fmt.Errorf("读取数据包头失败: %v", err)
+	}
+
+	sec := int64(rd.order.Uint32(rec[0:]))
+	frac := int64(rd.order.Uint32(rec[4:]))
+	if !rd.nano {
+		frac *= 1000
+	}
+	n := rd.order.Uint32(rec[8:])
+	if n > snapLen {
+		return Packet{}, fmt.Errorf("数据包长度无效: %d", n)
+	}
+
+	data := make([]byte, n)
+	if _, err := io.ReadFull(rd.r, data); err != nil {
+		return Packet{}, fmt.Errorf("读取数据包失败: %v", err)
+	}
+	if n < headerLen+1 {
+		return Packet{}, fmt.Errorf("数据包过短: %d 字节", n)
+	}
+
+	p := Packet{
+		Time:  time.Unix(sec, frac),
+		Bus:   int(data[0]),
+		Flags: binary.BigEndian.Uint32(data[1:]),
+		Addr:  data[5] >> 1,
+		Data:  data[6:],
+	}
+	// 其他工具生成的文件可能只在地址字节中标记读写方向
+	if data[5]&1 != 0 {
+		p.Flags |= FlagRead
+	}
+	return p, nil
+}
@@ -0,0 +1,125 @@
+package trace
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"sensorcli/i2c"
+)
+
+func TestTracingDeviceRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock := i2c.NewMockDevice(&i2c.DeviceConfig{Bus: 2, Address: 0x48, MockMode: true})
+	if err := mock.SetFaults(&i2c.FaultConfig{Rules: []i2c.FaultRule{{Op: i2c.OpRead, Kind: i2c.FaultNACK, Nth: []int{2}}}}); err != nil {
+		t.Fatal(err)
+	}
+	device := i2c.NewTracingDevice(mock, w)
+	if err := device.WriteBytes(0x01, []byte{0x60, 0xA0}); err != nil {
+		t.Fatalf("写入失败: %v", err)
+	}
+	if _, err := device.ReadBytes(0x01, 2); err != nil {
+		t.Fatalf("读取失败: %v", err)
+	}
+	if _, err := device.ReadRegister(0x01); err == nil {
+		t.Fatal("第二次读取应注入未应答")
+	}
+
+	// 装饰后仍然支持原始传输，可以用于 SMBus
+	bus, err := i2c.NewSMBus(device)
+	if err != nil {
+		t.Fatalf("装饰后的设备应支持原始传输: %v", err)
+	}
+	if err := bus.WriteByteData(0x02, 0x55); err != nil {
+		t.Fatalf("SMBus 写入失败: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 文件头: 微秒时间戳，链路类型 209
+	hdr := buf.Bytes()
+	if binary.LittleEndian.Uint32(hdr) != magicMicro || binary.LittleEndian.Uint32(hdr[20:]) != LinkTypeI2CLinux {
+		t.Fatalf("文件头错误: % X", hdr[:24])
+	}
+
+	want := []struct {
+		flags uint32
+		data  string
+	}{
+		{0, "01 60 A0"},
+		{0, "01"},
+		{FlagRead | FlagRestart, "60 A0"},
+		{FlagNACK, "01"},
+		{FlagRead | FlagRestart | FlagNACK, ""},
+		{0, "02 55"},
+	}
+	rd, err := NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, wp := range want {
+		p, err := rd.Next()
+		if err != nil {
+			t.Fatalf("数据包 %d: %v", i+1, err)
+		}
+		if p.Bus != 2 || p.Addr != 0x48 || p.Flags != wp.flags || fmt.Sprintf("% X", p.Data) != wp.data {
+			t.Errorf("数据包 %d = 总线 %d 地址 0x%02X 标志 0x%X 数据 %q，期望标志 0x%X 数据 %q",
+				i+1, p.Bus, p.Addr, p.Flags, fmt.Sprintf("% X", p.Data), wp.flags, wp.data)
+		}
+	}
+	if _, err := rd.Next(); err != io.EOF {
+		t.Errorf("应没有更多数据包，得到 %v", err)
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	var buf bytes.Buffer
+	w, _ := NewWriter(&buf)
+	device := i2c.NewTracingDevice(i2c.NewMockDevice(&i2c.DeviceConfig{Bus: 1, Address: 0x48, MockMode: true}), w)
+	device.WriteRegister(0x02, 0x55)
+	device.ReadRegister(0x02)
+
+	rd, err := NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	sum, err := WriteText(&out, rd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum.Packets != 3 || sum.Transactions != 2 || sum.NACKs != 0 {
+		t.Errorf("统计错误: %+v", sum)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("输出行数 %d，期望 4:\n%s", len(lines), out.String())
+	}
+	for i, want := range []string{"S   0x48  W  02 55", "S   0x48  W  02", "Sr  0x48  R  55"} {
+		if !strings.HasSuffix(lines[i+1], want) {
+			t.Errorf("第 %d 行 %q 应以 %q 结尾", i+2, lines[i+1], want)
+		}
+	}
+}
+
+func TestReaderRejectsOtherLinkType(t *testing.T) {
+	hdr := make([]byte, 24)
+	binary.LittleEndian.PutUint32(hdr, magicMicro)
+	binary.LittleEndian.PutUint32(hdr[20:], 1) // Ethernet
+	if _, err := NewReader(bytes.NewReader(hdr)); err == nil {
+		t.Error("应拒绝非 I2C 链路类型")
+	}
+	if _, err := NewReader(strings.NewReader("not a pcap file at all..")); err == nil {
+		t.Error("应拒绝非 pcap 文件")
+	}
+}